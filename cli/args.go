@@ -8,7 +8,7 @@ type Arguments struct {
 	Verbose bool `arg:"-v,help:toggle verbose"`
 
 	// DSN is the database string (ie, pgsql://user@blah:localhost:5432/dbname?args=)
-	DSNS internal.DSNS `arg:"positional,required,help:data source name"`
+	DSNS internal.DSNS `arg:"positional,help:data source name"`
 
 	// Schema is the name of the schema to query.
 	Schema string `arg:"-s,help:schema name to generate Go types for"`
@@ -101,6 +101,10 @@ type Arguments struct {
 	// QueryAllowNulls indicates that custom query results can contain null types.
 	QueryAllowNulls bool `arg:"--query-allow-nulls,-U,help:use query column NULL state"`
 
+	// QueryDir is a directory of .sql files, or a .sql file, of annotated
+	// queries to generate Go types and funcs from.
+	QueryDir string `arg:"--query-dir,help:directory or file of annotated .sql queries to generate Go types and funcs from"`
+
 	// EscapeAll toggles escaping schema, table, and column names in SQL queries.
 	EscapeAll bool `arg:"--escape-all,-X,help:escape all names in SQL queries"`
 
@@ -132,6 +136,45 @@ type Arguments struct {
 	// enable entension.
 	EnableExtension bool `arg:"--enable-extension,help:enable entension block"`
 
+	// EnableContext toggles generating context-aware Storage methods.
+	EnableContext bool `arg:"--enable-context,help:generate Storage methods taking a context.Context and XODBContext"`
+
+	// EnableDataLoader toggles batching the foreign key lookups of the GraphQL resolvers.
+	EnableDataLoader bool `arg:"--enable-dataloader,help:batch foreign key lookups of GraphQL resolvers"`
+
+	// EnableMock toggles generating MockStorage, a mock of the Storage interface.
+	EnableMock bool `arg:"--enable-mock,help:generate MockStorage mocking the Storage interface"`
+
+	// EnableMemory toggles generating MemoryStorage, an in-memory Storage
+	// registered as the memory driver.
+	EnableMemory bool `arg:"--enable-memory,help:generate MemoryStorage keeping the rows in memory as the memory driver"`
+
+	// SchemaCheckWarn toggles warning, instead of failing, when the schemas
+	// of the dsns differ.
+	SchemaCheckWarn bool `arg:"--schema-check-warn,help:warn instead of failing when the schemas of the dsns differ"`
+
+	// DeletedColumn is the soft delete column, the rows where it is not
+	// null are the deleted ones.
+	DeletedColumn string `arg:"--deleted-column,help:soft delete column of tables or - to disable [default: deleted_date]"`
+
+	// CreatedColumn is the creation time column ordering GetMostRecentX.
+	CreatedColumn string `arg:"--created-column,help:creation time column of tables or - to disable [default: created_date]"`
+
+	// ChangedColumn is the change time column ordering GetMostRecentChangedX.
+	ChangedColumn string `arg:"--changed-column,help:change time column of tables or - to disable [default: changed_date]"`
+
+	// SoftDelete toggles DeleteX setting the soft delete column instead of
+	// deleting the rows.
+	SoftDelete bool `arg:"--soft-delete,help:delete by setting the soft delete column of tables having it"`
+
 	// extra rules configuration file path
 	ExtraRuleFile string `arg:"--extra-rule,help:extra rules configuration file path"`
+
+	// ExtraRule is the extra rules declared in a config file, ExtraRuleFile
+	// takes precedence over it.
+	ExtraRule *internal.ExtraRule `arg:"-"`
+
+	// TypeOverrides are the Go types of database types and columns declared
+	// in a config file.
+	TypeOverrides []*internal.TypeOverride `arg:"-"`
 }
@@ -52,11 +52,8 @@ func Generate(arguments Arguments) error {
 		args.LoaderType = driver
 		args.Loader = loader
 
-		if db, ok := args.DBS[driver]; ok {
-			args.DB = db
-		} else {
-			return errors.New("not support " + driver)
-		}
+		// DDL files and snapshots are loaded without a database
+		args.DB = args.DBS[driver]
 
 		// load schema name
 		args.Schema, err = loader.SchemaName(args)
@@ -75,6 +72,12 @@ func Generate(arguments Arguments) error {
 		}
 	}
 
+	// check the schemas of the dsns are the same
+	err = checkSchemaDefinitions(args)
+	if err != nil {
+		return err
+	}
+
 	// add schema definitions
 	err = loadSchemaDefinition(args)
 	if err != nil {
@@ -94,6 +97,7 @@ func Generate(arguments Arguments) error {
 	}
 
 	// output
+	files = map[string]*os.File{}
 	err = writeTypes(args)
 	if err != nil {
 		return err
@@ -169,8 +173,16 @@ func processArgs(args *internal.ArgType) error {
 		args.Filename = args.Package + args.Suffix
 	}
 
+	// annotated query files
+	if args.QueryDir != "" {
+		if args.Query != "" {
+			return errors.New("query and query dir cannot be used together")
+		}
+		args.QueryMode = true
+	}
+
 	// if query mode toggled, but no query, read Stdin.
-	if args.QueryMode && args.Query == "" {
+	if args.QueryMode && args.Query == "" && args.QueryDir == "" {
 		buf, err := ioutil.ReadAll(os.Stdin)
 		if err != nil {
 			return err
@@ -184,7 +196,7 @@ func processArgs(args *internal.ArgType) error {
 	}
 
 	// check that query type was specified
-	if args.QueryMode && args.QueryType == "" {
+	if args.QueryMode && args.QueryType == "" && args.QueryDir == "" {
 		return errors.New("query type must be supplied for query parsing mode")
 	}
 
@@ -212,6 +224,9 @@ func processArgs(args *internal.ArgType) error {
 		if err := yaml.Unmarshal(ruleData, &extraRule); err != nil {
 			return err
 		}
+		args.ExtraRule = &extraRule
+	}
+	if extraRule := args.ExtraRule; extraRule != nil {
 		// pre process extra filter into map with key: field@table
 		for _, table := range extraRule.ExtraFilters {
 			if !table.Enable {
@@ -233,6 +248,11 @@ func processArgs(args *internal.ArgType) error {
 			}
 		}
 	}
+	// type overrides
+	if err := args.ValidateTypeOverrides(); err != nil {
+		return err
+	}
+
 	// if verbose
 	if args.Verbose {
 		models.XOLog = func(s string, p ...interface{}) {
@@ -258,6 +278,34 @@ func openDB(args *internal.ArgType) error {
 	}
 
 	for _, dsn := range args.DSNS {
+		// load schema snapshots
+		if loaders.IsSnapshotSource(dsn) {
+			snapshot, err := loaders.ReadSnapshot(dsn)
+			if err != nil {
+				return err
+			}
+			for _, d := range snapshot.Drivers {
+				loader, err := d.Loader()
+				if err != nil {
+					return err
+				}
+				args.LoaderTypes = append(args.LoaderTypes, d.Driver)
+				args.Loaders[d.Driver] = loader
+			}
+			continue
+		}
+
+		// load DDL files
+		if loaders.IsDDLSource(dsn) {
+			driver, loader, err := loaders.DDLLoader(dsn)
+			if err != nil {
+				return err
+			}
+			args.LoaderTypes = append(args.LoaderTypes, driver)
+			args.Loaders[driver] = loader
+			continue
+		}
+
 		// parse dsn
 		u, err := dburl.Parse(dsn)
 		if err != nil {
@@ -296,12 +344,33 @@ func openDB(args *internal.ArgType) error {
 	return nil
 }
 
+// checkSchemaDefinitions checks that the schema definitions of all drivers
+// are the same as the first one, the code is generated from. The
+// differences fail the generation, or are only reported with
+// SchemaCheckWarn.
+func checkSchemaDefinitions(args *internal.ArgType) error {
+	diffs := internal.CheckSchemaDefinitions(args.LoaderTypes, args.SchemaDefinition)
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	if args.SchemaCheckWarn {
+		for _, d := range diffs {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", d)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("the schemas of the dsns differ (use --schema-check-warn to ignore):\n  %s", strings.Join(diffs, "\n  "))
+}
+
 func loadSchemaDefinition(args *internal.ArgType) error {
 	if len(args.SchemaDefinition) == 0 || len(args.LoaderTypes) == 0 {
 		return nil
 	}
 
-	// use 1st element as schema definition, it should be checked later.
+	// use 1st element as schema definition, the others are checked to be the
+	// same by checkSchemaDefinitions.
 	drivers := args.LoaderTypes
 	firstDefinition := args.SchemaDefinition[drivers[0]]
 
@@ -311,6 +380,7 @@ func loadSchemaDefinition(args *internal.ArgType) error {
 		Foreign: firstDefinition.Foreign,
 		Indexes: firstDefinition.Indexes,
 		Drivers: drivers,
+		Enums:   firstDefinition.Enums,
 		TypeMap: args.TypeMap,
 	}
 
@@ -320,6 +390,22 @@ func loadSchemaDefinition(args *internal.ArgType) error {
 		return err
 	}
 
+	// add storage mock
+	if args.EnableMock {
+		err = args.ExecuteTemplate(internal.StorageMockTemplate, "storage_mock", "", definition)
+		if err != nil {
+			return err
+		}
+	}
+
+	// add memory storage
+	if args.EnableMemory {
+		err = args.ExecuteTemplate(internal.StorageMemoryTemplate, "storage_memory", "", definition)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -328,7 +414,8 @@ func loadExtension(args *internal.ArgType) error {
 		return nil
 	}
 
-	// use 1st element as schema definition, it should be checked later.
+	// use 1st element as schema definition, the others are checked to be the
+	// same by checkSchemaDefinitions.
 	driver := args.LoaderTypes[0]
 	firstDefinition := args.SchemaDefinition[driver]
 
@@ -383,6 +470,7 @@ func NewDefaultInternalArgs(arguments Arguments) *internal.ArgType {
 		QueryParamDelimiter:       arguments.QueryParamDelimiter,
 		QueryFields:               arguments.QueryFields,
 		QueryAllowNulls:           arguments.QueryAllowNulls,
+		QueryDir:                  arguments.QueryDir,
 		EscapeAll:                 arguments.EscapeAll,
 		EscapeSchemaName:          arguments.EscapeSchemaName,
 		EscapeTableNames:          arguments.EscapeTableNames,
@@ -393,7 +481,18 @@ func NewDefaultInternalArgs(arguments Arguments) *internal.ArgType {
 		Tags:                      arguments.Tags,
 		EnableAC:                  arguments.EnableAC,
 		EnableExtension:           arguments.EnableExtension,
+		EnableContext:             arguments.EnableContext,
+		EnableDataLoader:          arguments.EnableDataLoader,
+		EnableMock:                arguments.EnableMock,
+		EnableMemory:              arguments.EnableMemory,
+		SchemaCheckWarn:           arguments.SchemaCheckWarn,
+		DeletedColumn:             arguments.DeletedColumn,
+		CreatedColumn:             arguments.CreatedColumn,
+		ChangedColumn:             arguments.ChangedColumn,
+		SoftDelete:                arguments.SoftDelete,
 		ExtraRuleFile:             arguments.ExtraRuleFile,
+		ExtraRule:                 arguments.ExtraRule,
+		TypeOverrides:             arguments.TypeOverrides,
 
 		// KnownTypeMap is the collection of known Go types.
 		KnownTypeMap: map[string]bool{
@@ -442,6 +541,7 @@ func NewDefaultInternalArgs(arguments Arguments) *internal.ArgType {
 		Loaders:          map[string]internal.Loader{},
 		SchemaDefinition: map[string]internal.SchemaDefinition{},
 		TypeMap:          map[string]bool{},
+		EnumMap:          map[string]*internal.Enum{},
 		ScopeDupes:       map[string]map[string]struct{}{},
 	}
 
@@ -464,6 +564,15 @@ func NewDefaultInternalArgs(arguments Arguments) *internal.ArgType {
 	if args.NameConflictSuffix == "" {
 		args.NameConflictSuffix = "Val"
 	}
+	if args.DeletedColumn == "" {
+		args.DeletedColumn = "deleted_date"
+	}
+	if args.CreatedColumn == "" {
+		args.CreatedColumn = "created_date"
+	}
+	if args.ChangedColumn == "" {
+		args.ChangedColumn = "changed_date"
+	}
 
 	return args
 }
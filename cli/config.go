@@ -0,0 +1,385 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/alexflint/go-arg"
+	"github.com/ghodss/yaml"
+	"github.com/xo/xo/internal"
+)
+
+// DefaultConfigFile is the configuration file used by the generate command
+// when none is given.
+const DefaultConfigFile = "xo.yaml"
+
+// Config is a xo project configuration, it declares in one file what would
+// otherwise be passed to several xo invocations. Relative paths are relative
+// to the working directory.
+type Config struct {
+	// DSNs are the data sources the schema is generated from.
+	DSNs []string `json:"dsns"`
+
+	// Schema is the name of the schema to generate Go types for.
+	Schema string `json:"schema"`
+
+	// Out is the output directory, or the output file with SingleFile.
+	Out string `json:"out"`
+
+	// Package is the name of the generated Go package.
+	Package string `json:"package"`
+
+	// Suffix is the output suffix for filenames.
+	Suffix string `json:"suffix"`
+
+	// SingleFile toggles writing the generated code to one file.
+	SingleFile bool `json:"singleFile"`
+
+	// Tags is the list of build tags to add to generated Go files.
+	Tags string `json:"tags"`
+
+	// TemplatePath is the user supplied template path.
+	TemplatePath string `json:"templatePath"`
+
+	// IgnoreFields are the fields excluded from the generated Go types.
+	IgnoreFields []string `json:"ignoreFields"`
+
+	// IgnoreTables are the tables excluded from the generated Go code.
+	IgnoreTables []string `json:"ignoreTables"`
+
+	// Types are the Go type options.
+	Types ConfigTypes `json:"types"`
+
+	// Naming are the naming and escaping options.
+	Naming ConfigNaming `json:"naming"`
+
+	// Conventions are the soft delete and timestamp column options.
+	Conventions ConfigConventions `json:"conventions"`
+
+	// EnableAC enables the access control validitor.
+	EnableAC bool `json:"enableAC"`
+
+	// EnableExtension enables the GraphQL extension block.
+	EnableExtension bool `json:"enableExtension"`
+
+	// EnableContext generates context-aware Storage methods.
+	EnableContext bool `json:"enableContext"`
+
+	// EnableDataLoader batches the foreign key lookups of GraphQL resolvers.
+	EnableDataLoader bool `json:"enableDataLoader"`
+
+	// EnableMock generates MockStorage, a mock of the Storage interface.
+	EnableMock bool `json:"enableMock"`
+
+	// EnableMemory generates MemoryStorage, an in-memory Storage registered
+	// as the memory driver.
+	EnableMemory bool `json:"enableMemory"`
+
+	// SchemaCheckWarn warns instead of failing when the schemas of the DSNs
+	// differ.
+	SchemaCheckWarn bool `json:"schemaCheckWarn"`
+
+	// EnablePostgresOIDs enables postgres oids.
+	EnablePostgresOIDs bool `json:"enablePostgresOIDs"`
+
+	// ExtraRuleFile is the extra rules configuration file path.
+	ExtraRuleFile string `json:"extraRuleFile"`
+
+	// ExtraRules are the extra rules, used instead of ExtraRuleFile.
+	ExtraRules *internal.ExtraRule `json:"extraRules"`
+
+	// Queries are the custom queries to generate Go types and funcs from.
+	Queries []ConfigQuery `json:"queries"`
+
+	// QueryDir is a directory, or a file, of annotated .sql queries to
+	// generate Go types and funcs from.
+	QueryDir string `json:"queryDir"`
+}
+
+// ConfigTypes are the Go type options of a Config.
+type ConfigTypes struct {
+	// Int32 is the Go type assigned to integers.
+	Int32 string `json:"int32"`
+
+	// Uint32 is the Go type assigned to unsigned integers.
+	Uint32 string `json:"uint32"`
+
+	// CustomPackage is the Go package name to use for custom or unknown types.
+	CustomPackage string `json:"customPackage"`
+
+	// Overrides are the Go types of database types and table columns, used
+	// instead of the built-in type mappings.
+	Overrides []*internal.TypeOverride `json:"overrides"`
+}
+
+// ConfigNaming are the naming and escaping options of a Config.
+type ConfigNaming struct {
+	// FkMode is the mode for naming foreign key funcs: smart, parent, field or key.
+	FkMode string `json:"fkMode"`
+
+	// UseIndexNames toggles using index names as defined in schema.
+	UseIndexNames bool `json:"useIndexNames"`
+
+	// UseReversedEnumConstNames toggles using reversed enum names.
+	UseReversedEnumConstNames bool `json:"useReversedEnumConstNames"`
+
+	// NameConflictSuffix is the suffix appended when a name conflicts with a Go variable.
+	NameConflictSuffix string `json:"nameConflictSuffix"`
+
+	// EscapeAll toggles escaping schema, table, and column names.
+	EscapeAll bool `json:"escapeAll"`
+
+	// EscapeSchema toggles escaping the schema name.
+	EscapeSchema bool `json:"escapeSchema"`
+
+	// EscapeTable toggles escaping table names.
+	EscapeTable bool `json:"escapeTable"`
+
+	// EscapeColumn toggles escaping column names.
+	EscapeColumn bool `json:"escapeColumn"`
+}
+
+// ConfigConventions are the soft delete and timestamp column options of a
+// Config. The columns are only used by the tables having them, "-" disables
+// a convention.
+type ConfigConventions struct {
+	// DeletedColumn is the soft delete column.
+	DeletedColumn string `json:"deletedColumn"`
+
+	// CreatedColumn is the creation time column.
+	CreatedColumn string `json:"createdColumn"`
+
+	// ChangedColumn is the change time column.
+	ChangedColumn string `json:"changedColumn"`
+
+	// SoftDelete toggles deleting by setting the soft delete column.
+	SoftDelete bool `json:"softDelete"`
+}
+
+// ConfigQuery is a named custom query of a Config, the equivalent of a xo
+// invocation in query mode.
+type ConfigQuery struct {
+	// Name is the name of the generated Go func.
+	Name string `json:"name"`
+
+	// Type is the name of the generated Go type.
+	Type string `json:"type"`
+
+	// DSNs are the data sources to run the query against, they default to
+	// the DSNs of the Config.
+	DSNs []string `json:"dsns"`
+
+	// Query is the SQL query.
+	Query string `json:"query"`
+
+	// TypeComment is the comment of the generated Go type.
+	TypeComment string `json:"typeComment"`
+
+	// FuncComment is the comment of the generated Go func.
+	FuncComment string `json:"funcComment"`
+
+	// OnlyOne toggles the generated func to return only one result.
+	OnlyOne bool `json:"onlyOne"`
+
+	// Trim toggles trimming the query whitespace.
+	Trim bool `json:"trim"`
+
+	// Strip toggles stripping type casts from the query.
+	Strip bool `json:"strip"`
+
+	// Interpolate toggles the query interpolation.
+	Interpolate bool `json:"interpolate"`
+
+	// Delimiter is the delimiter of the embedded Go parameters.
+	Delimiter string `json:"delimiter"`
+
+	// Fields is the comma separated list of field names to scan the results to.
+	Fields string `json:"fields"`
+
+	// AllowNulls toggles using the query column NULL state.
+	AllowNulls bool `json:"allowNulls"`
+}
+
+// ConfigArguments are the arguments of the generate command, the flags
+// override the values of the configuration file.
+type ConfigArguments struct {
+	// Config is the project configuration file path.
+	Config string `arg:"-c,help:project configuration file"`
+
+	Arguments
+}
+
+// LoadConfig reads the Config from the yaml file at path.
+func LoadConfig(path string) (*Config, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	if err := yaml.Unmarshal(buf, &c); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return &c, nil
+}
+
+// Arguments returns the Arguments declared by the Config.
+func (c *Config) Arguments() (Arguments, error) {
+	arguments := Arguments{
+		DSNS:                      internal.DSNS(c.DSNs),
+		Schema:                    c.Schema,
+		Out:                       c.Out,
+		Package:                   c.Package,
+		Suffix:                    c.Suffix,
+		SingleFile:                c.SingleFile,
+		Tags:                      c.Tags,
+		TemplatePath:              c.TemplatePath,
+		IgnoreFields:              c.IgnoreFields,
+		IgnoreTables:              c.IgnoreTables,
+		Int32Type:                 c.Types.Int32,
+		Uint32Type:                c.Types.Uint32,
+		CustomTypePackage:         c.Types.CustomPackage,
+		TypeOverrides:             c.Types.Overrides,
+		UseIndexNames:             c.Naming.UseIndexNames,
+		UseReversedEnumConstNames: c.Naming.UseReversedEnumConstNames,
+		NameConflictSuffix:        c.Naming.NameConflictSuffix,
+		EscapeAll:                 c.Naming.EscapeAll,
+		EscapeSchemaName:          c.Naming.EscapeSchema,
+		EscapeTableNames:          c.Naming.EscapeTable,
+		EscapeColumnNames:         c.Naming.EscapeColumn,
+		DeletedColumn:             c.Conventions.DeletedColumn,
+		CreatedColumn:             c.Conventions.CreatedColumn,
+		ChangedColumn:             c.Conventions.ChangedColumn,
+		SoftDelete:                c.Conventions.SoftDelete,
+		EnableAC:                  c.EnableAC,
+		EnableExtension:           c.EnableExtension,
+		EnableContext:             c.EnableContext,
+		EnableDataLoader:          c.EnableDataLoader,
+		EnableMock:                c.EnableMock,
+		EnableMemory:              c.EnableMemory,
+		SchemaCheckWarn:           c.SchemaCheckWarn,
+		EnablePostgresOIDs:        c.EnablePostgresOIDs,
+		ExtraRuleFile:             c.ExtraRuleFile,
+		ExtraRule:                 c.ExtraRules,
+		QueryDir:                  c.QueryDir,
+	}
+
+	if c.Naming.FkMode != "" {
+		var fkMode internal.FkMode
+		if err := fkMode.UnmarshalText([]byte(c.Naming.FkMode)); err != nil {
+			return arguments, err
+		}
+		arguments.ForeignKeyMode = &fkMode
+	}
+
+	return arguments, nil
+}
+
+// queryArguments returns the Arguments generating the query q, based on
+// the schema arguments.
+func (q ConfigQuery) queryArguments(arguments Arguments) (Arguments, error) {
+	if q.Type == "" {
+		return arguments, fmt.Errorf("query %s: type must be supplied", q.Name)
+	}
+
+	if len(q.DSNs) != 0 {
+		arguments.DSNS = internal.DSNS(q.DSNs)
+	}
+	arguments.QueryMode = true
+	arguments.Query = q.Query
+	arguments.QueryType = q.Type
+	arguments.QueryFunc = q.Name
+	arguments.QueryTypeComment = q.TypeComment
+	arguments.QueryFuncComment = q.FuncComment
+	arguments.QueryOnlyOne = q.OnlyOne
+	arguments.QueryTrim = q.Trim
+	arguments.QueryStrip = q.Strip
+	arguments.QueryInterpolate = q.Interpolate
+	arguments.QueryParamDelimiter = q.Delimiter
+	arguments.QueryFields = q.Fields
+	arguments.QueryAllowNulls = q.AllowNulls
+
+	return arguments, nil
+}
+
+// parseConfigArguments parses args into cargs in two passes: the first one
+// finds the configuration file, whose values are then overridden by the
+// flags of the second one.
+func parseConfigArguments(p *arg.Parser, cargs *ConfigArguments, args []string) (*Config, error) {
+	// first pass to find the configuration file
+	if err := p.Parse(args); err != nil {
+		return nil, err
+	}
+	if cargs.Config == "" {
+		cargs.Config = DefaultConfigFile
+	}
+
+	c, err := LoadConfig(cargs.Config)
+	if err != nil {
+		return nil, err
+	}
+	cargs.Arguments, err = c.Arguments()
+	if err != nil {
+		return nil, err
+	}
+
+	// second pass so that the flags override the configuration
+	if err = p.Parse(args); err != nil {
+		return nil, err
+	}
+	if len(cargs.DSNS) == 0 {
+		return nil, errors.New("no dsns in config file " + cargs.Config)
+	}
+
+	return c, nil
+}
+
+// GenerateConfig runs the generate command with the command line arguments
+// args: it generates the schema, each query and then the query dir of the
+// configuration file in one process.
+func GenerateConfig(args []string) error {
+	var cargs ConfigArguments
+	p, err := arg.NewParser(arg.Config{Program: "xo generate"}, &cargs)
+	if err != nil {
+		return err
+	}
+
+	c, err := parseConfigArguments(p, &cargs, args)
+	if err == arg.ErrHelp {
+		p.WriteHelp(os.Stdout)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// the query dir is generated after the schema
+	queryDir := cargs.QueryDir
+	cargs.QueryDir = ""
+
+	if err = Generate(cargs.Arguments); err != nil {
+		return err
+	}
+
+	for _, q := range c.Queries {
+		arguments, err := q.queryArguments(cargs.Arguments)
+		if err != nil {
+			return err
+		}
+
+		if err = Generate(arguments); err != nil {
+			return fmt.Errorf("query %s: %v", q.Name, err)
+		}
+	}
+
+	if queryDir != "" {
+		arguments := cargs.Arguments
+		arguments.QueryDir = queryDir
+		if err = Generate(arguments); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/alexflint/go-arg"
+	"github.com/xo/xo/internal"
+)
+
+const testConfig = `
+dsns:
+  - pgsql://localhost/booktest
+schema: public
+out: models
+package: models
+suffix: .xo.go
+tags: integration
+types:
+  int32: int
+naming:
+  fkMode: field
+  escapeAll: true
+conventions:
+  deletedColumn: removed_at
+  softDelete: true
+enableExtension: true
+queries:
+  - name: AuthorCount
+    type: AuthorCountResult
+    query: SELECT count(*) AS count FROM authors
+    onlyOne: true
+`
+
+// writeConfig writes the configuration buf to a file named name in dir, and
+// returns its path.
+func writeConfig(t *testing.T, dir, name, buf string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(buf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// tempDir creates a temporary directory, removed by the returned func.
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "xo-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func Test_LoadConfigArguments(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	path := writeConfig(t, dir, DefaultConfigFile, testConfig)
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(c.Queries) != 1 || c.Queries[0].Name != "AuthorCount" || !c.Queries[0].OnlyOne {
+		t.Errorf("expected the AuthorCount query, got: %+v", c.Queries)
+	}
+
+	arguments, err := c.Arguments()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !reflect.DeepEqual(arguments.DSNS, internal.DSNS{"pgsql://localhost/booktest"}) {
+		t.Errorf("expected the config dsns, got: %v", arguments.DSNS)
+	}
+	if arguments.Schema != "public" || arguments.Out != "models" || arguments.Package != "models" || arguments.Suffix != ".xo.go" {
+		t.Errorf("expected the config paths, got: %+v", arguments)
+	}
+	if arguments.Int32Type != "int" || !arguments.EscapeAll || !arguments.EnableExtension {
+		t.Errorf("expected the config options, got: %+v", arguments)
+	}
+	if arguments.DeletedColumn != "removed_at" || !arguments.SoftDelete {
+		t.Errorf("expected the config conventions, got: %+v", arguments)
+	}
+	if arguments.ForeignKeyMode == nil || *arguments.ForeignKeyMode != internal.FkModeField {
+		t.Errorf("expected fk mode field, got: %v", arguments.ForeignKeyMode)
+	}
+
+	q, err := c.Queries[0].queryArguments(arguments)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !q.QueryMode || q.QueryType != "AuthorCountResult" || q.QueryFunc != "AuthorCount" || !q.QueryOnlyOne {
+		t.Errorf("expected the query arguments, got: %+v", q)
+	}
+	if q.Package != "models" || !reflect.DeepEqual(q.DSNS, arguments.DSNS) {
+		t.Errorf("expected the query to inherit the schema arguments, got: %+v", q)
+	}
+}
+
+func Test_ConfigErrors(t *testing.T) {
+	tests := []struct {
+		desc   string
+		config string
+		err    string
+	}{
+		{
+			desc:   "invalid fk mode",
+			config: "dsns: [pgsql://localhost/booktest]\nnaming:\n  fkMode: grandparent\n",
+			err:    "invalid FkMode",
+		},
+		{
+			desc:   "query without type",
+			config: "dsns: [pgsql://localhost/booktest]\nqueries:\n  - name: AuthorCount\n    query: SELECT 1\n",
+			err:    "query AuthorCount: type must be supplied",
+		},
+	}
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	for i, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			c, err := LoadConfig(writeConfig(t, dir, fmt.Sprintf("xo%d.yaml", i), test.config))
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			arguments, err := c.Arguments()
+			if err == nil {
+				for _, q := range c.Queries {
+					if _, err = q.queryArguments(arguments); err != nil {
+						break
+					}
+				}
+			}
+			if err == nil || err.Error() != test.err {
+				t.Errorf("expected error %q, got: %v", test.err, err)
+			}
+		})
+	}
+}
+
+func Test_ConfigFlagsOverride(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	path := writeConfig(t, dir, DefaultConfigFile, testConfig)
+
+	tests := []struct {
+		desc   string
+		args   []string
+		check  func(Arguments) bool
+		expect string
+	}{
+		{
+			desc:   "unset flags keep the config values",
+			args:   []string{"-c", path},
+			check:  func(a Arguments) bool { return a.Package == "models" && a.Schema == "public" && a.SoftDelete },
+			expect: "package models, schema public and soft delete",
+		},
+		{
+			desc:   "flag overrides the config value",
+			args:   []string{"-c", path, "-p", "store"},
+			check:  func(a Arguments) bool { return a.Package == "store" && a.Out == "models" },
+			expect: "package store and out models",
+		},
+		{
+			desc: "fk mode flag overrides the config value",
+			args: []string{"-c", path, "--fk-mode", "key"},
+			check: func(a Arguments) bool {
+				return a.ForeignKeyMode != nil && *a.ForeignKeyMode == internal.FkModeKey
+			},
+			expect: "fk mode key",
+		},
+		{
+			desc: "positional dsns override the config dsns",
+			args: []string{"-c", path, "mysql://localhost/booktest"},
+			check: func(a Arguments) bool {
+				return reflect.DeepEqual(a.DSNS, internal.DSNS{"mysql://localhost/booktest"})
+			},
+			expect: "the mysql dsn",
+		},
+		{
+			desc:   "bool flag is set over the config",
+			args:   []string{"-c", path, "--single-file"},
+			check:  func(a Arguments) bool { return a.SingleFile && a.EscapeAll },
+			expect: "single file and escape all",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var cargs ConfigArguments
+			p, err := arg.NewParser(arg.Config{Program: "xo generate"}, &cargs)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err = parseConfigArguments(p, &cargs, test.args); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if !test.check(cargs.Arguments) {
+				t.Errorf("expected %s, got: %+v", test.expect, cargs.Arguments)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexflint/go-arg"
+)
+
+const hooksSchema = `CREATE TABLE authors (
+  author_id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+  name text NOT NULL DEFAULT '',
+  changed_date timestamp
+);
+`
+
+const hooksModels = `package models
+
+import "time"
+
+// BeforeUpdate sets the changed date of the author.
+func (a *Author) BeforeUpdate(db XODB) error {
+	a.ChangedDate = NullTime{Time: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true}
+	return nil
+}
+`
+
+const hooksMain = `package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/xo/xo/cli/%s/models"
+)
+
+func run(s models.Storage, db models.XODB) error {
+	a := &models.Author{Name: "ann"}
+	if err := s.InsertAuthor(db, a); err != nil {
+		return err
+	}
+
+	// a column the hook sets outside the fields
+	if err := s.UpdateAuthorByFields(db, &models.Author{AuthorID: a.AuthorID}, []string{"name"}, nil, []interface{}{"bob"}, nil); err != nil {
+		return err
+	}
+	got, err := s.AuthorByAuthorID(db, a.AuthorID)
+	if err != nil {
+		return err
+	}
+	fmt.Println(got.Name, got.ChangedDate.Valid, got.ChangedDate.Time.Year())
+
+	// a column the hook sets, otherwise read back
+	b := &models.Author{AuthorID: a.AuthorID}
+	if err := s.UpdateAuthorByFields(db, b, []string{"name"}, []string{"changed_date"}, []interface{}{"cal"}, []interface{}{&b.ChangedDate}); err != nil {
+		return err
+	}
+	got, err = s.AuthorByAuthorID(db, a.AuthorID)
+	if err != nil {
+		return err
+	}
+	fmt.Println(got.Name, got.ChangedDate.Valid, b.ChangedDate.Valid)
+	return nil
+}
+
+func main() {
+	db, err := sql.Open("sqlite3", os.Args[1])
+	if err == nil {
+		_, err = db.Exec(os.Args[2])
+	}
+	for _, driver := range []string{"sqlite3", "memory"} {
+		var s models.Storage
+		if err == nil {
+			s, err = models.New(driver, models.Config{})
+		}
+		if err == nil {
+			err = run(s, db)
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+`
+
+func Test_GenerateUpdateHooks(t *testing.T) {
+	for _, name := range []string{"go", "goimports"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s is not available: %v", name, err)
+		}
+	}
+
+	// the generated package is built in the module
+	dir, err := ioutil.TempDir(".", "xo-hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	schema, err := filepath.Abs(filepath.Join(dir, "schema.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"schema.sql":      hooksSchema,
+		"models/hooks.go": hooksModels,
+		"main.go":         strings.Replace(hooksMain, "%s", filepath.Base(dir), 1),
+	}
+	if err := os.Mkdir(filepath.Join(dir, "models"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, buf := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(buf), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var arguments Arguments
+	p, err := arg.NewParser(arg.Config{}, &arguments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.Parse([]string{
+		"file://" + schema + "?dialect=sqlite3",
+		"-o", filepath.Join(dir, "models"),
+		"-p", "models",
+		"--template-path", filepath.Join("..", "templates"),
+		"--enable-memory",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Generate(arguments); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	out, err := exec.Command("go", "run", "./"+dir, filepath.Join(dir, "hooks.db"), hooksSchema).CombinedOutput()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v\n%s", err, out)
+	}
+	exp := "bob true 2020\ncal true true\n"
+	if s := string(out); s != exp+exp {
+		t.Errorf("expected the changed dates set by BeforeUpdate to be saved by both drivers:\n%s\ngot:\n%s", exp+exp, s)
+	}
+}
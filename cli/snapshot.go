@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"github.com/alexflint/go-arg"
+	"github.com/xo/xo/internal"
+	"github.com/xo/xo/loaders"
+)
+
+// DumpSchema runs the dump-schema command with the command line arguments
+// args: it loads the schema of the dsns and writes its snapshot as JSON to
+// the output file, or to stdout.
+func DumpSchema(args []string) error {
+	var arguments Arguments
+	p, err := arg.NewParser(arg.Config{Program: "xo dump-schema"}, &arguments)
+	if err != nil {
+		return err
+	}
+
+	err = p.Parse(args)
+	if err == arg.ErrHelp {
+		p.WriteHelp(os.Stdout)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if len(arguments.DSNS) == 0 {
+		return errors.New("dsns is required")
+	}
+	if arguments.QueryMode || arguments.Query != "" || arguments.QueryDir != "" {
+		return errors.New("query mode cannot be used with dump-schema")
+	}
+
+	// the output is the snapshot file, not generated code
+	out := arguments.Out
+	arguments.Out = ""
+
+	snapshot, err := LoadSnapshot(arguments)
+	if err != nil {
+		return err
+	}
+
+	buf, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+
+	if out == "" {
+		_, err = os.Stdout.Write(buf)
+		return err
+	}
+	return ioutil.WriteFile(out, buf, 0644)
+}
+
+// LoadSnapshot loads the schema of the dsns of arguments, and returns the
+// snapshot of the loaded catalogs.
+func LoadSnapshot(arguments Arguments) (*loaders.Snapshot, error) {
+	var err error
+
+	// get defaults
+	args := NewDefaultInternalArgs(arguments)
+	internal.Args = args
+
+	// process args
+	err = processArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// open database
+	err = openDB(args)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, db := range args.DBS {
+			db.Close()
+		}
+	}()
+
+	snapshot := &loaders.Snapshot{}
+	for _, driver := range args.LoaderTypes {
+		loader, err := snapshot.Record(driver, args.Loaders[driver])
+		if err != nil {
+			return nil, err
+		}
+
+		// set current driver
+		args.LoaderType = driver
+		args.Loader = loader
+		args.DB = args.DBS[driver]
+
+		// load schema name
+		args.Schema, err = loader.SchemaName(args)
+		if err != nil {
+			return nil, err
+		}
+
+		// load the schema, recording its catalog
+		err = loader.LoadSchema(args)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return snapshot, nil
+}
@@ -103,6 +103,10 @@ type ArgType struct {
 	// QueryAllowNulls indicates that custom query results can contain null types.
 	QueryAllowNulls bool `arg:"--query-allow-nulls,-U,help:use query column NULL state"`
 
+	// QueryDir is a directory of .sql files, or a .sql file, of annotated
+	// queries to generate Go types and funcs from.
+	QueryDir string `arg:"--query-dir,help:directory or file of annotated .sql queries to generate Go types and funcs from"`
+
 	// EscapeAll toggles escaping schema, table, and column names in SQL queries.
 	EscapeAll bool `arg:"--escape-all,-X,help:escape all names in SQL queries"`
 
@@ -161,6 +165,9 @@ type ArgType struct {
 	// KnownTypeMap is the collection of known Go types.
 	KnownTypeMap map[string]bool `arg:"-"`
 
+	// EnumMap is the collection of the loaded enums, by Go type name.
+	EnumMap map[string]*Enum `arg:"-"`
+
 	// ShortNameTypeMap is the collection of Go style short names for types, mainly
 	// used for use with declaring a func receiver on a type.
 	ShortNameTypeMap map[string]string `arg:"-"`
@@ -179,10 +186,46 @@ type ArgType struct {
 	// enable entension.
 	EnableExtension bool `arg:"--enable-extension,help:enable entension block"`
 
+	// EnableContext toggles generating context-aware Storage methods.
+	EnableContext bool `arg:"--enable-context,help:generate Storage methods taking a context.Context and XODBContext"`
+
+	// EnableDataLoader toggles batching the foreign key lookups of the GraphQL resolvers.
+	EnableDataLoader bool `arg:"--enable-dataloader,help:batch foreign key lookups of GraphQL resolvers"`
+
+	// EnableMock toggles generating MockStorage, a mock of the Storage interface.
+	EnableMock bool `arg:"--enable-mock,help:generate MockStorage mocking the Storage interface"`
+
+	// EnableMemory toggles generating MemoryStorage, an in-memory Storage
+	// registered as the memory driver.
+	EnableMemory bool `arg:"--enable-memory,help:generate MemoryStorage keeping the rows in memory as the memory driver"`
+
+	// SchemaCheckWarn toggles warning, instead of failing, when the schemas
+	// of the dsns differ.
+	SchemaCheckWarn bool `arg:"--schema-check-warn,help:warn instead of failing when the schemas of the dsns differ"`
+
+	// DeletedColumn is the soft delete column, the rows where it is not
+	// null are the deleted ones.
+	DeletedColumn string `arg:"--deleted-column,help:soft delete column of tables or - to disable [default: deleted_date]"`
+
+	// CreatedColumn is the creation time column ordering GetMostRecentX.
+	CreatedColumn string `arg:"--created-column,help:creation time column of tables or - to disable [default: created_date]"`
+
+	// ChangedColumn is the change time column ordering GetMostRecentChangedX.
+	ChangedColumn string `arg:"--changed-column,help:change time column of tables or - to disable [default: changed_date]"`
+
+	// SoftDelete toggles DeleteX setting the soft delete column instead of
+	// deleting the rows.
+	SoftDelete bool `arg:"--soft-delete,help:delete by setting the soft delete column of tables having it"`
+
 	// extra rules configuration file path
 	ExtraRuleFile   string              `arg:"--extra-rule,help:extra rules configuration file path"`
+	ExtraRule       *ExtraRule          `arg:"-"`
 	ExtraFiltersMap map[string]struct{} `arg:"-"`
 	ExtraACRulesMap map[string]struct{} `arg:"-"`
+
+	// TypeOverrides are the Go types of database types and columns, used
+	// instead of the built-in type mappings.
+	TypeOverrides []*TypeOverride `arg:"-"`
 }
 
 // NewDefaultArgs returns the default arguments.
@@ -196,6 +239,9 @@ func NewDefaultArgs() *ArgType {
 		ForeignKeyMode:      &fkMode,
 		QueryParamDelimiter: "%%",
 		NameConflictSuffix:  "Val",
+		DeletedColumn:       "deleted_date",
+		CreatedColumn:       "created_date",
+		ChangedColumn:       "changed_date",
 
 		// KnownTypeMap is the collection of known Go types.
 		KnownTypeMap: map[string]bool{
@@ -239,6 +285,9 @@ func NewDefaultArgs() *ArgType {
 			"Slice":       "s",
 			"StringSlice": "ss",
 		},
+
+		// EnumMap is the collection of the loaded enums, by Go type name.
+		EnumMap: map[string]*Enum{},
 	}
 }
 
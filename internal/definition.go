@@ -2,6 +2,7 @@ package internal
 
 // SchemaDefinition is schema definition
 type SchemaDefinition struct {
+	Enums   []*Enum
 	Tables  []*Type
 	Views   []*Type
 	Foreign []*ForeignKey
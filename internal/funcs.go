@@ -20,6 +20,7 @@ func (a *ArgType) NewTemplateFuncs() template.FuncMap {
 		"colnamesmulti":        a.colnamesmulti,
 		"colnamesquery":        a.colnamesquery,
 		"colprefixnamesquery":  a.colprefixnamesquery,
+		"colvaluesquery":       a.colvaluesquery,
 		"colnamesquerymulti":   a.colnamesquerymulti,
 		"colprefixnames":       a.colprefixnames,
 		"colvals":              a.colvals,
@@ -38,11 +39,17 @@ func (a *ArgType) NewTemplateFuncs() template.FuncMap {
 		"parsecolname":         a.parsecolname,
 		"hascolumn":            a.hascolumn,
 		"hasfield":             a.hasfield,
+		"deletedcolumn":        a.deletedcolumn,
+		"createdcolumn":        a.createdcolumn,
+		"changedcolumn":        a.changedcolumn,
+		"softdelete":           a.softdelete,
 		"getstartcount":        a.getstartcount,
 		"driver":               a.driver,
+		"toupper":              strings.ToUpper,
 		"firstletterupper":     a.firstLetterUpper,
 		"fkname":               a.fkname,
 		"fkreversefield":       a.fkreversefield,
+		"fkfiltername":         a.fkfiltername,
 		"getforeignkey":        a.getforeignkey,
 		"gotosql":              a.gotosql,
 		"plural":               a.plural,
@@ -57,11 +64,28 @@ func (a *ArgType) NewTemplateFuncs() template.FuncMap {
 		"isdup":                a.isdup,
 		"sqltogopointertype":   a.sqltogopointertype,
 		"sqltogqloptionaltype": a.sqltogqloptionaltype,
+		"sqltogoslicetype":     a.sqltogoslicetype,
 		"sqlfilter":            a.sqlfilter,
+		"isenum":               a.isenum,
+		"istypeoverride":       a.istypeoverride,
+		"hasvalid":             a.hasvalid,
+		"togqlenumvalue":       a.togqlenumvalue,
 		"flatidxfields":        a.flatidxfields,
 		"existsqlfilter":       a.existsqlfilter,
+		"aggregatefields":      a.aggregatefields,
+		"sqlaggregatetype":     a.sqlaggregatetype,
+		"aggregatetogqltype":   a.aggregatetogqltype,
+		"aggregatetogotype":    a.aggregatetogotype,
+		"aggregatetogql":       a.aggregatetogql,
 		"enableac":             a.enableAC,
 		"enableextension":      a.enableExtension,
+		"enablecontext":        a.enableContext,
+		"enabledataloader":     a.enableDataLoader,
+		"enablememory":         a.enableMemory,
+		"dbtype":               a.dbtype,
+		"dbparam":              a.dbparam,
+		"dbarg":                a.dbarg,
+		"dbcall":               a.dbcall,
 		"isacfield":            a.isACField,
 		"isprimaryindex":       a.isPrimaryIndex,
 		"groupindexedresource": a.groupIndexedResource,
@@ -351,6 +375,36 @@ func (a *ArgType) colprefixnamesquery(fields []*Field, prefixBefore string, pref
 	return str
 }
 
+// colvaluesquery creates a list of the column names in fields assigned from
+// the inserted row and joined by sep, excluding any Field with Name contained
+// in ignoreNames.
+//
+// Used to create the update clause of a MySQL upsert (ie, "field_1 =
+// VALUES(field_1), field_2 = VALUES(field_2), ...").
+func (a *ArgType) colvaluesquery(fields []*Field, sep string, ignoreNames ...string) string {
+	ignore := map[string]bool{}
+	for _, n := range ignoreNames {
+		ignore[n] = true
+	}
+
+	str := ""
+	i := 0
+	for _, f := range fields {
+		if ignore[f.Name] {
+			continue
+		}
+
+		if i != 0 {
+			str = str + sep
+		}
+		colname := a.colname(f.Col)
+		str = str + colname + " = VALUES(" + colname + ")"
+		i++
+	}
+
+	return str
+}
+
 // colnamesquerymulti creates a list of the column names in fields as a query and
 // joined by sep, excluding any Field with Name contained in the slice of fields in ignoreNames.
 //
@@ -765,6 +819,37 @@ func (a *ArgType) hascolumn(fields []*Field, name string) bool {
 	return false
 }
 
+// conventioncolumn returns the column name when the table t has it, or ""
+// when t does not have it or the convention is disabled with "-".
+func (a *ArgType) conventioncolumn(t *Type, name string) string {
+	if name == "" || name == "-" || !a.hascolumn(t.Fields, name) {
+		return ""
+	}
+
+	return name
+}
+
+// deletedcolumn returns the soft delete column of the table t, or "".
+func (a *ArgType) deletedcolumn(t *Type) string {
+	return a.conventioncolumn(t, a.DeletedColumn)
+}
+
+// createdcolumn returns the creation time column of the table t, or "".
+func (a *ArgType) createdcolumn(t *Type) string {
+	return a.conventioncolumn(t, a.CreatedColumn)
+}
+
+// changedcolumn returns the change time column of the table t, or "".
+func (a *ArgType) changedcolumn(t *Type) string {
+	return a.conventioncolumn(t, a.ChangedColumn)
+}
+
+// softdelete determines if the rows of the table t are deleted by setting
+// its soft delete column.
+func (a *ArgType) softdelete(t *Type) bool {
+	return a.SoftDelete && a.deletedcolumn(t) != ""
+}
+
 // hasfield takes a list of fields and determines if field with the specified
 // field name is in the list.
 func (a *ArgType) hasfield(fields []*Field, name string) bool {
@@ -801,6 +886,8 @@ var sqlToGoTypeMap = map[string]string{
 	"[]sql.NullString":    "[]string",
 	"sql.NullBool":        "*bool",
 	"NullTime":            "*graphql.Time",
+	"mysql.NullTime":      "*graphql.Time",
+	"xoutil.SqTime":       "graphql.Time",
 	"sql.NullInt64":       "*string",
 	"sql.NullFloat64":     "*float64",
 	"decimal.NullDecimal": "*string",
@@ -830,6 +917,8 @@ var sqlNilTypeMap = map[string]string{
 	"[]sql.NullString":    "[]string",
 	"sql.NullBool":        "sql.NullBool",
 	"NullTime":            "NullTime",
+	"mysql.NullTime":      "mysql.NullTime",
+	"xoutil.SqTime":       "NullTime",
 	"sql.NullInt64":       "sql.NullInt64",
 	"sql.NullFloat64":     "sql.NullFloat64",
 	"decimal.NullDecimal": "decimal.NullDecimal",
@@ -837,6 +926,12 @@ var sqlNilTypeMap = map[string]string{
 }
 
 func (a *ArgType) sqlniltype(typ string) string {
+	if a.isenum(typ) {
+		return "*" + typ
+	}
+	if o := a.customtype(typ); o != nil {
+		return "*" + o.GoType
+	}
 	if ret, ok := sqlNilTypeMap[typ]; ok {
 		return ret
 	}
@@ -847,6 +942,15 @@ func (a *ArgType) sqltogotype(typ string, isPK bool) string {
 	if isPK {
 		return "graphql.ID"
 	}
+	if a.isenum(typ) {
+		if strings.HasPrefix(typ, "*") {
+			return "*string"
+		}
+		return "string"
+	}
+	if a.istypeoverride(typ) {
+		return typ
+	}
 	if ret, ok := sqlToGoTypeMap[typ]; ok {
 		return ret
 	}
@@ -857,6 +961,15 @@ func (a *ArgType) sqltogoreturntype(typ string, isPK bool) string {
 	if isPK {
 		panic("in funcs.go unsupported extra ac rules on pk field")
 	}
+	if a.isenum(typ) {
+		return `""`
+	}
+	if o := a.customtype(typ); o != nil {
+		if strings.HasPrefix(typ, "*") || o.NilValue == "" {
+			return "nil"
+		}
+		return o.NilValue
+	}
 	if ret, ok := sqlToGoReturnTypeMap[typ]; ok {
 		return ret
 	}
@@ -870,6 +983,12 @@ func (a *ArgType) sqltogql(typ, field string, isPK bool) string {
 	if isPK {
 		return "graphql.ID(strconv.Itoa(" + field + "))"
 	}
+	if a.isenum(typ) {
+		return field + ".GraphQL()"
+	}
+	if a.istypeoverride(typ) {
+		return field
+	}
 	switch typ {
 	case "int":
 		return "strconv.Itoa(" + field + ")"
@@ -891,6 +1010,10 @@ func (a *ArgType) sqltogql(typ, field string, isPK bool) string {
 		return "PointerBool(" + field + ")"
 	case "NullTime":
 		return "PointerGqlTime(" + field + ")"
+	case "mysql.NullTime":
+		return "PointerGqlTime(NullTime(" + field + "))"
+	case "xoutil.SqTime":
+		return "graphql.Time{Time: " + field + ".Time}"
 	case "sql.NullInt64":
 		return "PointerStringSqlInt64(" + field + ")"
 	case "sql.NullFloat64":
@@ -923,6 +1046,8 @@ var sqlToGqlTypeMap = map[string]string{
 	"[]sql.NullString":    "[String]",
 	"sql.NullBool":        "Boolean",
 	"NullTime":            "Time",
+	"mysql.NullTime":      "Time",
+	"xoutil.SqTime":       "Time!",
 	"sql.NullInt64":       "String",
 	"sql.NullFloat64":     "Float",
 	"decimal.Decimal":     "String!",
@@ -933,6 +1058,18 @@ func (a *ArgType) sqltogqltype(typ string, isPK bool) string {
 	if isPK {
 		return "ID!"
 	}
+	if a.isenum(typ) {
+		if strings.HasPrefix(typ, "*") {
+			return typ[1:]
+		}
+		return typ + "!"
+	}
+	if o := a.customtype(typ); o != nil {
+		if strings.HasPrefix(typ, "*") {
+			return o.GraphQL
+		}
+		return o.GraphQL + "!"
+	}
 	if ret, ok := sqlToGqlTypeMap[typ]; ok {
 		return ret
 	}
@@ -940,6 +1077,12 @@ func (a *ArgType) sqltogqltype(typ string, isPK bool) string {
 }
 
 func (a *ArgType) gotosql(typ, field string) string {
+	if a.isenum(typ) {
+		return typ + "FromGraphQL(" + field + ")"
+	}
+	if a.istypeoverride(typ) {
+		return field
+	}
 	switch typ {
 	case "int":
 		return field
@@ -963,6 +1106,10 @@ func (a *ArgType) gotosql(typ, field string) string {
 		return fmt.Sprintf("Float64Pointer(%s)", field)
 	case "NullTime":
 		return fmt.Sprintf("TimeGqlPointer(%s)", field)
+	case "mysql.NullTime":
+		return fmt.Sprintf("mysql.NullTime(TimeGqlPointer(%s))", field)
+	case "xoutil.SqTime":
+		return fmt.Sprintf("xoutil.SqTime{Time: %s.Time}", field)
 	case "decimal.Decimal":
 		return fmt.Sprintf("decimal.NewFromString(%s)", field)
 	default:
@@ -991,6 +1138,16 @@ func (a *ArgType) fkname(field string) string {
 	return field
 }
 
+// fkfiltername returns the name of the relation filter of the foreign key fk,
+// the name of the referenced object unless it is the name of the column.
+func (a *ArgType) fkfiltername(fk *ForeignKey) string {
+	name := a.fkname(fk.Field.Name)
+	if name == fk.Field.Name {
+		return name + "Ref"
+	}
+	return name
+}
+
 // e.g. RefType: User, Type: Employment, Field: UserID,    returns {User}.employments
 //      RefType: User, Type: Company,    Field: CreatedBy, returns {User}.companiesCreated
 func (a *ArgType) fkreversefield(fk *ForeignKey, isDup bool) string {
@@ -1032,6 +1189,12 @@ func (a *ArgType) sqltogopointertype(typ string, isPK bool) string {
 	if isPK {
 		return "*graphql.ID"
 	}
+	if a.isenum(typ) {
+		return "*string"
+	}
+	if a.istypeoverride(typ) {
+		return "*" + strings.TrimPrefix(typ, "*")
+	}
 	if ret, ok := sqlToGoTypeMap[typ]; ok {
 		if !strings.Contains(ret, "*") {
 			return fmt.Sprintf("*%s", ret)
@@ -1041,11 +1204,22 @@ func (a *ArgType) sqltogopointertype(typ string, isPK bool) string {
 	panic("in funcs.go define sqltogotype for: " + typ)
 }
 
+// convert sql type to the pointer to slice type of graphql list arguments
+func (a *ArgType) sqltogoslicetype(typ string, isPK bool) string {
+	return "*[]" + strings.TrimPrefix(a.sqltogopointertype(typ, isPK), "*")
+}
+
 // convert sql type to optional type in graphql
 func (a *ArgType) sqltogqloptionaltype(typ string, isPK bool) string {
 	if isPK {
 		return "ID"
 	}
+	if a.isenum(typ) {
+		return strings.TrimPrefix(typ, "*")
+	}
+	if o := a.customtype(typ); o != nil {
+		return o.GraphQL
+	}
 	if ret, ok := sqlToGqlTypeMap[typ]; ok {
 		if strings.Contains(ret, "!") {
 			return ret[:len(ret)-1]
@@ -1058,14 +1232,16 @@ func (a *ArgType) sqltogqloptionaltype(typ string, isPK bool) string {
 // sql types map for filter control
 var sqlTypeFilterCtlMap = map[string]string{
 	"string":          "String",
-	"bool":            "unsupported",
+	"bool":            "Bool",
 	"int64":           "Number",
 	"int":             "Number",
 	"float64":         "Number",
 	"time.Time":       "Time",
 	"sql.NullString":  "String",
-	"sql.NullBool":    "unsupported",
+	"sql.NullBool":    "Bool",
 	"NullTime":        "Time",
+	"mysql.NullTime":  "Time",
+	"xoutil.SqTime":   "Time",
 	"sql.NullInt64":   "Number",
 	"sql.NullFloat64": "Number",
 }
@@ -1099,10 +1275,62 @@ func (a *ArgType) sqlfilter(table string, field *Field, idxFields []*Field) stri
 	if ret, ok := sqlTypeFilterCtlMap[field.Type]; ok {
 		return ret
 	}
+	if a.isenum(field.Type) {
+		return "Enum"
+	}
+	if o := a.customtype(field.Type); o != nil && o.Filter != "" {
+		return o.Filter
+	}
 	return "unsupported"
 }
 
+// isenum determines if typ is a database enum type, or a pointer to one
+func (a *ArgType) isenum(typ string) bool {
+	_, ok := a.EnumMap[strings.TrimPrefix(typ, "*")]
+	return ok
+}
+
+// togqlenumvalue converts the database enum value to the name of the graphql
+// enum value, e.g. "in progress" to IN_PROGRESS
+func (a *ArgType) togqlenumvalue(s string) string {
+	var b strings.Builder
+	for i, r := range strings.ToUpper(s) {
+		switch {
+		case r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// existsqlfilter determines if typ has a filter, on its own columns or on
+// the columns of a table it is related to by a foreign key
 func (a *ArgType) existsqlfilter(typ *Type) bool {
+	if a.hassqlfilterfields(typ) {
+		return true
+	}
+	for _, fk := range typ.ForeignKeys {
+		if a.hassqlfilterfields(fk.RefType) {
+			return true
+		}
+	}
+	for _, fk := range typ.RefFKs {
+		if a.hassqlfilterfields(fk.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// hassqlfilterfields determines if typ has columns to filter on
+func (a *ArgType) hassqlfilterfields(typ *Type) bool {
 	fields := make([]*Field, 0)
 	for _, index := range typ.Indexes {
 		for _, field := range index.Fields {
@@ -1123,6 +1351,92 @@ func (a *ArgType) existsqlfilter(typ *Type) bool {
 	return false
 }
 
+// aggregatefields returns the columns of typ to aggregate classified as one
+// of ctls (Number, Time), except the primary and foreign keys
+func (a *ArgType) aggregatefields(typ *Type, ctls ...string) []*Field {
+	fields := make([]*Field, 0)
+	for _, field := range typ.Fields {
+		if field.Col.IsPrimaryKey || a.getforeignkey(field.Name, typ.ForeignKeys) != nil {
+			continue
+		}
+		ctl := sqlTypeFilterCtlMap[field.Type]
+		for _, c := range ctls {
+			if ctl == c {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+var sqlAggregateTypeMap = map[string]string{
+	"int":             "*int64",
+	"int64":           "*int64",
+	"float64":         "*float64",
+	"sql.NullInt64":   "*int64",
+	"sql.NullFloat64": "*float64",
+	"time.Time":       "*time.Time",
+	"NullTime":        "*time.Time",
+	"mysql.NullTime":  "*time.Time",
+	"xoutil.SqTime":   "*xoutil.SqTime",
+}
+
+// sqlaggregatetype converts sql type to the type of its minimum and maximum,
+// sqlite3 returns the times of aggregates as text
+func (a *ArgType) sqlaggregatetype(typ string) string {
+	if a.LoaderType == "sqlite3" && sqlTypeFilterCtlMap[typ] == "Time" {
+		return "*xoutil.SqTime"
+	}
+	if ret, ok := sqlAggregateTypeMap[typ]; ok {
+		return ret
+	}
+	panic("in funcs.go define sqlaggregatetype for: " + typ)
+}
+
+// aggregatetogqltype converts aggregate type to optional type in graphql
+func (a *ArgType) aggregatetogqltype(typ string) string {
+	switch typ {
+	case "*int64":
+		return "String"
+	case "*float64":
+		return "Float"
+	case "*time.Time", "*xoutil.SqTime":
+		return "Time"
+	default:
+		panic("in funcs.go define aggregatetogqltype for: " + typ)
+	}
+}
+
+// aggregatetogotype converts aggregate type to the type of graphql resolver
+func (a *ArgType) aggregatetogotype(typ string) string {
+	switch typ {
+	case "*int64":
+		return "*string"
+	case "*float64":
+		return "*float64"
+	case "*time.Time", "*xoutil.SqTime":
+		return "*graphql.Time"
+	default:
+		panic("in funcs.go define aggregatetogotype for: " + typ)
+	}
+}
+
+// aggregatetogql converts the non-nil aggregate field to graphql value
+func (a *ArgType) aggregatetogql(typ, field string) string {
+	switch typ {
+	case "*int64":
+		return "strconv.FormatInt(*" + field + ", 10)"
+	case "*float64":
+		return "*" + field
+	case "*time.Time":
+		return "graphql.Time{Time: *" + field + "}"
+	case "*xoutil.SqTime":
+		return "graphql.Time{Time: " + field + ".Time}"
+	default:
+		panic("in funcs.go define aggregatetogql for: " + typ)
+	}
+}
+
 func (a *ArgType) enableAC() bool {
 	return a.EnableAC
 }
@@ -1131,6 +1445,57 @@ func (a *ArgType) enableExtension() bool {
 	return a.EnableExtension
 }
 
+func (a *ArgType) enableContext() bool {
+	return a.EnableContext
+}
+
+func (a *ArgType) enableDataLoader() bool {
+	return a.EnableDataLoader
+}
+
+func (a *ArgType) enableMemory() bool {
+	return a.EnableMemory
+}
+
+// dbtype returns the database handle type used by the generated code.
+func (a *ArgType) dbtype() string {
+	if a.EnableContext {
+		return "XODBContext"
+	}
+	return "XODB"
+}
+
+// dbparam returns the leading parameters of the generated Storage methods
+// (ie, "db XODB" or "ctx context.Context, db XODBContext").
+func (a *ArgType) dbparam() string {
+	if a.EnableContext {
+		return "ctx context.Context, db " + a.dbtype()
+	}
+	return "db " + a.dbtype()
+}
+
+// dbarg returns the leading arguments when calling a generated Storage method
+// with the database handle named db, or the first name supplied.
+func (a *ArgType) dbarg(names ...string) string {
+	db := "db"
+	if len(names) > 0 {
+		db = names[0]
+	}
+	if a.EnableContext {
+		return "ctx, " + db
+	}
+	return db
+}
+
+// dbcall returns the opening of a call to method on the database handle
+// (ie, "db.Exec(" or "db.ExecContext(ctx, ").
+func (a *ArgType) dbcall(method string) string {
+	if a.EnableContext {
+		return "db." + method + "Context(ctx, "
+	}
+	return "db." + method + "("
+}
+
 func (a *ArgType) isACField(table string, field *Field) bool {
 	key := fmt.Sprintf("%v@%v", field.Col.ColumnName, table)
 	_, ok := a.ExtraACRulesMap[key]
@@ -110,11 +110,30 @@ func (tl TypeLoader) SchemaName(args *ArgType) (string, error) {
 
 // ParseQuery satisfies Loader's ParseQuery.
 func (tl TypeLoader) ParseQuery(args *ArgType) error {
+	defs, err := args.QueryDefs()
+	if err != nil {
+		return err
+	}
+
+	// generated types, shared by the queries with the same type
+	types := map[string]*Type{}
+	for _, def := range defs {
+		err = tl.parseQuery(args, def, types)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseQuery generates the Go type and func of the custom query def.
+func (tl TypeLoader) parseQuery(args *ArgType, def *QueryDef, types map[string]*Type) error {
 	var err error
 
 	// parse supplied query
-	queryStr, params := args.ParseQuery(tl.Mask(), true)
-	inspectStr, _ := args.ParseQuery("NULL", false)
+	queryStr, params := args.ParseQuery(def.Query, tl.Mask(), true)
+	inspectStr, _ := args.ParseQuery(def.Query, "NULL", false)
 
 	// split up query and inspect based on lines
 	query := strings.Split(queryStr, "\n")
@@ -145,15 +164,30 @@ func (tl TypeLoader) ParseQuery(args *ArgType) error {
 		tl.QueryStrip(query, queryComments)
 	}
 
+	// exec queries have no results
+	if def.Exec {
+		queryTpl := &Query{
+			Name:          def.Func,
+			Query:         query,
+			QueryComments: queryComments,
+			QueryParams:   params,
+			Exec:          true,
+			Interpolate:   args.QueryInterpolate,
+			Comment:       def.FuncComment,
+		}
+
+		return args.ExecuteTemplate(QueryTemplate, def.Func, "", queryTpl)
+	}
+
 	// create template for query type
 	typeTpl := &Type{
-		Name:    args.QueryType,
+		Name:    def.Type,
 		RelType: Table,
 		Fields:  []*Field{},
 		Table: &models.Table{
-			TableName: "[custom " + strings.ToLower(snaker.CamelToSnake(args.QueryType)) + "]",
+			TableName: "[custom " + strings.ToLower(snaker.CamelToSnake(def.Type)) + "]",
 		},
-		Comment: args.QueryTypeComment,
+		Comment: def.TypeComment,
 	}
 
 	if args.QueryFields == "" {
@@ -169,7 +203,7 @@ func (tl TypeLoader) ParseQuery(args *ArgType) error {
 				Name: snaker.SnakeToCamelIdentifier(c.ColumnName),
 				Col:  c,
 			}
-			f.Len, f.NilType, f.Type = tl.ParseType(args, c.DataType, args.QueryAllowNulls && !c.NotNull)
+			f.Len, f.NilType, f.Type = tl.parseType(args, "", c.ColumnName, c.DataType, args.QueryAllowNulls && !c.NotNull)
 			typeTpl.Fields = append(typeTpl.Fields, f)
 		}
 	} else {
@@ -195,20 +229,29 @@ func (tl TypeLoader) ParseQuery(args *ArgType) error {
 		}
 	}
 
-	// generate query type template
-	err = args.ExecuteTemplate(QueryTypeTemplate, args.QueryType, "", typeTpl)
-	if err != nil {
-		return err
+	if t, ok := types[def.Type]; ok {
+		// the type is already generated, the query must return the same fields
+		if !sameFields(t.Fields, typeTpl.Fields) {
+			return fmt.Errorf("query %s: fields of type %s differ from a previous query", def.Func, def.Type)
+		}
+		typeTpl = t
+	} else {
+		// generate query type template
+		err = args.ExecuteTemplate(QueryTypeTemplate, def.Type, "", typeTpl)
+		if err != nil {
+			return err
+		}
+		types[def.Type] = typeTpl
 	}
 
 	// build func name
-	funcName := args.QueryFunc
+	funcName := def.Func
 	if funcName == "" {
 		// no func name specified, so generate based on type
-		if args.QueryOnlyOne {
-			funcName = args.QueryType
+		if def.OnlyOne {
+			funcName = def.Type
 		} else {
-			funcName = inflector.Pluralize(args.QueryType)
+			funcName = inflector.Pluralize(def.Type)
 		}
 
 		// affix any params
@@ -228,14 +271,14 @@ func (tl TypeLoader) ParseQuery(args *ArgType) error {
 		Query:         query,
 		QueryComments: queryComments,
 		QueryParams:   params,
-		OnlyOne:       args.QueryOnlyOne,
+		OnlyOne:       def.OnlyOne,
 		Interpolate:   args.QueryInterpolate,
 		Type:          typeTpl,
-		Comment:       args.QueryFuncComment,
+		Comment:       def.FuncComment,
 	}
 
 	// generate template
-	err = args.ExecuteTemplate(QueryTemplate, args.QueryType, "", queryTpl)
+	err = args.ExecuteTemplate(QueryTemplate, def.Type, "", queryTpl)
 	if err != nil {
 		return err
 	}
@@ -243,12 +286,25 @@ func (tl TypeLoader) ParseQuery(args *ArgType) error {
 	return nil
 }
 
+// sameFields reports whether the fields a and b have the same names and types.
+func sameFields(a, b []*Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].Type != b[i].Type {
+			return false
+		}
+	}
+	return true
+}
+
 // LoadSchema loads schema definitions.
 func (tl TypeLoader) LoadSchema(args *ArgType) error {
 	var err error
 
 	// load enums
-	_, err = tl.LoadEnums(args)
+	enumMap, err := tl.LoadEnums(args)
 	if err != nil {
 		return err
 	}
@@ -295,6 +351,15 @@ func (tl TypeLoader) LoadSchema(args *ArgType) error {
 
 	// load definition
 	var definition SchemaDefinition
+	var enumKeys []string
+	for key := range enumMap {
+		enumKeys = append(enumKeys, key)
+	}
+	sort.Strings(enumKeys)
+	for _, key := range enumKeys {
+		definition.Enums = append(definition.Enums, enumMap[key])
+	}
+
 	var tableKeys []string
 	for key := range tableMap {
 		tableKeys = append(tableKeys, key)
@@ -386,6 +451,7 @@ func (tl TypeLoader) LoadEnums(args *ArgType) (map[string]*Enum, error) {
 
 		enumMap[enumTpl.Name] = enumTpl
 		args.KnownTypeMap[enumTpl.Name] = true
+		args.EnumMap[enumTpl.Name] = enumTpl
 	}
 
 	// generate enum templates
@@ -464,7 +530,7 @@ func (tl TypeLoader) LoadProcs(args *ArgType) (map[string]*Proc, error) {
 
 		// parse return type into template
 		// TODO: fix this so that nullable types can be returned
-		_, procTpl.Return.NilType, procTpl.Return.Type = tl.ParseType(args, p.ReturnType, false)
+		_, procTpl.Return.NilType, procTpl.Return.Type = tl.parseType(args, "", "", p.ReturnType, false)
 
 		// load proc parameters
 		err = tl.LoadProcParams(args, procTpl)
@@ -504,7 +570,7 @@ func (tl TypeLoader) LoadProcParams(args *ArgType, procTpl *Proc) error {
 		}
 
 		// TODO: fix this so that nullable types can be used as parameters
-		_, _, paramTpl.Type = tl.ParseType(args, strings.TrimSpace(p.ParamType), false)
+		_, _, paramTpl.Type = tl.parseType(args, "", "", strings.TrimSpace(p.ParamType), false)
 
 		// add to proc params
 		if procTpl.ProcParams != "" {
@@ -570,6 +636,20 @@ func (tl TypeLoader) isIgnored(args *ArgType, tableName string) bool {
 	return false
 }
 
+// parseType parses the database type dt of the column of table into a Go
+// type, the type overrides taking precedence over ParseType.
+func (tl TypeLoader) parseType(args *ArgType, table, column, dt string, nullable bool) (int, string, string) {
+	if o := args.typeoverride(table, column, dt); o != nil {
+		nilVal := o.NilValue
+		if nilVal == "" {
+			nilVal = "nil"
+		}
+		return 0, nilVal, o.GoType
+	}
+
+	return tl.ParseType(args, dt, nullable)
+}
+
 // LoadColumns loads schema table/view columns.
 func (tl TypeLoader) LoadColumns(args *ArgType, typeTpl *Type) error {
 	var err error
@@ -605,7 +685,7 @@ func (tl TypeLoader) LoadColumns(args *ArgType, typeTpl *Type) error {
 			Name: snaker.SnakeToCamelIdentifier(c.ColumnName),
 			Col:  c,
 		}
-		f.Len, f.NilType, f.Type = tl.ParseType(args, c.DataType, !c.NotNull)
+		f.Len, f.NilType, f.Type = tl.parseType(args, typeTpl.Table.TableName, c.ColumnName, c.DataType, !c.NotNull)
 
 		// set primary key
 		if c.IsPrimaryKey {
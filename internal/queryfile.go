@@ -0,0 +1,197 @@
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// QueryDef is a custom query to generate a Go type and func from.
+type QueryDef struct {
+	// Query is the query, with its "%%<name> <type>%%" parameters.
+	Query string
+
+	// Type is the name of the generated Go type.
+	Type string
+
+	// Func is the name of the generated Go func.
+	Func string
+
+	// TypeComment is the comment of the generated Go type.
+	TypeComment string
+
+	// FuncComment is the comment of the generated Go func.
+	FuncComment string
+
+	// OnlyOne toggles the generated func to return only one result.
+	OnlyOne bool
+
+	// Exec toggles the generated func to execute the query without results.
+	Exec bool
+}
+
+// QueryDefs returns the custom queries of query mode: the annotated queries
+// of the .sql files in QueryDir when set, or else the query passed by args.
+// The type and func comments of args are the comments of the annotated
+// queries having none.
+func (a *ArgType) QueryDefs() ([]*QueryDef, error) {
+	if a.QueryDir == "" {
+		return []*QueryDef{{
+			Query:       a.Query,
+			Type:        a.QueryType,
+			Func:        a.QueryFunc,
+			TypeComment: a.QueryTypeComment,
+			FuncComment: a.QueryFuncComment,
+			OnlyOne:     a.QueryOnlyOne,
+		}}, nil
+	}
+
+	fi, err := os.Stat(a.QueryDir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{a.QueryDir}
+	if fi.IsDir() {
+		names, err = filepath.Glob(filepath.Join(a.QueryDir, "*.sql"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(names)
+	}
+
+	var defs []*QueryDef
+	for _, name := range names {
+		buf, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		d, err := ParseQueryFile(name, string(buf))
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, d...)
+	}
+
+	for _, d := range defs {
+		if d.TypeComment == "" {
+			d.TypeComment = a.QueryTypeComment
+		}
+		if d.FuncComment == "" {
+			d.FuncComment = a.QueryFuncComment
+		}
+	}
+
+	return defs, nil
+}
+
+// ParseQueryFile parses the annotated queries of the sql file name. Each query
+// starts with a "-- name: <Func> [:one|:many|:exec]" line, followed by
+// optional annotations:
+//
+//	-- type: <Type>       the Go type of the results, shared by the queries
+//	                      having the same type
+//	-- type-comment: <c>  the comment of the Go type, the one of the first
+//	                      query of the type is used
+//	-- one, -- many       whether the func returns one or all the results
+//	-- exec               the func only executes the query
+//	-- <comment>          any other comment is the comment of the func
+//
+// The query runs up to the next "-- name:" line, its trailing semicolon is
+// removed. The lines before the first "-- name:" line are ignored.
+func ParseQueryFile(name, buf string) ([]*QueryDef, error) {
+	var defs []*QueryDef
+	var def *QueryDef
+	var query []string
+	var comments []string
+
+	// end adds the current query to defs
+	end := func() error {
+		if def == nil {
+			return nil
+		}
+
+		def.Query = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(strings.Join(query, "\n")), ";"))
+		def.FuncComment = strings.Join(comments, " ")
+		if def.Query == "" {
+			return fmt.Errorf("%s: query %s is empty", name, def.Func)
+		}
+		if def.Type == "" && !def.Exec {
+			return fmt.Errorf("%s: query %s has no type", name, def.Func)
+		}
+
+		defs = append(defs, def)
+		def, query, comments = nil, nil, nil
+		return nil
+	}
+
+	for i, line := range strings.Split(buf, "\n") {
+		l := strings.TrimSpace(line)
+
+		// annotations only precede the query
+		if !strings.HasPrefix(l, "--") || (def != nil && len(query) != 0 && !strings.HasPrefix(l, "-- name:")) {
+			if def != nil && (len(query) != 0 || l != "") {
+				query = append(query, line)
+			}
+			continue
+		}
+
+		annotation := strings.TrimSpace(strings.TrimPrefix(l, "--"))
+		switch {
+		case strings.HasPrefix(annotation, "name:"):
+			if err := end(); err != nil {
+				return nil, err
+			}
+
+			f := strings.Fields(strings.TrimPrefix(annotation, "name:"))
+			if len(f) == 0 || len(f) > 2 {
+				return nil, fmt.Errorf("%s:%d: invalid annotation %q", name, i+1, l)
+			}
+			def = &QueryDef{Func: f[0]}
+			if len(f) == 2 {
+				if err := def.setMode(strings.TrimPrefix(f[1], ":")); err != nil {
+					return nil, fmt.Errorf("%s:%d: %v", name, i+1, err)
+				}
+			}
+
+		case def == nil:
+
+		case strings.HasPrefix(annotation, "type:"):
+			def.Type = strings.TrimSpace(strings.TrimPrefix(annotation, "type:"))
+
+		case strings.HasPrefix(annotation, "type-comment:"):
+			def.TypeComment = strings.TrimSpace(strings.TrimPrefix(annotation, "type-comment:"))
+
+		case annotation == "one" || annotation == "many" || annotation == "exec":
+			def.setMode(annotation)
+
+		case annotation != "":
+			comments = append(comments, annotation)
+		}
+	}
+
+	if err := end(); err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+// setMode sets how the func of the query returns its results: one, many or exec.
+func (d *QueryDef) setMode(mode string) error {
+	switch mode {
+	case "one":
+		d.OnlyOne, d.Exec = true, false
+	case "many":
+		d.OnlyOne, d.Exec = false, false
+	case "exec":
+		d.OnlyOne, d.Exec = false, true
+	default:
+		return fmt.Errorf("unknown query mode %q", mode)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_ParseQueryFile(t *testing.T) {
+	tests := []struct {
+		desc string
+		buf  string
+		defs []*QueryDef
+	}{
+		{
+			desc: "name without mode",
+			buf: `-- name: AuthorsByName
+-- type: Author
+SELECT author_id, name FROM authors WHERE name = %%name string%%;
+`,
+			defs: []*QueryDef{{
+				Func:  "AuthorsByName",
+				Type:  "Author",
+				Query: "SELECT author_id, name FROM authors WHERE name = %%name string%%",
+			}},
+		},
+		{
+			desc: "name with modes",
+			buf: `-- name: AuthorByID :one
+-- type: Author
+SELECT author_id, name FROM authors WHERE author_id = %%id int%%
+
+-- name: DeleteAuthors :exec
+DELETE FROM authors
+
+-- name: Authors :many
+-- type: Author
+SELECT author_id, name FROM authors
+`,
+			defs: []*QueryDef{{
+				Func:    "AuthorByID",
+				Type:    "Author",
+				Query:   "SELECT author_id, name FROM authors WHERE author_id = %%id int%%",
+				OnlyOne: true,
+			}, {
+				Func:  "DeleteAuthors",
+				Query: "DELETE FROM authors",
+				Exec:  true,
+			}, {
+				Func:  "Authors",
+				Type:  "Author",
+				Query: "SELECT author_id, name FROM authors",
+			}},
+		},
+		{
+			desc: "mode annotations and func comments",
+			buf: `-- ignored header
+-- name: BookCount
+-- type: BookCount
+-- one
+-- BookCount counts the books
+-- of an author.
+SELECT count(*) AS count FROM books WHERE author_id = %%authorID int%%;
+`,
+			defs: []*QueryDef{{
+				Func:        "BookCount",
+				Type:        "BookCount",
+				FuncComment: "BookCount counts the books of an author.",
+				Query:       "SELECT count(*) AS count FROM books WHERE author_id = %%authorID int%%",
+				OnlyOne:     true,
+			}},
+		},
+		{
+			desc: "type comment",
+			buf: `-- name: AuthorCount :one
+-- type: AuthorCount
+-- type-comment: AuthorCount is the number of authors.
+-- AuthorCount counts the authors.
+SELECT count(*) AS count FROM authors
+`,
+			defs: []*QueryDef{{
+				Func:        "AuthorCount",
+				Type:        "AuthorCount",
+				TypeComment: "AuthorCount is the number of authors.",
+				FuncComment: "AuthorCount counts the authors.",
+				Query:       "SELECT count(*) AS count FROM authors",
+				OnlyOne:     true,
+			}},
+		},
+		{
+			desc: "comments inside the query body",
+			buf: `-- name: Books
+-- type: Book
+SELECT book_id, title
+-- only the available books
+FROM books
+WHERE available -- not archived
+;
+`,
+			defs: []*QueryDef{{
+				Func:  "Books",
+				Type:  "Book",
+				Query: "SELECT book_id, title\n-- only the available books\nFROM books\nWHERE available -- not archived",
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			defs, err := ParseQueryFile("queries.sql", test.buf)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if len(defs) != len(test.defs) {
+				t.Fatalf("expected %d queries, got: %d", len(test.defs), len(defs))
+			}
+			for i, d := range defs {
+				if !reflect.DeepEqual(d, test.defs[i]) {
+					t.Errorf("query %d: expected %+v, got: %+v", i, test.defs[i], d)
+				}
+			}
+		})
+	}
+}
+
+func Test_ParseQueryFileErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		buf  string
+		err  string
+	}{
+		{
+			desc: "empty query",
+			buf:  "-- name: Authors\n-- type: Author\n;\n-- name: Books\n-- type: Book\nSELECT 1\n",
+			err:  "queries.sql: query Authors is empty",
+		},
+		{
+			desc: "missing type",
+			buf:  "-- name: Authors :many\nSELECT author_id FROM authors\n",
+			err:  "queries.sql: query Authors has no type",
+		},
+		{
+			desc: "unknown mode",
+			buf:  "-- name: Authors :all\n-- type: Author\nSELECT author_id FROM authors\n",
+			err:  `queries.sql:1: unknown query mode "all"`,
+		},
+		{
+			desc: "invalid name annotation",
+			buf:  "-- name:\nSELECT 1\n",
+			err:  `queries.sql:1: invalid annotation "-- name:"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			_, err := ParseQueryFile("queries.sql", test.buf)
+			if err == nil || err.Error() != test.err {
+				t.Errorf("expected error %q, got: %v", test.err, err)
+			}
+		})
+	}
+}
+
+func Test_QueryDefsSharedType(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xo-queries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"a.sql": "-- name: AuthorByID :one\n-- type: Author\nSELECT author_id, name FROM authors WHERE author_id = %%id int%%\n",
+		"b.sql": "-- name: Authors\n-- type: Author\nSELECT author_id, name FROM authors\n",
+		"c.txt": "-- name: Ignored\n-- type: Ignored\nSELECT 1\n",
+	}
+	for name, buf := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(buf), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defs, err := (&ArgType{QueryDir: dir}).QueryDefs()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(defs) != 2 {
+		t.Fatalf("expected 2 queries, got: %d", len(defs))
+	}
+	if defs[0].Func != "AuthorByID" || defs[1].Func != "Authors" {
+		t.Errorf("expected the queries in file order, got: %s, %s", defs[0].Func, defs[1].Func)
+	}
+	if defs[0].Type != "Author" || defs[1].Type != "Author" {
+		t.Errorf("expected the queries to share type Author, got: %s, %s", defs[0].Type, defs[1].Type)
+	}
+}
+
+func Test_QueryDefsComments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xo-queries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	buf := `-- name: AuthorCount :one
+-- type: AuthorCount
+-- type-comment: AuthorCount is the number of authors.
+-- AuthorCount counts the authors.
+SELECT count(*) AS count FROM authors
+
+-- name: BookCount :one
+-- type: BookCount
+SELECT count(*) AS count FROM books
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "counts.sql"), []byte(buf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &ArgType{QueryDir: dir, QueryTypeComment: "a count", QueryFuncComment: "counts the rows"}
+	defs, err := a.QueryDefs()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(defs) != 2 {
+		t.Fatalf("expected 2 queries, got: %d", len(defs))
+	}
+	if defs[0].TypeComment != "AuthorCount is the number of authors." || defs[0].FuncComment != "AuthorCount counts the authors." {
+		t.Errorf("expected the annotated comments, got: %q, %q", defs[0].TypeComment, defs[0].FuncComment)
+	}
+	if defs[1].TypeComment != "a count" || defs[1].FuncComment != "counts the rows" {
+		t.Errorf("expected the comments of the args, got: %q, %q", defs[1].TypeComment, defs[1].FuncComment)
+	}
+}
+
+func Test_sameFields(t *testing.T) {
+	author := []*Field{{Name: "AuthorID", Type: "int"}, {Name: "Name", Type: "string"}}
+
+	tests := []struct {
+		desc string
+		b    []*Field
+		exp  bool
+	}{
+		{
+			desc: "same fields",
+			b:    []*Field{{Name: "AuthorID", Type: "int", Comment: "author_id"}, {Name: "Name", Type: "string"}},
+			exp:  true,
+		},
+		{
+			desc: "missing field",
+			b:    []*Field{{Name: "AuthorID", Type: "int"}},
+		},
+		{
+			desc: "different name",
+			b:    []*Field{{Name: "AuthorID", Type: "int"}, {Name: "Title", Type: "string"}},
+		},
+		{
+			desc: "different type",
+			b:    []*Field{{Name: "AuthorID", Type: "int64"}, {Name: "Name", Type: "string"}},
+		},
+		{
+			desc: "different order",
+			b:    []*Field{{Name: "Name", Type: "string"}, {Name: "AuthorID", Type: "int"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := sameFields(author, test.b); got != test.exp {
+				t.Errorf("expected %t, got: %t", test.exp, got)
+			}
+		})
+	}
+}
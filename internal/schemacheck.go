@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckSchemaDefinitions compares the schema definitions of the drivers to
+// the definition of the first driver, the one the code is generated from,
+// and returns their differences: the missing tables, views and columns, the
+// columns of different Go types, and the different primary keys, indexes
+// and foreign keys.
+func CheckSchemaDefinitions(drivers []string, definitions map[string]SchemaDefinition) []string {
+	if len(drivers) < 2 {
+		return nil
+	}
+
+	first := drivers[0]
+	var diffs []string
+	for _, driver := range drivers[1:] {
+		if driver == first {
+			continue
+		}
+		diffs = append(diffs, checkSchemaDefinition(first, driver, definitions[first], definitions[driver])...)
+	}
+
+	return diffs
+}
+
+// checkSchemaDefinition returns the differences of the schema definitions a
+// of driver1 and b of driver2.
+func checkSchemaDefinition(driver1, driver2 string, a, b SchemaDefinition) []string {
+	var diffs []string
+	diff := func(format string, v ...interface{}) {
+		diffs = append(diffs, fmt.Sprintf(format, v...))
+	}
+
+	typesA, typesB := definitionTypes(a), definitionTypes(b)
+	for _, t := range a.Tables {
+		if _, ok := typesB[t.Name]; !ok {
+			diff("%s %s: missing on %s", relTypeName(t), t.Table.TableName, driver2)
+		}
+	}
+	for _, t := range b.Tables {
+		if _, ok := typesA[t.Name]; !ok {
+			diff("%s %s: missing on %s", relTypeName(t), t.Table.TableName, driver1)
+		}
+	}
+
+	for _, ta := range a.Tables {
+		tb, ok := typesB[ta.Name]
+		if !ok {
+			continue
+		}
+		name := ta.Table.TableName
+
+		if ta.RelType != tb.RelType {
+			diff("%s: %s on %s, %s on %s", name, relTypeName(ta), driver1, relTypeName(tb), driver2)
+		}
+
+		// columns
+		fieldsB := map[string]*Field{}
+		for _, f := range tb.Fields {
+			fieldsB[f.Name] = f
+		}
+		for _, fa := range ta.Fields {
+			fb, ok := fieldsB[fa.Name]
+			switch {
+			case !ok:
+				diff("column %s.%s: missing on %s", name, fa.Col.ColumnName, driver2)
+			case fa.Type != fb.Type:
+				diff("column %s.%s: %s on %s, %s on %s", name, fa.Col.ColumnName, fa.Type, driver1, fb.Type, driver2)
+			}
+			delete(fieldsB, fa.Name)
+		}
+		for _, fb := range tb.Fields {
+			if _, ok := fieldsB[fb.Name]; ok {
+				diff("column %s.%s: missing on %s", name, fb.Col.ColumnName, driver1)
+			}
+		}
+
+		// primary keys
+		if goFieldNames(ta.PrimaryKeyFields) != goFieldNames(tb.PrimaryKeyFields) {
+			diff("primary key of %s: (%s) on %s, (%s) on %s", name, fieldNames(ta.PrimaryKeyFields), driver1, fieldNames(tb.PrimaryKeyFields), driver2)
+		}
+
+		// indexes, by generated func
+		indexesB := map[string]*Index{}
+		for _, ix := range tb.Indexes {
+			indexesB[ix.FuncName] = ix
+		}
+		for _, ixa := range ta.Indexes {
+			ixb, ok := indexesB[ixa.FuncName]
+			switch {
+			case !ok:
+				diff("index %s (%s) of %s: missing on %s", ixa.Index.IndexName, fieldNames(ixa.Fields), name, driver2)
+			case ixa.Index.IsUnique != ixb.Index.IsUnique:
+				diff("index %s (%s) of %s: %s on %s, %s on %s", ixa.Index.IndexName, fieldNames(ixa.Fields), name, uniqueName(ixa), driver1, uniqueName(ixb), driver2)
+			}
+			delete(indexesB, ixa.FuncName)
+		}
+		for _, ixb := range tb.Indexes {
+			if _, ok := indexesB[ixb.FuncName]; ok {
+				diff("index %s (%s) of %s: missing on %s", ixb.Index.IndexName, fieldNames(ixb.Fields), name, driver1)
+			}
+		}
+
+		// foreign keys, by field and referenced field
+		fksB := map[string]*ForeignKey{}
+		for _, fk := range tb.ForeignKeys {
+			fksB[foreignKeyFields(fk)] = fk
+		}
+		for _, fk := range ta.ForeignKeys {
+			key := foreignKeyFields(fk)
+			if _, ok := fksB[key]; !ok {
+				diff("foreign key %s: missing on %s", foreignKeyName(fk), driver2)
+			}
+			delete(fksB, key)
+		}
+		for _, fk := range tb.ForeignKeys {
+			if _, ok := fksB[foreignKeyFields(fk)]; ok {
+				diff("foreign key %s: missing on %s", foreignKeyName(fk), driver1)
+			}
+		}
+	}
+
+	return diffs
+}
+
+// definitionTypes returns the tables and views of the schema definition by
+// Go type name, the tables of a definition including its views.
+func definitionTypes(d SchemaDefinition) map[string]*Type {
+	types := map[string]*Type{}
+	for _, t := range d.Tables {
+		types[t.Name] = t
+	}
+	return types
+}
+
+// relTypeName returns "table" or "view" for the type t.
+func relTypeName(t *Type) string {
+	return strings.ToLower(t.RelType.String())
+}
+
+// uniqueName returns "unique" or "not unique" for the index ix.
+func uniqueName(ix *Index) string {
+	if ix.Index.IsUnique {
+		return "unique"
+	}
+	return "not unique"
+}
+
+// fieldNames returns the comma separated column names of fields.
+func fieldNames(fields []*Field) string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Col.ColumnName
+	}
+	return strings.Join(names, ", ")
+}
+
+// goFieldNames returns the comma separated Go names of fields.
+func goFieldNames(fields []*Field) string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// foreignKeyName returns the column and referenced column of the foreign
+// key fk.
+func foreignKeyName(fk *ForeignKey) string {
+	return fmt.Sprintf("%s.%s -> %s.%s", fk.Type.Table.TableName, fk.Field.Col.ColumnName, fk.RefType.Table.TableName, fk.RefField.Col.ColumnName)
+}
+
+// foreignKeyFields returns the Go field and referenced field of the foreign
+// key fk, the same for all drivers.
+func foreignKeyFields(fk *ForeignKey) string {
+	return fk.Type.Name + "." + fk.Field.Name + ":" + fk.RefType.Name + "." + fk.RefField.Name
+}
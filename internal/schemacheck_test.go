@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xo/xo/models"
+)
+
+// testSchemaDefinition returns the definition of a schema of authors, books
+// and a view of the books.
+func testSchemaDefinition() SchemaDefinition {
+	field := func(name, col, typ string) *Field {
+		return &Field{Name: name, Type: typ, Col: &models.Column{ColumnName: col}}
+	}
+
+	authorID := field("AuthorID", "author_id", "int")
+	authors := &Type{
+		Name:             "Author",
+		RelType:          Table,
+		Table:            &models.Table{TableName: "authors"},
+		PrimaryKey:       authorID,
+		PrimaryKeyFields: []*Field{authorID},
+		Fields:           []*Field{authorID, field("Name", "name", "string")},
+	}
+	authors.Indexes = []*Index{
+		{FuncName: "AuthorByAuthorID", Type: authors, Fields: []*Field{authorID}, Index: &models.Index{IndexName: "authors_pkey", IsUnique: true, IsPrimary: true}},
+		{FuncName: "AuthorsByName", Type: authors, Fields: authors.Fields[1:], Index: &models.Index{IndexName: "authors_name_idx"}},
+	}
+
+	bookID, bookAuthorID := field("BookID", "book_id", "int"), field("AuthorID", "author_id", "int")
+	books := &Type{
+		Name:             "Book",
+		RelType:          Table,
+		Table:            &models.Table{TableName: "books"},
+		PrimaryKey:       bookID,
+		PrimaryKeyFields: []*Field{bookID},
+		Fields:           []*Field{bookID, bookAuthorID, field("Isbn", "isbn", "string")},
+	}
+	books.Indexes = []*Index{
+		{FuncName: "BookByBookID", Type: books, Fields: []*Field{bookID}, Index: &models.Index{IndexName: "books_pkey", IsUnique: true, IsPrimary: true}},
+		{FuncName: "BookByIsbn", Type: books, Fields: books.Fields[2:], Index: &models.Index{IndexName: "books_isbn_key", IsUnique: true}},
+	}
+	books.ForeignKeys = []*ForeignKey{
+		{Name: "Author", Type: books, Field: bookAuthorID, RefType: authors, RefField: authorID},
+	}
+
+	bookTitles := &Type{
+		Name:    "BookTitle",
+		RelType: View,
+		Table:   &models.Table{TableName: "book_titles"},
+		Fields:  []*Field{field("Isbn", "isbn", "string")},
+	}
+
+	return SchemaDefinition{Tables: []*Type{authors, books, bookTitles}}
+}
+
+func Test_CheckSchemaDefinitions(t *testing.T) {
+	tests := []struct {
+		desc   string
+		change func(d *SchemaDefinition)
+		diffs  []string
+	}{
+		{
+			desc:   "same schemas",
+			change: func(d *SchemaDefinition) {},
+		},
+		{
+			desc: "missing table",
+			change: func(d *SchemaDefinition) {
+				d.Tables = d.Tables[1:]
+				d.Tables[0].ForeignKeys = nil
+			},
+			diffs: []string{
+				"table authors: missing on mysql",
+				"foreign key books.author_id -> authors.author_id: missing on mysql",
+			},
+		},
+		{
+			desc: "extra view",
+			change: func(d *SchemaDefinition) {
+				d.Tables = append(d.Tables, &Type{Name: "AuthorName", RelType: View, Table: &models.Table{TableName: "author_names"}})
+			},
+			diffs: []string{"view author_names: missing on postgres"},
+		},
+		{
+			desc: "table instead of view",
+			change: func(d *SchemaDefinition) {
+				d.Tables[2].RelType = Table
+			},
+			diffs: []string{"book_titles: view on postgres, table on mysql"},
+		},
+		{
+			desc: "column type and missing columns",
+			change: func(d *SchemaDefinition) {
+				authors := d.Tables[0]
+				authors.Fields[1] = &Field{Name: "Name", Type: "sql.NullString", Col: &models.Column{ColumnName: "name"}}
+				books := d.Tables[1]
+				books.Fields = append(books.Fields[:2], &Field{Name: "Title", Type: "string", Col: &models.Column{ColumnName: "title"}})
+			},
+			diffs: []string{
+				"column authors.name: string on postgres, sql.NullString on mysql",
+				"column books.isbn: missing on mysql",
+				"column books.title: missing on postgres",
+			},
+		},
+		{
+			desc: "primary key",
+			change: func(d *SchemaDefinition) {
+				books := d.Tables[1]
+				books.PrimaryKeyFields = books.Fields[:2]
+			},
+			diffs: []string{"primary key of books: (book_id) on postgres, (book_id, author_id) on mysql"},
+		},
+		{
+			desc: "indexes",
+			change: func(d *SchemaDefinition) {
+				authors := d.Tables[0]
+				authors.Indexes[1].Index.IsUnique = true
+				books := d.Tables[1]
+				books.Indexes = append(books.Indexes[:1], &Index{FuncName: "BooksByAuthorID", Type: books, Fields: books.Fields[1:2], Index: &models.Index{IndexName: "books_author_id_idx"}})
+			},
+			diffs: []string{
+				"index authors_name_idx (name) of authors: not unique on postgres, unique on mysql",
+				"index books_isbn_key (isbn) of books: missing on mysql",
+				"index books_author_id_idx (author_id) of books: missing on postgres",
+			},
+		},
+		{
+			desc: "foreign keys",
+			change: func(d *SchemaDefinition) {
+				authors, books := d.Tables[0], d.Tables[1]
+				books.ForeignKeys = []*ForeignKey{
+					{Name: "Author", Type: books, Field: books.Fields[0], RefType: authors, RefField: authors.Fields[0]},
+				}
+			},
+			diffs: []string{
+				"foreign key books.author_id -> authors.author_id: missing on mysql",
+				"foreign key books.book_id -> authors.author_id: missing on postgres",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			b := testSchemaDefinition()
+			test.change(&b)
+			definitions := map[string]SchemaDefinition{
+				"postgres": testSchemaDefinition(),
+				"mysql":    b,
+			}
+
+			diffs := CheckSchemaDefinitions([]string{"postgres", "mysql"}, definitions)
+			if !reflect.DeepEqual(diffs, test.diffs) {
+				t.Errorf("expected:\n%q\ngot:\n%q", test.diffs, diffs)
+			}
+		})
+	}
+}
+
+func Test_CheckSchemaDefinitionsOneDriver(t *testing.T) {
+	definitions := map[string]SchemaDefinition{"postgres": testSchemaDefinition()}
+	if diffs := CheckSchemaDefinitions([]string{"postgres"}, definitions); diffs != nil {
+		t.Errorf("expected no differences, got: %q", diffs)
+	}
+}
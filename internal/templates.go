@@ -60,7 +60,7 @@ func (a *ArgType) ExecuteTemplate(tt TemplateType, name string, sub string, obj
 
 	// build template name
 	loaderType := ""
-	if tt != XOTemplate && tt != SchemaTemplate && tt != ExtensionTemplate {
+	if tt != XOTemplate && tt != SchemaTemplate && tt != StorageMockTemplate && tt != StorageMemoryTemplate && tt != ExtensionTemplate {
 		if a.LoaderType == "oci8" || a.LoaderType == "godror" {
 			// force oracle for oci8 since the oracle driver doesn't recognize
 			// 'oracle' as valid protocol
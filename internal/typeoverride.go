@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TypeOverride maps a database type, or a table column, to a Go type instead
+// of the built-in type mapping of the loader.
+type TypeOverride struct {
+	// DBType is the database type to override, e.g. jsonb, citext or a
+	// domain, matched case-insensitively and without precision.
+	DBType string `json:"dbType"`
+
+	// Column is the table.column to override, it takes precedence over the
+	// database type overrides.
+	Column string `json:"column"`
+
+	// GoType is the Go type of the column, used for the nullable columns too.
+	GoType string `json:"goType"`
+
+	// Import is the import path of the package of GoType.
+	Import string `json:"import"`
+
+	// NilValue is the zero value of GoType, defaults to nil.
+	NilValue string `json:"nilValue"`
+
+	// GraphQL is the GraphQL scalar of GoType, required by the GraphQL
+	// extension when GoType is not one of the built-in types.
+	GraphQL string `json:"graphql"`
+
+	// Filter is the filter category of GoType: String, Number or Bool.
+	Filter string `json:"filter"`
+}
+
+// ValidateTypeOverrides checks the type overrides of a.
+func (a *ArgType) ValidateTypeOverrides() error {
+	for _, o := range a.TypeOverrides {
+		name := o.DBType
+		if name == "" {
+			name = o.Column
+		}
+
+		switch {
+		case o.DBType == "" && o.Column == "":
+			return errors.New("type override: dbType or column must be supplied")
+		case o.DBType != "" && o.Column != "":
+			return fmt.Errorf("type override %s: dbType and column cannot be used together", name)
+		case o.Column != "" && strings.Count(o.Column, ".") != 1:
+			return fmt.Errorf("type override %s: column must be table.column", name)
+		case o.GoType == "":
+			return fmt.Errorf("type override %s: goType must be supplied", name)
+		}
+
+		if _, ok := sqlToGoTypeMap[o.GoType]; ok {
+			if o.GraphQL != "" || o.Filter != "" {
+				return fmt.Errorf("type override %s: graphql and filter only apply to custom Go types", name)
+			}
+			continue
+		}
+		if a.EnableExtension && o.GraphQL == "" {
+			return fmt.Errorf("type override %s: graphql scalar must be supplied for Go type %s", name, o.GoType)
+		}
+		switch o.Filter {
+		case "", "String", "Number", "Bool":
+		default:
+			return fmt.Errorf("type override %s: invalid filter %s", name, o.Filter)
+		}
+	}
+
+	return nil
+}
+
+// typeoverride returns the type override of the column of table, or else of
+// the database type dt.
+func (a *ArgType) typeoverride(table, column, dt string) *TypeOverride {
+	if len(a.TypeOverrides) == 0 {
+		return nil
+	}
+
+	if table != "" {
+		for _, o := range a.TypeOverrides {
+			if o.Column == table+"."+column {
+				return o
+			}
+		}
+	}
+
+	dt = strings.ToLower(strings.TrimSpace(dt))
+	if a.Schema != "" {
+		dt = strings.TrimPrefix(dt, strings.ToLower(a.Schema)+".")
+	}
+	dt, _, _ = a.ParsePrecision(dt)
+	for _, o := range a.TypeOverrides {
+		if o.DBType != "" && strings.ToLower(o.DBType) == dt {
+			return o
+		}
+	}
+
+	return nil
+}
+
+// customtype returns the type override of the custom Go type typ, or of a
+// pointer to it, nil when typ is one of the built-in types.
+func (a *ArgType) customtype(typ string) *TypeOverride {
+	typ = strings.TrimPrefix(typ, "*")
+	if _, ok := sqlToGoTypeMap[typ]; ok {
+		return nil
+	}
+
+	for _, o := range a.TypeOverrides {
+		if o.GoType == typ && o.GraphQL != "" {
+			return o
+		}
+	}
+
+	return nil
+}
+
+// istypeoverride determines if typ is a custom Go type of a type override,
+// or a pointer to one
+func (a *ArgType) istypeoverride(typ string) bool {
+	return a.customtype(typ) != nil
+}
+
+// hasvalid determines if the Go type typ of a nullable column has a Valid
+// field, the Go types of the type overrides are used as is
+func (a *ArgType) hasvalid(typ string) bool {
+	if sqlNilTypeMap[typ] == typ {
+		return true
+	}
+	for _, o := range a.TypeOverrides {
+		if o.GoType == typ {
+			return false
+		}
+	}
+	return true
+}
+
+// TypeOverrideImports returns the sorted import paths of the type overrides.
+func (a *ArgType) TypeOverrideImports() []string {
+	imports := make([]string, 0)
+	seen := map[string]bool{}
+	for _, o := range a.TypeOverrides {
+		if o.Import != "" && !seen[o.Import] {
+			seen[o.Import] = true
+			imports = append(imports, o.Import)
+		}
+	}
+	sort.Strings(imports)
+	return imports
+}
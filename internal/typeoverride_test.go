@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_typeoverride(t *testing.T) {
+	jsonb := &TypeOverride{DBType: "JSONB", GoType: "json.RawMessage", Import: "encoding/json"}
+	citext := &TypeOverride{DBType: "citext", GoType: "string"}
+	money := &TypeOverride{DBType: "numeric", GoType: "decimal.Decimal", Import: "github.com/shopspring/decimal"}
+	email := &TypeOverride{DBType: "email", GoType: "mail.Address", Import: "net/mail"}
+	settings := &TypeOverride{Column: "users.settings", GoType: "Settings"}
+	a := &ArgType{
+		Schema:        "public",
+		TypeOverrides: []*TypeOverride{jsonb, citext, money, email, settings},
+	}
+
+	tests := []struct {
+		desc   string
+		table  string
+		column string
+		dt     string
+		exp    *TypeOverride
+	}{
+		{
+			desc:   "database type",
+			table:  "users",
+			column: "profile",
+			dt:     "jsonb",
+			exp:    jsonb,
+		},
+		{
+			desc:   "column takes precedence over database type",
+			table:  "users",
+			column: "settings",
+			dt:     "jsonb",
+			exp:    settings,
+		},
+		{
+			desc:   "column of another table",
+			table:  "accounts",
+			column: "settings",
+			dt:     "text",
+		},
+		{
+			desc:   "case-insensitive database type",
+			table:  "users",
+			column: "name",
+			dt:     "CITEXT",
+			exp:    citext,
+		},
+		{
+			desc:   "database type without precision",
+			table:  "orders",
+			column: "total",
+			dt:     "numeric(10,2)",
+			exp:    money,
+		},
+		{
+			desc:   "database type with whitespace",
+			table:  "orders",
+			column: "amount",
+			dt:     " numeric(12) ",
+			exp:    money,
+		},
+		{
+			desc:   "schema qualified domain",
+			table:  "users",
+			column: "email",
+			dt:     "public.email",
+			exp:    email,
+		},
+		{
+			desc:   "query column",
+			column: "settings",
+			dt:     "jsonb",
+			exp:    jsonb,
+		},
+		{
+			desc:   "no override",
+			table:  "users",
+			column: "id",
+			dt:     "integer",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if o := a.typeoverride(test.table, test.column, test.dt); o != test.exp {
+				t.Errorf("expected %+v, got: %+v", test.exp, o)
+			}
+		})
+	}
+
+	if o := (&ArgType{}).typeoverride("users", "settings", "jsonb"); o != nil {
+		t.Errorf("expected no override without type overrides, got: %+v", o)
+	}
+}
+
+func Test_ValidateTypeOverrides(t *testing.T) {
+	tests := []struct {
+		desc      string
+		o         *TypeOverride
+		extension bool
+		err       string
+	}{
+		{
+			desc: "built-in Go type",
+			o:    &TypeOverride{DBType: "citext", GoType: "string"},
+		},
+		{
+			desc:      "custom Go type with graphql scalar",
+			o:         &TypeOverride{Column: "users.settings", GoType: "Settings", GraphQL: "JSON", Filter: "String"},
+			extension: true,
+		},
+		{
+			desc: "custom Go type without extension",
+			o:    &TypeOverride{DBType: "jsonb", GoType: "json.RawMessage"},
+		},
+		{
+			desc: "missing dbType and column",
+			o:    &TypeOverride{GoType: "string"},
+			err:  "type override: dbType or column must be supplied",
+		},
+		{
+			desc: "both dbType and column",
+			o:    &TypeOverride{DBType: "jsonb", Column: "users.settings", GoType: "Settings"},
+			err:  "type override jsonb: dbType and column cannot be used together",
+		},
+		{
+			desc: "column without table",
+			o:    &TypeOverride{Column: "settings", GoType: "Settings"},
+			err:  "type override settings: column must be table.column",
+		},
+		{
+			desc: "missing goType",
+			o:    &TypeOverride{DBType: "jsonb"},
+			err:  "type override jsonb: goType must be supplied",
+		},
+		{
+			desc: "graphql scalar of a built-in Go type",
+			o:    &TypeOverride{DBType: "citext", GoType: "string", GraphQL: "String"},
+			err:  "type override citext: graphql and filter only apply to custom Go types",
+		},
+		{
+			desc:      "custom Go type without graphql scalar",
+			o:         &TypeOverride{DBType: "jsonb", GoType: "json.RawMessage"},
+			extension: true,
+			err:       "type override jsonb: graphql scalar must be supplied for Go type json.RawMessage",
+		},
+		{
+			desc: "invalid filter",
+			o:    &TypeOverride{DBType: "jsonb", GoType: "json.RawMessage", Filter: "Date"},
+			err:  "type override jsonb: invalid filter Date",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			a := &ArgType{EnableExtension: test.extension, TypeOverrides: []*TypeOverride{test.o}}
+			err := a.ValidateTypeOverrides()
+			switch {
+			case test.err == "" && err != nil:
+				t.Errorf("expected no error, got: %v", err)
+			case test.err != "" && (err == nil || err.Error() != test.err):
+				t.Errorf("expected error %q, got: %v", test.err, err)
+			}
+		})
+	}
+}
+
+func Test_TypeOverrideGoTypes(t *testing.T) {
+	a := &ArgType{
+		TypeOverrides: []*TypeOverride{
+			{DBType: "jsonb", GoType: "json.RawMessage", Import: "encoding/json", GraphQL: "JSON"},
+			{DBType: "json", GoType: "json.RawMessage", Import: "encoding/json", GraphQL: "JSON"},
+			{DBType: "numeric", GoType: "decimal.Decimal", Import: "github.com/shopspring/decimal"},
+			{DBType: "citext", GoType: "string"},
+		},
+	}
+
+	if imports := a.TypeOverrideImports(); !reflect.DeepEqual(imports, []string{"encoding/json", "github.com/shopspring/decimal"}) {
+		t.Errorf("expected the sorted unique imports, got: %v", imports)
+	}
+	if !a.istypeoverride("json.RawMessage") || !a.istypeoverride("*json.RawMessage") {
+		t.Errorf("expected json.RawMessage to be a custom type")
+	}
+	if a.istypeoverride("decimal.Decimal") {
+		t.Errorf("expected decimal.Decimal without graphql scalar not to be a custom type")
+	}
+	if a.istypeoverride("string") {
+		t.Errorf("expected string not to be a custom type")
+	}
+	if a.hasvalid("json.RawMessage") {
+		t.Errorf("expected json.RawMessage to be used as is")
+	}
+	if !a.hasvalid("sql.NullString") {
+		t.Errorf("expected sql.NullString to have a Valid field")
+	}
+}
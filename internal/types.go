@@ -15,6 +15,8 @@ const (
 	QueryTypeTemplate
 	QueryTemplate
 	SchemaTemplate
+	StorageMockTemplate
+	StorageMemoryTemplate
 	ExtensionTemplate
 
 	// always last
@@ -43,6 +45,10 @@ func (tt TemplateType) String() string {
 		s = "query"
 	case SchemaTemplate:
 		s = "schema"
+	case StorageMockTemplate:
+		s = "storage_mock"
+	case StorageMemoryTemplate:
+		s = "storage_memory"
 	case ExtensionTemplate:
 		s = "extension"
 	default:
@@ -178,6 +184,7 @@ type Query struct {
 	QueryComments []string
 	QueryParams   []*QueryParam
 	OnlyOne       bool
+	Exec          bool
 	Interpolate   bool
 	Type          *Type
 	Comment       string
@@ -13,11 +13,11 @@ import (
 	"github.com/knq/snaker"
 )
 
-// ParseQuery takes the query in args and looks for strings in the form of
+// ParseQuery takes the query and looks for strings in the form of
 // "%%<name> <type>[,<option>,...]%%", replacing them with the supplied mask.
 // mask can contain "%d" to indicate current position. The modified query is
 // returned, and the slice of extracted QueryParam's.
-func (a *ArgType) ParseQuery(mask string, interpol bool) (string, []*QueryParam) {
+func (a *ArgType) ParseQuery(query, mask string, interpol bool) (string, []*QueryParam) {
 	dl := a.QueryParamDelimiter
 
 	// create the regexp for the delimiter
@@ -26,7 +26,7 @@ func (a *ArgType) ParseQuery(mask string, interpol bool) (string, []*QueryParam)
 	)
 
 	// grab matches from query string
-	matches := placeholderRE.FindAllStringIndex(a.Query, -1)
+	matches := placeholderRE.FindAllStringIndex(query, -1)
 
 	// return vals and placeholders
 	str := ""
@@ -43,7 +43,7 @@ func (a *ArgType) ParseQuery(mask string, interpol bool) (string, []*QueryParam)
 		}
 
 		// extract parameter info
-		paramStr := a.Query[m[0]+len(dl) : m[1]-len(dl)]
+		paramStr := query[m[0]+len(dl) : m[1]-len(dl)]
 		p := strings.SplitN(paramStr, " ", 2)
 		param := &QueryParam{
 			Name: p[0],
@@ -69,7 +69,7 @@ func (a *ArgType) ParseQuery(mask string, interpol bool) (string, []*QueryParam)
 		}
 
 		// add to string
-		str = str + a.Query[last:m[0]]
+		str = str + query[last:m[0]]
 		if interpol && param.Interpolate {
 			// handle interpolation case
 			xstr := `fmt.Sprintf("%v", ` + param.Name + `)`
@@ -87,7 +87,7 @@ func (a *ArgType) ParseQuery(mask string, interpol bool) (string, []*QueryParam)
 	}
 
 	// add part of query remains
-	str = str + a.Query[last:]
+	str = str + query[last:]
 
 	return str, params
 }
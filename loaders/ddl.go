@@ -0,0 +1,1092 @@
+package loaders
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xo/xo/internal"
+	"github.com/xo/xo/models"
+)
+
+// sqliteHeader is the header of sqlite database files.
+const sqliteHeader = "SQLite format 3\x00"
+
+// IsDDLSource reports whether dsn is a file:// source of DDL statements,
+// that is a file: URL of a .sql file or directory instead of a sqlite
+// database.
+func IsDDLSource(dsn string) bool {
+	if !strings.HasPrefix(dsn, "file:") {
+		return false
+	}
+
+	name, _, err := ddlParseDSN(dsn)
+	if err != nil {
+		return true
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+
+	buf := make([]byte, len(sqliteHeader))
+	n, _ := f.Read(buf)
+	return string(buf[:n]) != sqliteHeader
+}
+
+// DDLLoader returns the TypeLoader of the schema declared by the DDL
+// statements of the file:// source dsn, along with the driver of its
+// dialect. The source is either a .sql file or a directory of migration
+// files applied in name order, the *.down.sql files being skipped. The
+// dialect is given by the dialect parameter (postgres or sqlite3), or else
+// guessed from the statements.
+//
+// The loader reads the CREATE TABLE, INDEX, TYPE and VIEW statements, along
+// with the ALTER and DROP statements changing them, into the same
+// structures the driver's catalog queries produce, so that the generated
+// code is identical to introspecting a database the statements were run
+// against.
+func DDLLoader(dsn string) (string, internal.TypeLoader, error) {
+	name, dialect, err := ddlParseDSN(dsn)
+	if err != nil {
+		return "", internal.TypeLoader{}, err
+	}
+
+	src, err := ddlReadSource(name)
+	if err != nil {
+		return "", internal.TypeLoader{}, err
+	}
+
+	// determine dialect
+	switch dialect {
+	case "":
+		dialect = "postgres"
+		if ddlSqliteRE.MatchString(src) {
+			dialect = "sqlite3"
+		}
+	case "postgres", "postgresql", "pg", "pgsql":
+		dialect = "postgres"
+	case "sqlite3", "sqlite", "sq":
+		dialect = "sqlite3"
+	default:
+		return "", internal.TypeLoader{}, fmt.Errorf("unsupported DDL dialect %s", dialect)
+	}
+
+	s := &ddlSchema{dialect: dialect}
+	if err = ddlParse(s, src); err != nil {
+		return "", internal.TypeLoader{}, fmt.Errorf("%s: %v", name, err)
+	}
+
+	return dialect, s.loader(internal.SchemaLoaders[dialect].(internal.TypeLoader)), nil
+}
+
+// ddlSqliteRE matches the statements only sqlite accepts.
+var ddlSqliteRE = regexp.MustCompile(`(?i)\b(autoincrement|without\s+rowid|pragma)\b`)
+
+// ddlParseDSN returns the path and dialect of a file:// source.
+func ddlParseDSN(dsn string) (string, string, error) {
+	name := strings.TrimPrefix(dsn, "file:")
+	name = strings.TrimPrefix(name, "//")
+
+	var dialect string
+	if i := strings.IndexByte(name, '?'); i != -1 {
+		v, err := url.ParseQuery(name[i+1:])
+		if err != nil {
+			return "", "", err
+		}
+		name, dialect = name[:i], strings.ToLower(v.Get("dialect"))
+	}
+	if name == "" {
+		return "", "", errors.New("missing DDL file path")
+	}
+
+	return name, dialect, nil
+}
+
+// ddlReadSource reads the DDL statements of the file, or of the .sql
+// files of the directory name.
+func ddlReadSource(name string) (string, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return "", err
+	}
+
+	names := []string{name}
+	if fi.IsDir() {
+		names, err = filepath.Glob(filepath.Join(name, "*.sql"))
+		if err != nil {
+			return "", err
+		}
+		sort.Strings(names)
+	}
+
+	var buf bytes.Buffer
+	for _, n := range names {
+		if fi.IsDir() && strings.HasSuffix(n, ".down.sql") {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(n)
+		if err != nil {
+			return "", err
+		}
+		buf.Write(b)
+		buf.WriteString("\n;\n")
+	}
+
+	return buf.String(), nil
+}
+
+// ddlSchema is the schema declared by DDL statements.
+type ddlSchema struct {
+	dialect   string
+	tables    []*ddlTable
+	enums     []*ddlEnum
+	functions []*ddlFunction
+}
+
+// ddlTable is a table or a view.
+type ddlTable struct {
+	schema       string
+	name         string
+	view         bool
+	sql          string
+	columns      []*ddlColumn
+	pk           []string
+	indexes      []*ddlIndex
+	fks          []*ddlConstraint
+	sequence     bool
+	withoutRowid bool
+	nocase       bool
+	expand       func() ([]*ddlColumn, error)
+}
+
+// ddlColumn is a table or view column.
+type ddlColumn struct {
+	name    string
+	typ     string
+	def     string
+	notNull bool
+	serial  bool
+}
+
+// ddlIndex is an index, either created or backing a constraint.
+type ddlIndex struct {
+	name    string
+	unique  bool
+	primary bool
+	origin  string
+	columns []string
+	partial bool
+}
+
+// ddlEnum is a postgres enum type.
+type ddlEnum struct {
+	name   string
+	values []string
+}
+
+// ddlFunction is a postgres function.
+type ddlFunction struct {
+	name       string
+	params     []string
+	returnType string
+}
+
+// sameName reports whether a and b name the same object.
+func (s *ddlSchema) sameName(a, b string) bool {
+	if s.dialect == "sqlite3" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// inSchema reports whether the objects qualified by schema are loaded.
+func (s *ddlSchema) inSchema(schema string) bool {
+	if s.dialect == "sqlite3" {
+		return schema == "" || strings.EqualFold(schema, "main")
+	}
+	return schema == "" || schema == "public"
+}
+
+// schemaName returns the schema name of the loaded objects.
+func (s *ddlSchema) schemaName(string) string {
+	if s.dialect == "sqlite3" {
+		return ""
+	}
+	return "public"
+}
+
+// table returns the table or view name.
+func (s *ddlSchema) table(name string) *ddlTable {
+	for _, t := range s.tables {
+		if s.sameName(t.name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// index returns the index name.
+func (s *ddlSchema) index(name string) *ddlIndex {
+	for _, t := range s.tables {
+		for _, ix := range t.indexes {
+			if s.sameName(ix.name, name) {
+				return ix
+			}
+		}
+	}
+	return nil
+}
+
+// enum returns the enum name.
+func (s *ddlSchema) enum(name string) *ddlEnum {
+	for _, e := range s.enums {
+		if e.name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// column returns the column name.
+func (t *ddlTable) column(name string) *ddlColumn {
+	for _, c := range t.columns {
+		if c.name == name || (t.nocase && strings.EqualFold(c.name, name)) {
+			return c
+		}
+	}
+	return nil
+}
+
+// isPrimaryKey reports whether the column name is part of the primary key.
+func (t *ddlTable) isPrimaryKey(name string) bool {
+	for _, n := range t.pk {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// columnNames returns the declared names of the columns, the names of
+// unknown columns are kept as is.
+func (t *ddlTable) columnNames(names []string) []string {
+	res := make([]string, len(names))
+	for i, n := range names {
+		res[i] = n
+		if c := t.column(n); c != nil {
+			res[i] = c.name
+		}
+	}
+	return res
+}
+
+// columnType returns the data type of a column declared by toks, as
+// reported by the database catalog, and whether it is a serial type.
+func (s *ddlSchema) columnType(toks []ddlToken, src string) (string, bool, error) {
+	if s.dialect == "sqlite3" {
+		if len(toks) == 0 {
+			return "", false, nil
+		}
+		return src[toks[0].pos:toks[len(toks)-1].end], false, nil
+	}
+	return pgFormatType(toks)
+}
+
+// pgTypes are the postgres type names and aliases with their
+// format_type name.
+var pgTypes = map[string]string{
+	"int":                         "integer",
+	"int4":                        "integer",
+	"integer":                     "integer",
+	"int2":                        "smallint",
+	"smallint":                    "smallint",
+	"int8":                        "bigint",
+	"bigint":                      "bigint",
+	"serial":                      "integer",
+	"serial4":                     "integer",
+	"smallserial":                 "smallint",
+	"serial2":                     "smallint",
+	"bigserial":                   "bigint",
+	"serial8":                     "bigint",
+	"bool":                        "boolean",
+	"boolean":                     "boolean",
+	"float4":                      "real",
+	"real":                        "real",
+	"float8":                      "double precision",
+	"double precision":            "double precision",
+	"decimal":                     "numeric",
+	"numeric":                     "numeric",
+	"varchar":                     "character varying",
+	"character varying":           "character varying",
+	"char varying":                "character varying",
+	"char":                        "character",
+	"character":                   "character",
+	"bpchar":                      "bpchar",
+	"timestamp":                   "timestamp without time zone",
+	"timestamp without time zone": "timestamp without time zone",
+	"timestamptz":                 "timestamp with time zone",
+	"timestamp with time zone":    "timestamp with time zone",
+	"time":                        "time without time zone",
+	"time without time zone":      "time without time zone",
+	"timetz":                      "time with time zone",
+	"time with time zone":         "time with time zone",
+	"bit":                         "bit",
+	"varbit":                      "bit varying",
+	"bit varying":                 "bit varying",
+}
+
+// pgFormatType returns the postgres type declared by toks as format_type
+// formats it, and whether it is a serial type.
+func pgFormatType(toks []ddlToken) (string, bool, error) {
+	var words []string
+	var mods []int
+	array, quoted := false, false
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case t.kind == ddlIdent && strings.EqualFold(t.val, "ARRAY"):
+			array = true
+		case t.kind == ddlIdent || t.kind == ddlQuoted:
+			v := t.val
+			if t.kind == ddlIdent {
+				v = strings.ToLower(v)
+			} else {
+				quoted = true
+			}
+			if len(words) != 0 && i > 0 && toks[i-1].val == "." {
+				words[len(words)-1] += "." + v
+			} else {
+				words = append(words, v)
+			}
+		case t.kind == ddlPunct && t.val == "(":
+			j := i
+			for j < len(toks) && toks[j].val != ")" {
+				j++
+			}
+			mods = ddlTypeArgs(toks[i:j])
+			i = j
+		case t.kind == ddlPunct && t.val == "[":
+			array = true
+			for i < len(toks) && toks[i].val != "]" {
+				i++
+			}
+		case t.kind == ddlPunct && t.val == ".":
+		default:
+			return "", false, fmt.Errorf("invalid type %q", t.val)
+		}
+	}
+	if len(words) == 0 {
+		return "", false, errors.New("missing type")
+	}
+
+	name := strings.Join(words, " ")
+	typ, ok := pgTypes[name]
+	if quoted {
+		typ, ok = "", false
+	}
+	serial := ok && strings.Contains(name, "serial")
+
+	mod := ""
+	if len(mods) != 0 {
+		s := make([]string, len(mods))
+		for i, m := range mods {
+			s[i] = strconv.Itoa(m)
+		}
+		mod = "(" + strings.Join(s, ",") + ")"
+	}
+
+	switch {
+	case name == "float" || name == "double":
+		typ = "double precision"
+		if len(mods) != 0 && mods[0] <= 24 {
+			typ = "real"
+		}
+	case typ == "numeric" && len(mods) == 1:
+		typ += "(" + strconv.Itoa(mods[0]) + ",0)"
+	case (typ == "character" || typ == "bit") && mod == "":
+		typ += "(1)"
+	case strings.HasPrefix(typ, "time"):
+		parts := strings.SplitN(typ, " ", 2)
+		typ = parts[0] + mod + " " + parts[1]
+	case strings.HasPrefix(name, "interval"):
+		typ = name + mod
+	case ok:
+		typ += mod
+	default:
+		// user types, as seen from the public schema
+		typ = strings.TrimPrefix(strings.TrimPrefix(name, "public."), "pg_catalog.")
+		if quoted && strings.ToLower(typ) != typ {
+			typ = `"` + typ + `"`
+		}
+		typ += mod
+	}
+
+	if array {
+		typ += "[]"
+	}
+	return typ, serial, nil
+}
+
+// pgMaxNameLen is the maximum length of postgres identifiers.
+const pgMaxNameLen = 63
+
+// pgObjectName returns the name postgres generates for an object from
+// name1, name2 and label, the first one for which used is false.
+func pgObjectName(name1, name2, label string, used func(string) bool) string {
+	for pass := 0; ; pass++ {
+		l := label
+		if pass != 0 {
+			l += strconv.Itoa(pass)
+		}
+
+		// truncate the longest name until it fits
+		overhead := len(l) + 1
+		if name2 != "" {
+			overhead++
+		}
+		n1, n2 := len(name1), len(name2)
+		for n1+n2 > pgMaxNameLen-overhead {
+			if n1 > n2 {
+				n1--
+			} else {
+				n2--
+			}
+		}
+
+		name := name1[:n1]
+		if name2 != "" {
+			name += "_" + name2[:n2]
+		}
+		name += "_" + l
+		if !used(name) {
+			return name
+		}
+	}
+}
+
+// pgNameAddition returns the name postgres generates for columns.
+func pgNameAddition(columns []string) string {
+	var s []string
+	for _, c := range columns {
+		if c == "" {
+			c = "expr"
+		}
+		s = append(s, c)
+	}
+	return strings.Join(s, "_")
+}
+
+// relationUsed reports whether a postgres relation is named name.
+func (s *ddlSchema) relationUsed(name string) bool {
+	return s.table(name) != nil || s.index(name) != nil
+}
+
+// constraintUsed reports whether a postgres constraint is named name.
+func (s *ddlSchema) constraintUsed(name string) bool {
+	for _, t := range s.tables {
+		for _, fk := range t.fks {
+			if fk.name == name {
+				return true
+			}
+		}
+		for _, ix := range t.indexes {
+			if ix.name == name && ix.origin != "c" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addConstraints adds the constraints of table t, in declaration order.
+func (s *ddlSchema) addConstraints(t *ddlTable, cons []*ddlConstraint) error {
+	var rowidPk *ddlConstraint
+	for _, c := range cons {
+		c.columns = t.columnNames(c.columns)
+		for _, n := range c.columns {
+			if t.column(n) == nil {
+				return fmt.Errorf("table %s: unknown column %s", t.name, n)
+			}
+		}
+
+		switch c.typ {
+		case "p":
+			if len(t.pk) != 0 {
+				return fmt.Errorf("table %s: multiple primary keys", t.name)
+			}
+			t.pk = c.columns
+
+			if s.dialect == "postgres" {
+				for _, n := range c.columns {
+					t.column(n).notNull = true
+				}
+				name := c.name
+				if name == "" {
+					name = pgObjectName(t.name, "", "pkey", s.relationUsed)
+				}
+				t.indexes = append(t.indexes, &ddlIndex{name: name, unique: true, primary: true, columns: c.columns})
+				continue
+			}
+
+			// integer primary keys are the sqlite rowid
+			if len(c.columns) == 1 && !c.desc && strings.ToUpper(t.column(c.columns[0]).typ) == "INTEGER" {
+				rowidPk = c
+				continue
+			}
+			s.sqAutoIndex(t, "pk", c.columns)
+
+		case "u":
+			if s.dialect == "postgres" {
+				name := c.name
+				if name == "" {
+					name = pgObjectName(t.name, pgNameAddition(c.columns), "key", s.relationUsed)
+				}
+				t.indexes = append(t.indexes, &ddlIndex{name: name, unique: true, columns: c.columns})
+				continue
+			}
+			s.sqAutoIndex(t, "u", c.columns)
+
+		case "f":
+			if s.dialect == "sqlite3" {
+				c.name = ""
+			} else if c.name == "" {
+				c.name = pgObjectName(t.name, pgNameAddition(c.columns), "fkey", s.constraintUsed)
+			}
+			if !s.inSchema(c.refSchema) {
+				continue
+			}
+			if ref := s.table(c.refTable); ref != nil && s.dialect == "postgres" {
+				if len(c.refColumns) == 0 {
+					c.refColumns = ref.pk
+				}
+				c.refColumns = ref.columnNames(c.refColumns)
+			}
+			if len(c.refColumns) != 0 && len(c.refColumns) != len(c.columns) {
+				return fmt.Errorf("table %s: foreign key %s references %d columns", t.name, c.name, len(c.refColumns))
+			}
+			t.fks = append(t.fks, c)
+		}
+	}
+
+	// sqlite column constraints precede the table constraints, even those
+	// of added columns
+	if s.dialect == "sqlite3" {
+		sort.SliceStable(t.fks, func(i, j int) bool {
+			return t.fks[i].inline && !t.fks[j].inline
+		})
+	}
+
+	// the primary key of WITHOUT ROWID tables is not null, its index is
+	// created last
+	if t.withoutRowid {
+		for _, n := range t.pk {
+			t.column(n).notNull = true
+		}
+		if rowidPk != nil {
+			s.sqAutoIndex(t, "pk", rowidPk.columns)
+		}
+	}
+
+	return nil
+}
+
+// sqAutoIndex adds the sqlite index of a primary key or unique constraint
+// to t, unless an index of the same columns exists.
+func (s *ddlSchema) sqAutoIndex(t *ddlTable, origin string, columns []string) {
+	for _, ix := range t.indexes {
+		if strings.Join(ix.columns, "\x00") == strings.Join(columns, "\x00") && ix.origin != "c" {
+			return
+		}
+	}
+
+	t.indexes = append(t.indexes, &ddlIndex{
+		name:    "sqlite_autoindex_" + t.name + "_" + strconv.Itoa(len(t.indexes)+1),
+		unique:  true,
+		origin:  origin,
+		columns: columns,
+	})
+}
+
+// addIndex adds the index created by CREATE INDEX to t.
+func (s *ddlSchema) addIndex(t *ddlTable, name string, unique bool, columns []string, partial bool) error {
+	columns = t.columnNames(columns)
+	for _, n := range columns {
+		if n != "" && t.column(n) == nil {
+			return fmt.Errorf("index %s: unknown column %s", name, n)
+		}
+	}
+	if name == "" {
+		if s.dialect == "sqlite3" {
+			return fmt.Errorf("index on %s: missing name", t.name)
+		}
+		name = pgObjectName(t.name, pgNameAddition(columns), "idx", s.relationUsed)
+	}
+
+	t.indexes = append(t.indexes, &ddlIndex{
+		name:    name,
+		unique:  unique,
+		origin:  "c",
+		columns: columns,
+		partial: partial,
+	})
+	return nil
+}
+
+// drop drops the object kind (TABLE, VIEW, INDEX or TYPE) name.
+func (s *ddlSchema) drop(kind, name string) {
+	switch kind {
+	case "TABLE", "VIEW":
+		for i, t := range s.tables {
+			if s.sameName(t.name, name) {
+				s.tables = append(s.tables[:i], s.tables[i+1:]...)
+				return
+			}
+		}
+
+	case "INDEX":
+		for _, t := range s.tables {
+			for i, ix := range t.indexes {
+				if s.sameName(ix.name, name) {
+					t.indexes = append(t.indexes[:i], t.indexes[i+1:]...)
+					return
+				}
+			}
+		}
+
+	case "TYPE":
+		for i, e := range s.enums {
+			if e.name == name {
+				s.enums = append(s.enums[:i], s.enums[i+1:]...)
+				return
+			}
+		}
+
+	case "FUNCTION":
+		// name is either the name or the signature of the function
+		for i := len(s.functions) - 1; i >= 0; i-- {
+			f := s.functions[i]
+			if f.name == name || f.name+"("+strings.Join(f.params, ", ")+")" == name {
+				s.functions = append(s.functions[:i], s.functions[i+1:]...)
+			}
+		}
+	}
+}
+
+// dropConstraint drops the constraint name of t.
+func (s *ddlSchema) dropConstraint(t *ddlTable, name string) {
+	for i, fk := range t.fks {
+		if fk.name == name {
+			t.fks = append(t.fks[:i], t.fks[i+1:]...)
+			return
+		}
+	}
+	for i, ix := range t.indexes {
+		if ix.name == name && ix.origin != "c" {
+			if ix.primary {
+				t.pk = nil
+			}
+			t.indexes = append(t.indexes[:i], t.indexes[i+1:]...)
+			return
+		}
+	}
+}
+
+// dropColumn drops the column name of t, along with its indexes and
+// constraints.
+func (s *ddlSchema) dropColumn(t *ddlTable, name string) {
+	has := func(columns []string) bool {
+		for _, c := range columns {
+			if s.sameName(c, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i, c := range t.columns {
+		if s.sameName(c.name, name) {
+			t.columns = append(t.columns[:i], t.columns[i+1:]...)
+			break
+		}
+	}
+	if has(t.pk) {
+		t.pk = nil
+	}
+
+	var indexes []*ddlIndex
+	for _, ix := range t.indexes {
+		if !has(ix.columns) {
+			indexes = append(indexes, ix)
+		}
+	}
+	t.indexes = indexes
+
+	var fks []*ddlConstraint
+	for _, fk := range t.fks {
+		if !has(fk.columns) {
+			fks = append(fks, fk)
+		}
+	}
+	t.fks = fks
+}
+
+// renameTable renames t, the foreign keys referencing it follow.
+func (s *ddlSchema) renameTable(t *ddlTable, name string) {
+	for _, o := range s.tables {
+		for _, fk := range o.fks {
+			if s.sameName(fk.refTable, t.name) {
+				fk.refTable = name
+			}
+		}
+	}
+	t.name = name
+}
+
+// renameColumn renames the column from of t to to, the indexes and the
+// foreign keys follow.
+func (s *ddlSchema) renameColumn(t *ddlTable, from, to string) {
+	col := t.column(from)
+	if col == nil {
+		return
+	}
+	rename := func(columns []string) {
+		for i, c := range columns {
+			if s.sameName(c, col.name) {
+				columns[i] = to
+			}
+		}
+	}
+
+	rename(t.pk)
+	for _, ix := range t.indexes {
+		rename(ix.columns)
+	}
+	for _, fk := range t.fks {
+		rename(fk.columns)
+	}
+	for _, o := range s.tables {
+		for _, fk := range o.fks {
+			if s.sameName(fk.refTable, t.name) {
+				rename(fk.refColumns)
+			}
+		}
+	}
+	col.name = to
+}
+
+// renameConstraint renames the constraint from of t to to.
+func (s *ddlSchema) renameConstraint(t *ddlTable, from, to string) {
+	for _, fk := range t.fks {
+		if fk.name == from {
+			fk.name = to
+		}
+	}
+	for _, ix := range t.indexes {
+		if ix.name == from && ix.origin != "c" {
+			ix.name = to
+		}
+	}
+}
+
+// loader returns the TypeLoader of the schema, based on the driver's
+// loader base.
+func (s *ddlSchema) loader(base internal.TypeLoader) internal.TypeLoader {
+	tl := base
+	tl.TableList = s.tableList
+	tl.ColumnList = s.columnList
+	tl.ForeignKeyList = s.foreignKeyList
+	tl.IndexList = s.indexList
+	tl.IndexColumnList = s.indexColumnList
+	tl.QueryColumnList = func(*internal.ArgType, []string) ([]*models.Column, error) {
+		return nil, errors.New("query mode requires a database, not a DDL file")
+	}
+	if s.dialect == "postgres" {
+		tl.EnumList = s.enumList
+		tl.EnumValueList = s.enumValueList
+		tl.ProcList = s.procList
+		tl.ProcParamList = s.procParamList
+	}
+	return tl
+}
+
+// tableList returns the tables or views of the schema.
+func (s *ddlSchema) tableList(_ models.XODB, schema string, relkind string) ([]*models.Table, error) {
+	view := relkind == "v" || relkind == "view"
+
+	var tables []*models.Table
+	for _, t := range s.tables {
+		if t.view != view || t.schema != schema {
+			continue
+		}
+
+		table := &models.Table{TableName: t.name}
+		if s.dialect == "postgres" {
+			table.Type = relkind
+			table.ManualPk = !t.sequence
+			for _, c := range t.columns {
+				table.ManualPk = table.ManualPk && !c.serial
+			}
+		} else {
+			// sqlite system tables are not listed
+			if n := strings.ToLower(t.name); strings.HasPrefix(n, "sqlite") && len(n) > len("sqlite") {
+				continue
+			}
+			table.ManualPk = !strings.Contains(strings.ToLower(t.sql), "autoincrement")
+			for _, c := range t.columns {
+				if t.isPrimaryKey(c.name) {
+					table.ManualPk = table.ManualPk && strings.ToUpper(c.typ) != "INTEGER"
+					break
+				}
+			}
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+// pgSystemColumns are the postgres system columns of tables, loaded with
+// the postgres oids.
+var pgSystemColumns = []*models.Column{
+	{FieldOrdinal: -7, ColumnName: "tableoid", DataType: "oid", NotNull: true},
+	{FieldOrdinal: -6, ColumnName: "cmax", DataType: "cid", NotNull: true},
+	{FieldOrdinal: -5, ColumnName: "xmax", DataType: "xid", NotNull: true},
+	{FieldOrdinal: -4, ColumnName: "cmin", DataType: "cid", NotNull: true},
+	{FieldOrdinal: -3, ColumnName: "xmin", DataType: "xid", NotNull: true},
+	{FieldOrdinal: -1, ColumnName: "ctid", DataType: "tid", NotNull: true},
+}
+
+// columnList returns the columns of the table.
+func (s *ddlSchema) columnList(_ models.XODB, schema string, table string) ([]*models.Column, error) {
+	t := s.table(table)
+	if t == nil {
+		return nil, fmt.Errorf("unknown table %s", table)
+	}
+
+	if t.expand != nil {
+		var err error
+		if t.columns, err = t.expand(); err != nil {
+			return nil, err
+		}
+	}
+
+	cols := []*models.Column{}
+	if s.dialect == "postgres" && !t.view && internal.Args != nil && internal.Args.EnablePostgresOIDs {
+		for _, c := range pgSystemColumns {
+			col := *c
+			cols = append(cols, &col)
+		}
+	}
+	for i, c := range t.columns {
+		col := &models.Column{
+			FieldOrdinal: i,
+			ColumnName:   c.name,
+			DataType:     c.typ,
+			NotNull:      c.notNull && !t.view,
+			DefaultValue: sql.NullString{String: c.def, Valid: c.def != ""},
+			IsPrimaryKey: t.isPrimaryKey(c.name),
+		}
+		if s.dialect == "postgres" {
+			col.FieldOrdinal = i + 1
+			if c.serial && c.def == "" {
+				col.DefaultValue = sql.NullString{String: fmt.Sprintf("nextval('%s_%s_seq'::regclass)", t.name, c.name), Valid: true}
+			}
+		}
+		cols = append(cols, col)
+	}
+
+	return cols, nil
+}
+
+// foreignKeyList returns the foreign keys of the table: ordered by name
+// and column for postgres, from the last declared one for sqlite.
+func (s *ddlSchema) foreignKeyList(_ models.XODB, schema string, table string) ([]*models.ForeignKey, error) {
+	t := s.table(table)
+	if t == nil {
+		return nil, fmt.Errorf("unknown table %s", table)
+	}
+
+	fks := []*models.ForeignKey{}
+	for i := range t.fks {
+		fk := t.fks[len(t.fks)-1-i]
+		for j, col := range fk.columns {
+			refCol := ""
+			if j < len(fk.refColumns) {
+				refCol = fk.refColumns[j]
+			}
+			fks = append(fks, &models.ForeignKey{
+				ForeignKeyName: fk.name,
+				ColumnName:     col,
+				RefIndexName:   s.refIndexName(fk),
+				RefTableName:   fk.refTable,
+				RefColumnName:  refCol,
+				KeyID:          i,
+				SeqNo:          j,
+				OnUpdate:       fk.onUpdate,
+				OnDelete:       fk.onDelete,
+				Match:          fk.match,
+			})
+		}
+	}
+
+	if s.dialect == "postgres" {
+		sort.SliceStable(fks, func(i, j int) bool {
+			if fks[i].ForeignKeyName != fks[j].ForeignKeyName {
+				return fks[i].ForeignKeyName < fks[j].ForeignKeyName
+			}
+			return fks[i].ColumnName < fks[j].ColumnName
+		})
+		for _, fk := range fks {
+			fk.KeyID, fk.SeqNo = 0, 0
+			fk.OnUpdate, fk.OnDelete, fk.Match = "", "", ""
+		}
+	}
+
+	return fks, nil
+}
+
+// refIndexName returns the name of the postgres unique index of the
+// columns referenced by fk.
+func (s *ddlSchema) refIndexName(fk *ddlConstraint) string {
+	ref := s.table(fk.refTable)
+	if s.dialect != "postgres" || ref == nil {
+		return ""
+	}
+	for _, ix := range ref.indexes {
+		if ix.unique && strings.Join(ix.columns, "\x00") == strings.Join(fk.refColumns, "\x00") {
+			return ix.name
+		}
+	}
+	return ""
+}
+
+// indexList returns the indexes of the table having a column: in creation
+// order for postgres, from the last created one for sqlite.
+func (s *ddlSchema) indexList(_ models.XODB, schema string, table string) ([]*models.Index, error) {
+	t := s.table(table)
+	if t == nil {
+		return nil, fmt.Errorf("unknown table %s", table)
+	}
+
+	indexes := []*models.Index{}
+	for i := range t.indexes {
+		ix := t.indexes[i]
+		if s.dialect == "sqlite3" {
+			ix = t.indexes[len(t.indexes)-1-i]
+		}
+		if len(ix.columnList(t)) == 0 {
+			continue
+		}
+
+		index := &models.Index{
+			IndexName: ix.name,
+			IsUnique:  ix.unique,
+			IsPrimary: ix.primary,
+		}
+		if s.dialect == "sqlite3" {
+			index.SeqNo, index.Origin, index.IsPartial = len(indexes), ix.origin, ix.partial
+		}
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+// indexColumnList returns the columns of the index, expressions excluded.
+func (s *ddlSchema) indexColumnList(_ models.XODB, schema string, table string, index string) ([]*models.IndexColumn, error) {
+	t := s.table(table)
+	if t == nil {
+		return nil, fmt.Errorf("unknown table %s", table)
+	}
+
+	for _, ix := range t.indexes {
+		if s.sameName(ix.name, index) {
+			cols := ix.columnList(t)
+			if s.dialect == "postgres" {
+				for _, c := range cols {
+					c.SeqNo++
+					c.Cid++
+				}
+			}
+			return cols, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown index %s", index)
+}
+
+// columnList returns the columns of the index of t.
+func (ix *ddlIndex) columnList(t *ddlTable) []*models.IndexColumn {
+	cols := []*models.IndexColumn{}
+	for _, n := range ix.columns {
+		for i, c := range t.columns {
+			if n != "" && c.name == n {
+				cols = append(cols, &models.IndexColumn{
+					SeqNo:      len(cols),
+					Cid:        i,
+					ColumnName: c.name,
+				})
+			}
+		}
+	}
+	return cols
+}
+
+// enumList returns the postgres enums.
+func (s *ddlSchema) enumList(_ models.XODB, schema string) ([]*models.Enum, error) {
+	var enums []*models.Enum
+	for _, e := range s.enums {
+		enums = append(enums, &models.Enum{EnumName: e.name})
+	}
+	return enums, nil
+}
+
+// enumValueList returns the values of the postgres enum.
+func (s *ddlSchema) enumValueList(_ models.XODB, schema string, enum string) ([]*models.EnumValue, error) {
+	e := s.enum(enum)
+	if e == nil {
+		return nil, fmt.Errorf("unknown enum %s", enum)
+	}
+
+	var values []*models.EnumValue
+	for i, v := range e.values {
+		values = append(values, &models.EnumValue{EnumValue: v, ConstValue: i + 1})
+	}
+	return values, nil
+}
+
+// procList returns the postgres functions.
+func (s *ddlSchema) procList(_ models.XODB, schema string) ([]*models.Proc, error) {
+	procs := []*models.Proc{}
+	for _, f := range s.functions {
+		procs = append(procs, &models.Proc{ProcName: f.name, ReturnType: f.returnType})
+	}
+	return procs, nil
+}
+
+// procParamList returns the parameters of the postgres functions named
+// proc.
+func (s *ddlSchema) procParamList(_ models.XODB, schema string, proc string) ([]*models.ProcParam, error) {
+	params := []*models.ProcParam{}
+	for _, f := range s.functions {
+		if f.name != proc {
+			continue
+		}
+		for _, p := range f.params {
+			params = append(params, &models.ProcParam{ParamType: p})
+		}
+	}
+	return params, nil
+}
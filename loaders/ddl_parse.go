@@ -0,0 +1,1556 @@
+package loaders
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ddl token kinds.
+const (
+	ddlEOF = iota
+	ddlIdent
+	ddlQuoted
+	ddlString
+	ddlNumber
+	ddlPunct
+)
+
+// ddlToken is a token of a DDL statement.
+type ddlToken struct {
+	kind int
+	val  string
+	pos  int
+	end  int
+}
+
+// ddlTokenize splits the sql source src of dialect into tokens, comments and
+// whitespace are dropped.
+func ddlTokenize(dialect, src string) ([]ddlToken, error) {
+	var toks []ddlToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+
+		case strings.HasPrefix(src[i:], "--"):
+			j := strings.IndexByte(src[i:], '\n')
+			if j < 0 {
+				j = len(src) - i
+			}
+			i += j
+
+		case strings.HasPrefix(src[i:], "/*"):
+			j := strings.Index(src[i+2:], "*/")
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", i)
+			}
+			i += j + 4
+
+		case c == '\'' || ((c == 'e' || c == 'E') && strings.HasPrefix(src[i+1:], "'")):
+			start := i
+			escapes := c != '\''
+			if escapes {
+				i++
+			}
+			var sb strings.Builder
+			for i++; ; i++ {
+				if i >= len(src) {
+					return nil, fmt.Errorf("unterminated string at offset %d", start)
+				}
+				if escapes && src[i] == '\\' && i+1 < len(src) {
+					i++
+					sb.WriteByte(src[i])
+					continue
+				}
+				if src[i] == '\'' {
+					if i+1 < len(src) && src[i+1] == '\'' {
+						sb.WriteByte('\'')
+						i++
+						continue
+					}
+					break
+				}
+				sb.WriteByte(src[i])
+			}
+			i++
+			toks = append(toks, ddlToken{kind: ddlString, val: sb.String(), pos: start, end: i})
+
+		case c == '"' || c == '`' || (c == '[' && dialect == "sqlite3"):
+			start := i
+			q := c
+			if c == '[' {
+				q = ']'
+			}
+			var sb strings.Builder
+			for i++; ; i++ {
+				if i >= len(src) {
+					return nil, fmt.Errorf("unterminated identifier at offset %d", start)
+				}
+				if src[i] == q {
+					if q != ']' && i+1 < len(src) && src[i+1] == q {
+						sb.WriteByte(q)
+						i++
+						continue
+					}
+					break
+				}
+				sb.WriteByte(src[i])
+			}
+			i++
+			toks = append(toks, ddlToken{kind: ddlQuoted, val: sb.String(), pos: start, end: i})
+
+		case c == '$' && ddlDollarTag(src[i:]) != "":
+			start := i
+			tag := ddlDollarTag(src[i:])
+			j := strings.Index(src[i+len(tag):], tag)
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated dollar quoted string at offset %d", start)
+			}
+			i += len(tag) + j + len(tag)
+			toks = append(toks, ddlToken{kind: ddlString, val: src[start+len(tag) : i-len(tag)], pos: start, end: i})
+
+		case ddlIsIdentStart(c):
+			start := i
+			for i < len(src) && (ddlIsIdentStart(src[i]) || ddlIsDigit(src[i]) || src[i] == '$') {
+				i++
+			}
+			toks = append(toks, ddlToken{kind: ddlIdent, val: src[start:i], pos: start, end: i})
+
+		case ddlIsDigit(c) || (c == '.' && i+1 < len(src) && ddlIsDigit(src[i+1])):
+			start := i
+			for i < len(src) && (ddlIsDigit(src[i]) || src[i] == '.' || ddlIsIdentStart(src[i])) {
+				i++
+			}
+			toks = append(toks, ddlToken{kind: ddlNumber, val: src[start:i], pos: start, end: i})
+
+		case strings.HasPrefix(src[i:], "::"):
+			toks = append(toks, ddlToken{kind: ddlPunct, val: "::", pos: i, end: i + 2})
+			i += 2
+
+		default:
+			toks = append(toks, ddlToken{kind: ddlPunct, val: string(c), pos: i, end: i + 1})
+			i++
+		}
+	}
+
+	return toks, nil
+}
+
+// ddlDollarTag returns the postgres dollar quote tag ($$ or $tag$) s starts
+// with, if any.
+func ddlDollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '$':
+			return s[:i+1]
+		case ddlIsIdentStart(s[i]) || (i > 1 && ddlIsDigit(s[i])):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func ddlIsIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func ddlIsDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// ddlStatements splits toks into statements. The statements of trigger
+// and atomic function bodies are kept in their CREATE statement.
+func ddlStatements(toks []ddlToken) [][]ddlToken {
+	var stmts [][]ddlToken
+	start, depth, block := 0, 0, false
+	for i, t := range toks {
+		switch {
+		case t.kind == ddlPunct && t.val == ";" && depth == 0:
+			if i > start {
+				stmts = append(stmts, toks[start:i])
+			}
+			start, block = i+1, false
+
+		case t.kind != ddlIdent:
+
+		case strings.EqualFold(t.val, "TRIGGER") && ddlIsCreate(toks[start:i]):
+			block = true
+
+		case strings.EqualFold(t.val, "ATOMIC") && i > 0 && strings.EqualFold(toks[i-1].val, "BEGIN"):
+			block, depth = true, depth+1
+
+		case block && (strings.EqualFold(t.val, "BEGIN") || strings.EqualFold(t.val, "CASE")):
+			if !(i+1 < len(toks) && strings.EqualFold(toks[i+1].val, "ATOMIC")) {
+				depth++
+			}
+
+		case block && strings.EqualFold(t.val, "END") && depth > 0:
+			depth--
+		}
+	}
+	if start < len(toks) {
+		stmts = append(stmts, toks[start:])
+	}
+	return stmts
+}
+
+// ddlIsCreate reports whether toks are the leading words of a CREATE
+// statement.
+func ddlIsCreate(toks []ddlToken) bool {
+	if len(toks) == 0 || !strings.EqualFold(toks[0].val, "CREATE") {
+		return false
+	}
+	for _, t := range toks[1:] {
+		if t.kind != ddlIdent {
+			return false
+		}
+	}
+	return true
+}
+
+// ddlParser parses the statements of a DDL source into a ddlSchema.
+type ddlParser struct {
+	s    *ddlSchema
+	src  string
+	toks []ddlToken
+	i    int
+}
+
+// peek returns the token at offset n from the current one.
+func (p *ddlParser) peek(n int) ddlToken {
+	if p.i+n < len(p.toks) {
+		return p.toks[p.i+n]
+	}
+	return ddlToken{kind: ddlEOF, pos: len(p.src), end: len(p.src)}
+}
+
+// next returns the current token and advances.
+func (p *ddlParser) next() ddlToken {
+	t := p.peek(0)
+	if p.i < len(p.toks) {
+		p.i++
+	}
+	return t
+}
+
+// eof reports whether the statement is fully read.
+func (p *ddlParser) eof() bool {
+	return p.i >= len(p.toks)
+}
+
+// is reports whether the next tokens are the keywords or punctuation words.
+func (p *ddlParser) is(words ...string) bool {
+	for n, w := range words {
+		t := p.peek(n)
+		switch t.kind {
+		case ddlIdent:
+			if !strings.EqualFold(t.val, w) {
+				return false
+			}
+		case ddlPunct:
+			if t.val != w {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// accept advances over words when they are next.
+func (p *ddlParser) accept(words ...string) bool {
+	if !p.is(words...) {
+		return false
+	}
+	p.i += len(words)
+	return true
+}
+
+// expect advances over words, or errors.
+func (p *ddlParser) expect(words ...string) error {
+	if !p.accept(words...) {
+		return p.errorf("expected %s", strings.Join(words, " "))
+	}
+	return nil
+}
+
+// errorf returns an error located at the current token.
+func (p *ddlParser) errorf(format string, v ...interface{}) error {
+	pos := p.peek(0).pos
+	line := 1 + strings.Count(p.src[:pos], "\n")
+	near := p.peek(0).val
+	if p.eof() {
+		near = "end of statement"
+	}
+	return fmt.Errorf("line %d near %q: %s", line, near, fmt.Sprintf(format, v...))
+}
+
+// isName reports whether the current token is an identifier.
+func (p *ddlParser) isName() bool {
+	k := p.peek(0).kind
+	return k == ddlIdent || k == ddlQuoted || (k == ddlString && p.s.dialect == "sqlite3")
+}
+
+// name reads an identifier, unquoted postgres identifiers are folded to
+// lower case.
+func (p *ddlParser) name() (string, error) {
+	if !p.isName() {
+		return "", p.errorf("expected a name")
+	}
+	t := p.next()
+	if t.kind == ddlIdent && p.s.dialect == "postgres" {
+		return strings.ToLower(t.val), nil
+	}
+	return t.val, nil
+}
+
+// qualifiedName reads an optionally schema qualified name.
+func (p *ddlParser) qualifiedName() (string, string, error) {
+	name, err := p.name()
+	if err != nil {
+		return "", "", err
+	}
+	if !p.accept(".") {
+		return "", name, nil
+	}
+	n, err := p.name()
+	if err != nil {
+		return "", "", err
+	}
+	return name, n, nil
+}
+
+// skip advances to the next top level token that is one of the stop
+// words, or to the end of the statement. It returns the source text of the
+// skipped tokens.
+func (p *ddlParser) skip(stops ...string) string {
+	start, depth := p.i, 0
+	for !p.eof() {
+		if depth == 0 {
+			for _, s := range stops {
+				if p.is(s) {
+					return p.text(start, p.i)
+				}
+			}
+		}
+		t := p.next()
+		if t.kind != ddlPunct {
+			continue
+		}
+		switch t.val {
+		case "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+			if depth < 0 {
+				p.i--
+				return p.text(start, p.i)
+			}
+		}
+	}
+	return p.text(start, p.i)
+}
+
+// skipParens advances over a parenthesized list, when it is next.
+func (p *ddlParser) skipParens() bool {
+	if !p.accept("(") {
+		return false
+	}
+	p.skip()
+	p.accept(")")
+	return true
+}
+
+// text returns the source text of the tokens from start to end.
+func (p *ddlParser) text(start, end int) string {
+	if start >= end {
+		return ""
+	}
+	return p.src[p.toks[start].pos:p.toks[end-1].end]
+}
+
+// nameList reads a parenthesized list of index columns: the names of
+// column expressions are empty.
+func (p *ddlParser) nameList() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		name := ""
+		if p.isName() && (p.peek(1).kind != ddlPunct || p.peek(1).val != "(" && p.peek(1).val != ".") {
+			name, _ = p.name()
+		}
+		rest := p.skip(",")
+		if name != "" && ddlIsExpression(rest) {
+			name = ""
+		}
+		names = append(names, name)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// ddlIsExpression reports whether rest, the remainder of an index column
+// following its first name, makes it an expression.
+func ddlIsExpression(rest string) bool {
+	for _, c := range rest {
+		switch c {
+		case '+', '-', '*', '/', '|', '(', ':', '<', '>', '=', '%', '&':
+			return true
+		}
+	}
+	return false
+}
+
+// ddlParse parses the DDL source src into the schema s.
+func ddlParse(s *ddlSchema, src string) error {
+	toks, err := ddlTokenize(s.dialect, src)
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range ddlStatements(toks) {
+		p := &ddlParser{s: s, src: src, toks: stmt}
+		if err := p.statement(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// statement parses the statement, the statements that do not change the
+// tables, views and enums are ignored.
+func (p *ddlParser) statement() error {
+	switch {
+	case p.accept("CREATE"):
+		p.accept("OR", "REPLACE")
+		temp := p.accept("GLOBAL") || p.accept("LOCAL")
+		temp = p.accept("TEMP") || p.accept("TEMPORARY") || temp
+		p.accept("UNLOGGED")
+		switch {
+		case p.accept("TABLE"):
+			return p.createTable(temp)
+		case p.accept("VIEW"):
+			return p.createView(temp)
+		case p.is("UNIQUE") || p.is("INDEX"):
+			return p.createIndex()
+		case p.accept("TYPE"):
+			return p.createType()
+		case p.accept("FUNCTION"):
+			return p.createFunction()
+		}
+
+	case p.accept("ALTER", "TABLE"):
+		return p.alterTable()
+
+	case p.accept("ALTER", "TYPE"):
+		return p.alterType()
+
+	case p.accept("ALTER", "SEQUENCE"):
+		return p.alterSequence()
+
+	case p.accept("DROP"):
+		return p.drop()
+	}
+
+	return nil
+}
+
+// ifNotExists advances over IF NOT EXISTS.
+func (p *ddlParser) ifNotExists() bool {
+	return p.accept("IF", "NOT", "EXISTS")
+}
+
+// createTable parses a CREATE TABLE statement.
+func (p *ddlParser) createTable(temp bool) error {
+	ifNotExists := p.ifNotExists()
+	schema, name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if temp || !p.s.inSchema(schema) {
+		return nil
+	}
+	if t := p.s.table(name); t != nil {
+		if ifNotExists {
+			return nil
+		}
+		return p.errorf("table %s already exists", name)
+	}
+
+	t := &ddlTable{schema: p.s.schemaName(schema), name: name, sql: p.text(0, len(p.toks)), nocase: p.s.dialect == "sqlite3"}
+	if p.is("AS") || p.is("PARTITION", "OF") || p.is("OF") {
+		return p.errorf("unsupported CREATE TABLE %s", p.peek(0).val)
+	}
+	if err = p.expect("("); err != nil {
+		return err
+	}
+
+	var cons []*ddlConstraint
+	for !p.is(")") {
+		if p.isTableConstraint() {
+			c, err := p.tableConstraint()
+			if err != nil {
+				return err
+			}
+			if c != nil {
+				cons = append(cons, c)
+			}
+		} else if p.accept("LIKE") {
+			return p.errorf("unsupported LIKE table")
+		} else {
+			col, ccons, err := p.column()
+			if err != nil {
+				return err
+			}
+			if t.column(col.name) != nil {
+				return p.errorf("duplicate column %s", col.name)
+			}
+			t.columns = append(t.columns, col)
+			cons = append(cons, ccons...)
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err = p.expect(")"); err != nil {
+		return err
+	}
+	for !p.eof() {
+		if p.accept("WITHOUT", "ROWID") {
+			t.withoutRowid = true
+			continue
+		}
+		p.next()
+	}
+
+	p.s.tables = append(p.s.tables, t)
+	return p.s.addConstraints(t, cons)
+}
+
+// isTableConstraint reports whether a table constraint is next.
+func (p *ddlParser) isTableConstraint() bool {
+	return p.is("CONSTRAINT") || p.is("PRIMARY", "KEY") || p.is("FOREIGN", "KEY") ||
+		p.is("UNIQUE", "(") || p.is("UNIQUE", "NULLS") || p.is("CHECK", "(") ||
+		p.is("EXCLUDE", "(") || p.is("EXCLUDE", "USING")
+}
+
+// ddlConstraint is a primary key, unique or foreign key constraint.
+type ddlConstraint struct {
+	typ        string
+	name       string
+	columns    []string
+	refSchema  string
+	refTable   string
+	refColumns []string
+	onUpdate   string
+	onDelete   string
+	match      string
+	desc       bool
+	inline     bool
+}
+
+// tableConstraint parses a table constraint, nil is returned for the
+// constraints without an index.
+func (p *ddlParser) tableConstraint() (*ddlConstraint, error) {
+	c := &ddlConstraint{}
+	var err error
+	if p.accept("CONSTRAINT") {
+		if c.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		c.typ = "p"
+		if c.columns, err = p.nameList(); err != nil {
+			return nil, err
+		}
+
+	case p.accept("UNIQUE"):
+		c.typ = "u"
+		p.accept("NULLS", "NOT", "DISTINCT")
+		p.accept("NULLS", "DISTINCT")
+		if c.columns, err = p.nameList(); err != nil {
+			return nil, err
+		}
+
+	case p.accept("FOREIGN", "KEY"):
+		c.typ = "f"
+		if c.columns, err = p.nameList(); err != nil {
+			return nil, err
+		}
+		if err = p.expect("REFERENCES"); err != nil {
+			return nil, err
+		}
+		if err = p.references(c); err != nil {
+			return nil, err
+		}
+
+	case p.accept("CHECK"), p.accept("EXCLUDE"):
+		c = nil
+
+	default:
+		return nil, p.errorf("unsupported table constraint")
+	}
+
+	p.skip(",", ")")
+	return c, nil
+}
+
+// references parses the referenced table and columns of a foreign key.
+func (p *ddlParser) references(c *ddlConstraint) error {
+	var err error
+	if c.refSchema, c.refTable, err = p.qualifiedName(); err != nil {
+		return err
+	}
+	if p.is("(") {
+		if c.refColumns, err = p.nameList(); err != nil {
+			return err
+		}
+	}
+
+	// actions
+	c.onUpdate, c.onDelete, c.match = "NO ACTION", "NO ACTION", "NONE"
+	for {
+		switch {
+		case p.accept("ON", "DELETE"):
+			c.onDelete = p.action()
+		case p.accept("ON", "UPDATE"):
+			c.onUpdate = p.action()
+		case p.accept("MATCH"):
+			c.match = strings.ToUpper(p.next().val)
+		case p.accept("INITIALLY"):
+			p.next()
+		case p.accept("DEFERRABLE"), p.accept("NOT", "DEFERRABLE"):
+		default:
+			return nil
+		}
+	}
+}
+
+// action reads a foreign key action.
+func (p *ddlParser) action() string {
+	for _, a := range [][]string{{"SET", "NULL"}, {"SET", "DEFAULT"}, {"NO", "ACTION"}} {
+		if p.accept(a...) {
+			return strings.Join(a, " ")
+		}
+	}
+	action := strings.ToUpper(p.next().val)
+	p.skipParens()
+	return action
+}
+
+// ddlColumnStops are the words ending a column type.
+var ddlColumnStops = []string{
+	",", ")", "CONSTRAINT", "PRIMARY", "NOT", "NULL", "UNIQUE", "CHECK",
+	"DEFAULT", "COLLATE", "REFERENCES", "GENERATED", "AS",
+}
+
+// column parses a column definition and its constraints.
+func (p *ddlParser) column() (*ddlColumn, []*ddlConstraint, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, nil, err
+	}
+	col := &ddlColumn{name: name}
+
+	// type
+	start := p.i
+	p.skip(ddlColumnStops...)
+	if col.typ, col.serial, err = p.s.columnType(p.toks[start:p.i], p.src); err != nil {
+		return nil, nil, p.errorf("column %s: %v", name, err)
+	}
+	col.notNull = col.serial
+
+	// constraints
+	var cons []*ddlConstraint
+	for {
+		c := &ddlConstraint{}
+		if p.accept("CONSTRAINT") {
+			if c.name, err = p.name(); err != nil {
+				return nil, nil, err
+			}
+		}
+		switch {
+		case p.accept("PRIMARY", "KEY"):
+			c.typ, c.columns = "p", []string{name}
+			c.desc = p.accept("DESC")
+			p.accept("ASC")
+			cons = append(cons, c)
+
+		case p.accept("NOT", "NULL"):
+			col.notNull = true
+
+		case p.accept("NULL"):
+
+		case p.accept("UNIQUE"):
+			c.typ, c.columns = "u", []string{name}
+			cons = append(cons, c)
+
+		case p.accept("CHECK"):
+			p.skipParens()
+			p.accept("NO", "INHERIT")
+
+		case p.accept("DEFAULT"):
+			start := p.i
+			parens := p.skipParens()
+			if !parens {
+				p.next()
+			}
+			end := p.i
+			p.skip(ddlColumnStops...)
+			col.def = p.text(start, p.i)
+
+			// sqlite drops the parentheses of expressions
+			if parens && p.i == end && p.s.dialect == "sqlite3" {
+				col.def = p.text(start+1, p.i-1)
+			}
+
+		case p.accept("COLLATE"):
+			p.next()
+
+		case p.accept("REFERENCES"):
+			c.typ, c.columns, c.inline = "f", []string{name}, true
+			if err = p.references(c); err != nil {
+				return nil, nil, err
+			}
+			cons = append(cons, c)
+
+		case p.accept("GENERATED"):
+			if !p.accept("ALWAYS") {
+				p.accept("BY", "DEFAULT")
+			}
+			if err = p.expect("AS"); err != nil {
+				return nil, nil, err
+			}
+			if p.accept("IDENTITY") {
+				col.serial, col.notNull = true, true
+			}
+			p.skipParens()
+
+		case p.accept("AS"):
+			p.skipParens()
+
+		case p.is("ON", "CONFLICT"):
+			p.i += 2
+			p.next()
+
+		case p.accept("AUTOINCREMENT"), p.accept("ASC"), p.accept("DESC"),
+			p.accept("DEFERRABLE"), p.accept("NOT", "DEFERRABLE"), p.accept("STORED"), p.accept("VIRTUAL"):
+
+		case p.accept("INITIALLY"):
+			p.next()
+
+		default:
+			if p.is(",") || p.is(")") || p.eof() {
+				return col, cons, nil
+			}
+			return nil, nil, p.errorf("unexpected token in column %s", name)
+		}
+	}
+}
+
+// createIndex parses a CREATE INDEX statement.
+func (p *ddlParser) createIndex() error {
+	unique := p.accept("UNIQUE")
+	if err := p.expect("INDEX"); err != nil {
+		return err
+	}
+	p.accept("CONCURRENTLY")
+	ifNotExists := p.ifNotExists()
+
+	var err error
+	var schema, name string
+	if !p.is("ON") {
+		if schema, name, err = p.qualifiedName(); err != nil {
+			return err
+		}
+	}
+	if err = p.expect("ON"); err != nil {
+		return err
+	}
+	p.accept("ONLY")
+	tschema, tname, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if schema == "" {
+		schema = tschema
+	}
+	if !p.s.inSchema(schema) {
+		return nil
+	}
+	t := p.s.table(tname)
+	if t == nil {
+		return p.errorf("index %s on unknown table %s", name, tname)
+	}
+	if name != "" && p.s.index(name) != nil {
+		if ifNotExists {
+			return nil
+		}
+		return p.errorf("index %s already exists", name)
+	}
+	if p.accept("USING") {
+		p.next()
+	}
+
+	start := p.i
+	columns, err := p.nameList()
+	if err != nil {
+		return err
+	}
+	if name == "" && p.s.dialect == "postgres" {
+		name = pgObjectName(t.name, pgNameAddition(p.indexColumnNames(start, columns)), "idx", p.s.relationUsed)
+	}
+	partial := false
+	for !p.eof() {
+		if p.accept("WHERE") {
+			partial = true
+			break
+		}
+		p.next()
+	}
+
+	return p.s.addIndex(t, name, unique, columns, partial)
+}
+
+// indexColumnNames returns the names postgres gives to the index columns
+// read by nameList from the token start: the column, or else the function
+// an expression calls, if any.
+func (p *ddlParser) indexColumnNames(start int, columns []string) []string {
+	names := append([]string(nil), columns...)
+	n, depth, elem := 0, 0, []ddlToken(nil)
+	for _, t := range p.toks[start+1 : p.i] {
+		if t.kind == ddlPunct && depth == 0 && (t.val == "," || t.val == ")") {
+			if n < len(names) && names[n] == "" {
+				names[n] = p.s.exprName(elem)
+			}
+			n, elem = n+1, nil
+			continue
+		}
+		if t.kind == ddlPunct && t.val == "(" {
+			depth++
+		} else if t.kind == ddlPunct && t.val == ")" {
+			depth--
+		}
+		elem = append(elem, t)
+	}
+	return names
+}
+
+// exprName returns the name postgres gives to the expression toks: the
+// function it calls or the column it casts, "" when there is none.
+func (s *ddlSchema) exprName(toks []ddlToken) string {
+	for len(toks) != 0 && toks[0].kind == ddlPunct && toks[0].val == "(" {
+		toks = toks[1:]
+	}
+	if len(toks) < 2 || (toks[0].kind != ddlIdent && toks[0].kind != ddlQuoted) {
+		return ""
+	}
+	name := toks[0].val
+	if toks[0].kind == ddlIdent {
+		name = strings.ToLower(name)
+	}
+	switch {
+	case name == "cast" && toks[1].val == "(":
+		if len(toks) > 3 && toks[2].kind == ddlIdent && strings.EqualFold(toks[3].val, "AS") {
+			return strings.ToLower(toks[2].val)
+		}
+		return ""
+	case name == "case":
+		return name
+	case toks[1].kind == ddlPunct && (toks[1].val == "(" || toks[1].val == "::"):
+		return name
+	}
+	return ""
+}
+
+// createType parses a CREATE TYPE statement, only enums are kept.
+func (p *ddlParser) createType() error {
+	schema, name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if !p.accept("AS", "ENUM") || !p.s.inSchema(schema) {
+		return nil
+	}
+
+	e := &ddlEnum{name: name}
+	if err = p.expect("("); err != nil {
+		return err
+	}
+	for p.peek(0).kind == ddlString {
+		e.values = append(e.values, p.next().val)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err = p.expect(")"); err != nil {
+		return err
+	}
+
+	p.s.enums = append(p.s.enums, e)
+	return nil
+}
+
+// alterType parses an ALTER TYPE ... ADD VALUE statement.
+func (p *ddlParser) alterType() error {
+	schema, name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	e := p.s.enum(name)
+	if !p.s.inSchema(schema) || e == nil {
+		return nil
+	}
+
+	if p.accept("RENAME", "VALUE") {
+		from := p.next().val
+		p.accept("TO")
+		to := p.next().val
+		for i, v := range e.values {
+			if v == from {
+				e.values[i] = to
+			}
+		}
+		return nil
+	}
+	if !p.accept("ADD", "VALUE") {
+		return nil
+	}
+	p.ifNotExists()
+	value := p.next().val
+	for _, v := range e.values {
+		if v == value {
+			return nil
+		}
+	}
+
+	pos := len(e.values)
+	if before := p.accept("BEFORE"); before || p.accept("AFTER") {
+		ref := p.next().val
+		for i, v := range e.values {
+			if v == ref {
+				pos = i
+				if !before {
+					pos++
+				}
+			}
+		}
+	}
+	e.values = append(e.values[:pos], append([]string{value}, e.values[pos:]...)...)
+	return nil
+}
+
+// alterSequence parses an ALTER SEQUENCE ... OWNED BY statement.
+func (p *ddlParser) alterSequence() error {
+	p.accept("IF", "EXISTS")
+	if _, _, err := p.qualifiedName(); err != nil {
+		return err
+	}
+	for !p.eof() {
+		if !p.accept("OWNED", "BY") {
+			p.next()
+			continue
+		}
+		var names []string
+		for p.isName() {
+			n, _ := p.name()
+			names = append(names, n)
+			if !p.accept(".") {
+				break
+			}
+		}
+		if len(names) < 2 || (len(names) == 3 && !p.s.inSchema(names[0])) {
+			return nil
+		}
+		if t := p.s.table(names[len(names)-2]); t != nil {
+			t.sequence = true
+		}
+	}
+	return nil
+}
+
+// alterTable parses an ALTER TABLE statement.
+func (p *ddlParser) alterTable() error {
+	p.accept("IF", "EXISTS")
+	p.accept("ONLY")
+	schema, name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if !p.s.inSchema(schema) {
+		return nil
+	}
+	t := p.s.table(name)
+	if t == nil {
+		return p.errorf("unknown table %s", name)
+	}
+
+	for !p.eof() {
+		if err = p.alterTableAction(t); err != nil {
+			return err
+		}
+		p.skip(",")
+		if !p.accept(",") {
+			break
+		}
+	}
+	return nil
+}
+
+// alterTableAction parses an action of an ALTER TABLE statement.
+func (p *ddlParser) alterTableAction(t *ddlTable) error {
+	switch {
+	case p.accept("ADD"):
+		if p.isTableConstraint() {
+			c, err := p.tableConstraint()
+			if err != nil || c == nil {
+				return err
+			}
+			return p.s.addConstraints(t, []*ddlConstraint{c})
+		}
+		p.accept("COLUMN")
+		if p.ifNotExists() && t.column(p.columnName()) != nil {
+			return nil
+		}
+		col, cons, err := p.column()
+		if err != nil {
+			return err
+		}
+		if t.column(col.name) != nil {
+			return p.errorf("column %s already exists", col.name)
+		}
+		t.columns = append(t.columns, col)
+		return p.s.addConstraints(t, cons)
+
+	case p.accept("DROP", "CONSTRAINT"):
+		p.accept("IF", "EXISTS")
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		p.s.dropConstraint(t, name)
+
+	case p.accept("DROP"):
+		p.accept("COLUMN")
+		p.accept("IF", "EXISTS")
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		p.s.dropColumn(t, name)
+
+	case p.accept("RENAME", "TO"):
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		p.s.renameTable(t, name)
+
+	case p.accept("RENAME"):
+		if p.accept("CONSTRAINT") {
+			from, err := p.name()
+			if err != nil {
+				return err
+			}
+			if err = p.expect("TO"); err != nil {
+				return err
+			}
+			to, err := p.name()
+			if err != nil {
+				return err
+			}
+			p.s.renameConstraint(t, from, to)
+			return nil
+		}
+		p.accept("COLUMN")
+		from, err := p.name()
+		if err != nil {
+			return err
+		}
+		if err = p.expect("TO"); err != nil {
+			return err
+		}
+		to, err := p.name()
+		if err != nil {
+			return err
+		}
+		p.s.renameColumn(t, from, to)
+
+	case p.accept("ALTER"):
+		p.accept("COLUMN")
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		col := t.column(name)
+		if col == nil {
+			return p.errorf("unknown column %s", name)
+		}
+		switch {
+		case p.accept("SET", "NOT", "NULL"):
+			col.notNull = true
+		case p.accept("DROP", "NOT", "NULL"):
+			col.notNull = t.isPrimaryKey(col.name) && p.s.dialect == "postgres"
+		case p.accept("SET", "DEFAULT"):
+			start := p.i
+			p.skip(",")
+			col.def = p.text(start, p.i)
+		case p.accept("DROP", "DEFAULT"):
+			col.def = ""
+		case p.accept("ADD", "GENERATED"):
+			t.sequence = true
+		case p.accept("SET", "DATA", "TYPE"), p.accept("TYPE"):
+			start := p.i
+			p.skip(",", "USING", "COLLATE")
+			typ, serial, err := p.s.columnType(p.toks[start:p.i], p.src)
+			if err != nil {
+				return p.errorf("column %s: %v", name, err)
+			}
+			col.typ, col.serial = typ, col.serial || serial
+		}
+	}
+
+	return nil
+}
+
+// columnName returns the name of the column definition that is next.
+func (p *ddlParser) columnName() string {
+	i := p.i
+	name, _ := p.name()
+	p.i = i
+	return name
+}
+
+// drop parses a DROP TABLE, VIEW, INDEX, TYPE or FUNCTION statement.
+func (p *ddlParser) drop() error {
+	var kind string
+	for _, k := range []string{"TABLE", "VIEW", "INDEX", "TYPE", "FUNCTION"} {
+		if p.accept(k) {
+			kind = k
+		}
+	}
+	if kind == "" {
+		return nil
+	}
+	p.accept("CONCURRENTLY")
+	p.accept("IF", "EXISTS")
+
+	for {
+		schema, name, err := p.qualifiedName()
+		if err != nil {
+			return err
+		}
+		if kind == "FUNCTION" && p.is("(") {
+			params, err := p.functionParams()
+			if err != nil {
+				return err
+			}
+			name += "(" + strings.Join(params, ", ") + ")"
+		}
+		if p.s.inSchema(schema) {
+			p.s.drop(kind, name)
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	return nil
+}
+
+// createFunction parses a postgres CREATE FUNCTION statement.
+func (p *ddlParser) createFunction() error {
+	if p.s.dialect != "postgres" {
+		return nil
+	}
+	schema, name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	params, err := p.functionParams()
+	if err != nil {
+		return err
+	}
+	if !p.s.inSchema(schema) {
+		return nil
+	}
+
+	f := &ddlFunction{name: name, params: params, returnType: "record"}
+	if p.accept("RETURNS") {
+		switch {
+		case p.accept("TABLE"):
+			start := p.i
+			p.skipParens()
+			f.returnType = "TABLE" + p.text(start, p.i)
+		default:
+			setof := p.accept("SETOF")
+			start := p.i
+			p.skip("AS", "LANGUAGE", "IMMUTABLE", "STABLE", "VOLATILE", "STRICT", "CALLED",
+				"RETURNS", "SECURITY", "PARALLEL", "COST", "ROWS", "SET", "BEGIN", "RETURN", "WINDOW", "LEAKPROOF", "NOT", "EXTERNAL", "TRANSFORM", "SUPPORT")
+			if f.returnType, _, err = pgFormatType(p.toks[start:p.i]); err != nil {
+				return p.errorf("function %s: %v", name, err)
+			}
+			if setof {
+				f.returnType = "SETOF " + f.returnType
+			}
+		}
+	}
+
+	p.s.drop("FUNCTION", name+"("+strings.Join(params, ", ")+")")
+	p.s.functions = append(p.s.functions, f)
+	return nil
+}
+
+// ddlMultiWordTypes are the leading words of the postgres types made of
+// several words.
+var ddlMultiWordTypes = []string{"double", "character", "char", "bit", "timestamp", "time", "national"}
+
+// functionParams reads the parenthesized parameters of a function, it
+// returns the types of the input parameters.
+func (p *ddlParser) functionParams() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	var params []string
+	for !p.is(")") && !p.eof() {
+		out := p.accept("OUT")
+		if !out && !p.accept("INOUT") && !p.accept("IN") {
+			p.accept("VARIADIC")
+		}
+
+		start := p.i
+		p.skip(",", "DEFAULT", "=")
+		toks := p.toks[start:p.i]
+
+		// skip the parameter name
+		if len(toks) > 1 && (toks[1].kind == ddlIdent || toks[1].kind == ddlQuoted) {
+			multi := false
+			for _, w := range ddlMultiWordTypes {
+				multi = multi || strings.EqualFold(toks[0].val, w)
+			}
+			if !multi || toks[0].kind == ddlQuoted {
+				toks = toks[1:]
+			}
+		}
+
+		typ, _, err := pgFormatType(toks)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if !out {
+			params = append(params, typ)
+		}
+
+		p.skip(",")
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// createView parses a CREATE VIEW statement, the types of the columns are
+// those of the columns they select.
+func (p *ddlParser) createView(temp bool) error {
+	p.accept("RECURSIVE")
+	ifNotExists := p.ifNotExists()
+	schema, name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if temp || !p.s.inSchema(schema) {
+		return nil
+	}
+	if p.s.table(name) != nil {
+		if ifNotExists {
+			return nil
+		}
+		p.s.drop("VIEW", name)
+	}
+
+	var names []string
+	if p.is("(") {
+		if names, err = p.nameList(); err != nil {
+			return err
+		}
+	}
+	for !p.eof() && !p.is("AS") {
+		p.next()
+	}
+	if err = p.expect("AS"); err != nil {
+		return err
+	}
+
+	t := &ddlTable{schema: p.s.schemaName(schema), name: name, view: true, sql: p.text(0, len(p.toks)), nocase: p.s.dialect == "sqlite3"}
+	start := p.i
+	expand := func() ([]*ddlColumn, error) {
+		vp := &ddlParser{s: p.s, src: p.src, toks: p.toks, i: start}
+		cols, err := vp.selectColumns()
+		if err != nil {
+			return nil, fmt.Errorf("view %s: %v", name, err)
+		}
+		for i, n := range names {
+			if i < len(cols) {
+				cols[i].name = n
+			}
+		}
+		return cols, nil
+	}
+	if t.columns, err = expand(); err != nil {
+		return err
+	}
+
+	// sqlite expands the views when they are queried
+	if p.s.dialect == "sqlite3" {
+		t.expand = expand
+	}
+
+	p.s.tables = append(p.s.tables, t)
+	return nil
+}
+
+// ddlFromStops are the words ending a FROM clause.
+var ddlFromStops = []string{
+	"WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "OFFSET", "UNION", "INTERSECT",
+	"EXCEPT", "WINDOW", "FETCH", "FOR", "WITH", ")",
+}
+
+// ddlJoins are the words starting a join of a FROM clause.
+var ddlJoins = []string{"JOIN", "LEFT", "RIGHT", "FULL", "INNER", "CROSS", "NATURAL", "OUTER", ","}
+
+// selectColumns parses the select list of a view query.
+func (p *ddlParser) selectColumns() ([]*ddlColumn, error) {
+	for p.accept("(") {
+	}
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	if !p.accept("ALL") && p.accept("DISTINCT") && p.accept("ON") {
+		p.skipParens()
+	}
+
+	// items
+	type item struct{ start, end int }
+	var items []item
+	for {
+		start := p.i
+		p.skip(append([]string{",", "FROM"}, ddlFromStops...)...)
+		items = append(items, item{start, p.i})
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	// from
+	type ref struct {
+		alias string
+		t     *ddlTable
+	}
+	var refs []ref
+	if p.accept("FROM") {
+		for !p.eof() {
+			var r ref
+			if p.is("(") {
+				p.skipParens()
+			} else {
+				p.accept("ONLY")
+				schema, name, err := p.qualifiedName()
+				if err != nil {
+					return nil, err
+				}
+				r.alias = name
+				if p.s.inSchema(schema) {
+					r.t = p.s.table(name)
+				}
+			}
+			p.accept("AS")
+			if p.isName() && !p.isOneOf(ddlFromStops) && !p.isOneOf(ddlJoins) && !p.is("ON") && !p.is("USING") {
+				r.alias, _ = p.name()
+			}
+			refs = append(refs, r)
+			p.skip(append(ddlJoins, ddlFromStops...)...)
+			if p.isOneOf(ddlFromStops) {
+				break
+			}
+			for p.isOneOf(ddlJoins) {
+				p.next()
+			}
+		}
+	}
+
+	// find returns the table of the alias, or of the column
+	find := func(alias, column string) (*ddlTable, *ddlColumn) {
+		for _, r := range refs {
+			if r.t == nil || (alias != "" && !p.s.sameName(r.alias, alias)) {
+				continue
+			}
+			if c := r.t.column(column); c != nil {
+				return r.t, c
+			}
+		}
+		return nil, nil
+	}
+
+	var cols []*ddlColumn
+	for _, it := range items {
+		ip := &ddlParser{s: p.s, src: p.src, toks: p.toks[it.start:it.end]}
+
+		// star
+		if ip.accept("*") || (ip.isName() && ip.peek(1).val == "." && ip.peek(2).val == "*") {
+			alias := ""
+			if !ip.eof() {
+				alias, _ = ip.name()
+			}
+			for _, r := range refs {
+				if r.t == nil {
+					return nil, fmt.Errorf("cannot expand * of a subquery")
+				}
+				if alias == "" || p.s.sameName(r.alias, alias) {
+					for _, c := range r.t.columns {
+						cols = append(cols, &ddlColumn{name: c.name, typ: c.typ})
+					}
+				}
+			}
+			continue
+		}
+
+		col, err := ip.selectItem(find)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+
+	return cols, nil
+}
+
+// isOneOf reports whether the next token is one of words.
+func (p *ddlParser) isOneOf(words []string) bool {
+	for _, w := range words {
+		if p.is(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectItem parses an item of a select list.
+func (p *ddlParser) selectItem(find func(string, string) (*ddlTable, *ddlColumn)) (*ddlColumn, error) {
+	col := &ddlColumn{}
+
+	// alias
+	if n := len(p.toks); n > 1 && (p.toks[n-1].kind == ddlIdent || p.toks[n-1].kind == ddlQuoted) {
+		ap := &ddlParser{s: p.s, src: p.src, toks: p.toks[n-1:]}
+		switch prev := p.toks[n-2]; {
+		case prev.kind == ddlIdent && strings.EqualFold(prev.val, "AS"):
+			col.name, _ = ap.name()
+			p.toks = p.toks[:n-2]
+		case !ddlIsCastSuffix(p.toks[:n-1]) && (prev.kind != ddlPunct || prev.val == ")"):
+			col.name, _ = ap.name()
+			p.toks = p.toks[:n-1]
+		}
+	}
+
+	// column reference
+	if p.isName() {
+		alias, name, err := p.qualifiedName()
+		if err == nil && p.eof() {
+			_, c := find(alias, name)
+			if c == nil {
+				return nil, fmt.Errorf("unknown column %s", p.text(0, len(p.toks)))
+			}
+			col.typ = c.typ
+			if col.name == "" {
+				col.name = c.name
+			}
+			return col, nil
+		}
+		p.i = 0
+	}
+
+	// sqlite only knows the types of the selected columns
+	if p.s.dialect == "sqlite3" {
+		if col.name == "" {
+			col.name = p.text(0, len(p.toks))
+		}
+		return col, nil
+	}
+
+	// cast
+	for i := len(p.toks) - 1; i > 0; i-- {
+		if p.toks[i].kind != ddlPunct || p.toks[i].val != "::" {
+			continue
+		}
+		typ, _, err := p.s.columnType(p.toks[i+1:], p.src)
+		if err != nil {
+			break
+		}
+		col.typ = typ
+		if col.name == "" && p.isName() {
+			np := &ddlParser{s: p.s, src: p.src, toks: p.toks[:i]}
+			if _, name, err := np.qualifiedName(); err == nil && np.eof() {
+				col.name = name
+			}
+		}
+		if col.name != "" {
+			return col, nil
+		}
+		break
+	}
+	if p.is("count", "(") && p.toks[len(p.toks)-1].val == ")" {
+		col.typ = "bigint"
+		if col.name == "" {
+			col.name = "count"
+		}
+		return col, nil
+	}
+
+	return nil, fmt.Errorf("cannot determine the type of %s, cast it with ::type AS name", p.text(0, len(p.toks)))
+}
+
+// ddlIsCastSuffix reports whether toks end with a ::type cast, of which
+// the last word of the select item is part.
+func ddlIsCastSuffix(toks []ddlToken) bool {
+	for i := len(toks) - 1; i >= 0; i-- {
+		switch {
+		case toks[i].kind == ddlPunct && toks[i].val == "::":
+			return true
+		case toks[i].kind != ddlIdent:
+			return false
+		}
+	}
+	return false
+}
+
+// ddlTypeArgs returns the integer arguments of a type modifier.
+func ddlTypeArgs(toks []ddlToken) []int {
+	var args []int
+	for _, t := range toks {
+		if t.kind == ddlNumber {
+			n, err := strconv.Atoi(t.val)
+			if err == nil {
+				args = append(args, n)
+			}
+		}
+	}
+	return args
+}
@@ -0,0 +1,302 @@
+package loaders
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ddlDump returns a summary of the tables, indexes, foreign keys and enums
+// the DDL source src of dialect declares, as loaded by the TypeLoader.
+func ddlDump(dialect, src string) (string, error) {
+	s := &ddlSchema{dialect: dialect}
+	if err := ddlParse(s, src); err != nil {
+		return "", err
+	}
+
+	var lines []string
+	schema := s.schemaName("")
+	for _, relkind := range []string{"r", "v"} {
+		tables, err := s.tableList(nil, schema, relkind)
+		if err != nil {
+			return "", err
+		}
+		for _, t := range tables {
+			kind := "table"
+			if relkind == "v" {
+				kind = "view"
+			}
+			lines = append(lines, fmt.Sprintf("%s %s manualpk=%t", kind, t.TableName, t.ManualPk))
+
+			cols, err := s.columnList(nil, schema, t.TableName)
+			if err != nil {
+				return "", err
+			}
+			for _, c := range cols {
+				l := fmt.Sprintf("  column %s %s", c.ColumnName, c.DataType)
+				if c.NotNull {
+					l += " not null"
+				}
+				if c.IsPrimaryKey {
+					l += " pk"
+				}
+				if c.DefaultValue.Valid {
+					l += " default " + c.DefaultValue.String
+				}
+				lines = append(lines, l)
+			}
+
+			indexes, err := s.indexList(nil, schema, t.TableName)
+			if err != nil {
+				return "", err
+			}
+			for _, ix := range indexes {
+				cols, err := s.indexColumnList(nil, schema, t.TableName, ix.IndexName)
+				if err != nil {
+					return "", err
+				}
+				var names []string
+				for _, c := range cols {
+					names = append(names, c.ColumnName)
+				}
+				l := fmt.Sprintf("  index %s (%s)", ix.IndexName, strings.Join(names, ", "))
+				if ix.IsPrimary {
+					l += " primary"
+				} else if ix.IsUnique {
+					l += " unique"
+				}
+				lines = append(lines, l)
+			}
+
+			fks, err := s.foreignKeyList(nil, schema, t.TableName)
+			if err != nil {
+				return "", err
+			}
+			for _, fk := range fks {
+				lines = append(lines, fmt.Sprintf("  fk %s %s -> %s.%s", fk.ForeignKeyName, fk.ColumnName, fk.RefTableName, fk.RefColumnName))
+			}
+		}
+	}
+
+	enums, _ := s.enumList(nil, schema)
+	for _, e := range enums {
+		values, err := s.enumValueList(nil, schema, e.EnumName)
+		if err != nil {
+			return "", err
+		}
+		var names []string
+		for _, v := range values {
+			names = append(names, v.EnumValue)
+		}
+		lines = append(lines, fmt.Sprintf("enum %s (%s)", e.EnumName, strings.Join(names, ", ")))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func Test_DDLParse(t *testing.T) {
+	tests := []struct {
+		desc    string
+		dialect string
+		src     string
+		exp     string
+	}{
+		{
+			desc:    "composite primary, foreign and unique keys",
+			dialect: "postgres",
+			src: `CREATE TABLE authors (
+  author_id SERIAL PRIMARY KEY,
+  name text NOT NULL DEFAULT ''
+);
+CREATE TABLE books (
+  author_id integer NOT NULL REFERENCES authors,
+  isbn varchar(255) NOT NULL,
+  title text,
+  PRIMARY KEY (author_id, isbn),
+  UNIQUE (title, isbn)
+);
+CREATE TABLE editions (
+  author_id integer,
+  isbn varchar(255),
+  CONSTRAINT editions_book_fk FOREIGN KEY (author_id, isbn) REFERENCES books (author_id, isbn)
+);`,
+			exp: `table authors manualpk=false
+  column author_id integer not null pk default nextval('authors_author_id_seq'::regclass)
+  column name text not null default ''
+  index authors_pkey (author_id) primary
+table books manualpk=true
+  column author_id integer not null pk
+  column isbn character varying(255) not null pk
+  column title text
+  index books_pkey (author_id, isbn) primary
+  index books_title_isbn_key (title, isbn) unique
+  fk books_author_id_fkey author_id -> authors.author_id
+table editions manualpk=true
+  column author_id integer
+  column isbn character varying(255)
+  fk editions_book_fk author_id -> books.author_id
+  fk editions_book_fk isbn -> books.isbn`,
+		},
+		{
+			desc:    "create index",
+			dialect: "postgres",
+			src: `CREATE TABLE authors (author_id integer PRIMARY KEY, name text);
+CREATE INDEX authors_name_idx ON authors (name);
+CREATE INDEX IF NOT EXISTS authors_name_idx ON authors (name);
+CREATE UNIQUE INDEX ON authors (lower(name), author_id);
+CREATE INDEX ON authors USING btree (name) WHERE name IS NOT NULL;`,
+			exp: `table authors manualpk=true
+  column author_id integer not null pk
+  column name text
+  index authors_pkey (author_id) primary
+  index authors_name_idx (name)
+  index authors_lower_author_id_idx (author_id) unique
+  index authors_name_idx1 (name)`,
+		},
+		{
+			desc:    "create type as enum",
+			dialect: "postgres",
+			src: `CREATE TYPE book_type AS ENUM ('FICTION', 'NON;FICTION', 'it''s');
+CREATE TYPE point2d AS (x integer, y integer);
+CREATE TABLE books (book_id integer PRIMARY KEY, book_type book_type NOT NULL);`,
+			exp: `table books manualpk=true
+  column book_id integer not null pk
+  column book_type book_type not null
+  index books_pkey (book_id) primary
+enum book_type (FICTION, NON;FICTION, it's)`,
+		},
+		{
+			desc:    "quoted identifiers",
+			dialect: "postgres",
+			src:     `CREATE TABLE "User Accounts" ("Account ID" integer PRIMARY KEY, "select" text, Email text);`,
+			exp: `table User Accounts manualpk=true
+  column Account ID integer not null pk
+  column select text
+  column email text
+  index User Accounts_pkey (Account ID) primary`,
+		},
+		{
+			desc:    "sqlite quoted identifiers",
+			dialect: "sqlite3",
+			src: "CREATE TABLE [my table] (`id` INTEGER PRIMARY KEY AUTOINCREMENT, \"Name\" TEXT UNIQUE);\n" +
+				`CREATE INDEX my_table_name ON "MY TABLE" (name);`,
+			exp: `table my table manualpk=false
+  column id INTEGER pk
+  column Name TEXT
+  index my_table_name (Name)
+  index sqlite_autoindex_my table_1 (Name) unique`,
+		},
+		{
+			desc:    "comments and string literals containing semicolons",
+			dialect: "postgres",
+			src: `-- a comment; with a semicolon
+/* a block; comment */
+CREATE TABLE notes (
+  note_id integer PRIMARY KEY, -- the id; of the note
+  body text DEFAULT 'a;b',
+  tag text DEFAULT $$c;d$$
+);`,
+			exp: `table notes manualpk=true
+  column note_id integer not null pk
+  column body text default 'a;b'
+  column tag text default $$c;d$$
+  index notes_pkey (note_id) primary`,
+		},
+		{
+			desc:    "alter table add constraint",
+			dialect: "postgres",
+			src: `CREATE TABLE a (a_id integer);
+CREATE TABLE b (b_id integer, a_id integer);
+ALTER TABLE a ADD CONSTRAINT a_pk PRIMARY KEY (a_id);
+ALTER TABLE ONLY b ADD CONSTRAINT b_a_fk FOREIGN KEY (a_id) REFERENCES a (a_id);
+ALTER TABLE b ADD CONSTRAINT b_uq UNIQUE (b_id);`,
+			exp: `table a manualpk=true
+  column a_id integer not null pk
+  index a_pk (a_id) primary
+table b manualpk=true
+  column b_id integer
+  column a_id integer
+  index b_uq (b_id) unique
+  fk b_a_fk a_id -> a.a_id`,
+		},
+		{
+			desc:    "unsupported statements are ignored",
+			dialect: "postgres",
+			src: `CREATE EXTENSION pgcrypto;
+CREATE SEQUENCE counter;
+GRANT ALL ON ALL TABLES IN SCHEMA public TO app;
+INSERT INTO x VALUES ('a;b');
+COMMENT ON TABLE x IS 'y';
+CREATE TABLE other.hidden (id integer);
+CREATE TEMP TABLE scratch (id integer);
+CREATE TABLE kept (id integer);`,
+			exp: `table kept manualpk=true
+  column id integer`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			out, err := ddlDump(test.dialect, test.src)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if out != test.exp {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.exp, out)
+			}
+		})
+	}
+}
+
+func Test_DDLParseErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		err  string
+	}{
+		{
+			desc: "duplicate table",
+			src:  "CREATE TABLE a (a_id integer);\nCREATE TABLE a (b integer);",
+			err:  `line 2 near "(": table a already exists`,
+		},
+		{
+			desc: "duplicate column",
+			src:  "CREATE TABLE a (a_id integer, a_id text);",
+			err:  `line 1 near ")": duplicate column a_id`,
+		},
+		{
+			desc: "unsupported create table as",
+			src:  "CREATE TABLE a AS SELECT 1;",
+			err:  `line 1 near "AS": unsupported CREATE TABLE AS`,
+		},
+		{
+			desc: "index on unknown table",
+			src:  "CREATE INDEX a_idx ON a (b);",
+			err:  `line 1 near "(": index a_idx on unknown table a`,
+		},
+		{
+			desc: "alter unknown table",
+			src:  "ALTER TABLE a ADD CONSTRAINT a_uq UNIQUE (b);",
+			err:  `line 1 near "ADD": unknown table a`,
+		},
+		{
+			desc: "unterminated string",
+			src:  "CREATE TABLE a (name text DEFAULT 'oops);",
+			err:  "unterminated string at offset 34",
+		},
+		{
+			desc: "unterminated comment",
+			src:  "/* never closed",
+			err:  "unterminated comment at offset 0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			_, err := ddlDump("postgres", test.src)
+			if err == nil || err.Error() != test.err {
+				t.Errorf("expected error %q, got: %v", test.err, err)
+			}
+		})
+	}
+}
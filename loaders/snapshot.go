@@ -0,0 +1,479 @@
+package loaders
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/xo/xo/internal"
+	"github.com/xo/xo/models"
+)
+
+// SnapshotVersion is the version of the schema snapshot format.
+const SnapshotVersion = 1
+
+// Snapshot is a schema snapshot: the catalog of each driver as loaded by
+// LoadSchema, that generates the same code without database access.
+type Snapshot struct {
+	Version int               `json:"version"`
+	Drivers []*DriverSnapshot `json:"drivers"`
+}
+
+// DriverSnapshot is the catalog of the schema of a driver.
+type DriverSnapshot struct {
+	Driver string           `json:"driver"`
+	Schema string           `json:"schema"`
+	Enums  []*SnapshotEnum  `json:"enums,omitempty"`
+	Procs  []*SnapshotProc  `json:"procs,omitempty"`
+	Tables []*SnapshotTable `json:"tables,omitempty"`
+}
+
+// SnapshotEnum is an enum of a snapshot.
+type SnapshotEnum struct {
+	Name   string               `json:"name"`
+	Values []*SnapshotEnumValue `json:"values"`
+}
+
+// SnapshotEnumValue is an enum value of a snapshot.
+type SnapshotEnumValue struct {
+	Value      string `json:"value"`
+	ConstValue int    `json:"constValue"`
+}
+
+// SnapshotProc is a stored procedure of a snapshot.
+type SnapshotProc struct {
+	Name       string   `json:"name"`
+	ReturnType string   `json:"returnType"`
+	Params     []string `json:"params"`
+}
+
+// SnapshotTable is a table or a view of a snapshot.
+type SnapshotTable struct {
+	Name        string                `json:"name"`
+	Relkind     string                `json:"relkind"`
+	Type        string                `json:"type,omitempty"`
+	ManualPk    bool                  `json:"manualPk"`
+	Columns     []*SnapshotColumn     `json:"columns"`
+	ForeignKeys []*SnapshotForeignKey `json:"foreignKeys,omitempty"`
+	Indexes     []*SnapshotIndex      `json:"indexes,omitempty"`
+}
+
+// SnapshotColumn is a column of a snapshot.
+type SnapshotColumn struct {
+	Ordinal    int     `json:"ordinal"`
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	NotNull    bool    `json:"notNull"`
+	Default    *string `json:"default,omitempty"`
+	PrimaryKey bool    `json:"primaryKey,omitempty"`
+}
+
+// SnapshotForeignKey is a foreign key column of a snapshot.
+type SnapshotForeignKey struct {
+	Name      string `json:"name"`
+	Column    string `json:"column"`
+	RefIndex  string `json:"refIndex,omitempty"`
+	RefTable  string `json:"refTable"`
+	RefColumn string `json:"refColumn"`
+	KeyID     int    `json:"keyId,omitempty"`
+	SeqNo     int    `json:"seqNo,omitempty"`
+	OnUpdate  string `json:"onUpdate,omitempty"`
+	OnDelete  string `json:"onDelete,omitempty"`
+	Match     string `json:"match,omitempty"`
+}
+
+// SnapshotIndex is an index of a snapshot.
+type SnapshotIndex struct {
+	Name      string                 `json:"name"`
+	IsUnique  bool                   `json:"isUnique,omitempty"`
+	IsPrimary bool                   `json:"isPrimary,omitempty"`
+	SeqNo     int                    `json:"seqNo,omitempty"`
+	Origin    string                 `json:"origin,omitempty"`
+	IsPartial bool                   `json:"isPartial,omitempty"`
+	Columns   []*SnapshotIndexColumn `json:"columns"`
+}
+
+// SnapshotIndexColumn is an index column of a snapshot.
+type SnapshotIndexColumn struct {
+	SeqNo int    `json:"seqNo"`
+	Cid   int    `json:"cid"`
+	Name  string `json:"name"`
+}
+
+// IsSnapshotSource reports whether dsn is a snapshot:// schema snapshot.
+func IsSnapshotSource(dsn string) bool {
+	return strings.HasPrefix(dsn, "snapshot:")
+}
+
+// ReadSnapshot reads the schema snapshot of the snapshot:// source dsn.
+func ReadSnapshot(dsn string) (*Snapshot, error) {
+	name := strings.TrimPrefix(strings.TrimPrefix(dsn, "snapshot:"), "//")
+	if name == "" {
+		return nil, errors.New("missing snapshot file path")
+	}
+
+	buf, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %v", name, err)
+	}
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("snapshot %s: unsupported version %d", name, s.Version)
+	}
+	if len(s.Drivers) == 0 {
+		return nil, fmt.Errorf("snapshot %s: no drivers", name)
+	}
+
+	return &s, nil
+}
+
+// Record returns a TypeLoader that records the catalog loaded by the loader
+// of driver to the snapshot.
+func (s *Snapshot) Record(driver string, loader internal.Loader) (internal.TypeLoader, error) {
+	base, ok := loader.(internal.TypeLoader)
+	if !ok {
+		return internal.TypeLoader{}, fmt.Errorf("cannot snapshot %s schema", driver)
+	}
+
+	d := &DriverSnapshot{Driver: driver}
+	s.Version = SnapshotVersion
+	s.Drivers = append(s.Drivers, d)
+
+	return d.record(base), nil
+}
+
+// Loader returns the TypeLoader of the driver snapshot.
+func (d *DriverSnapshot) Loader() (internal.TypeLoader, error) {
+	if d.Driver == "godror" {
+		ManualLoadOracle()
+	}
+	loader, ok := internal.SchemaLoaders[d.Driver]
+	if !ok {
+		return internal.TypeLoader{}, errors.New("unsupported database type " + d.Driver)
+	}
+
+	tl := loader.(internal.TypeLoader)
+	tl.Schema = func(*internal.ArgType) (string, error) {
+		return d.Schema, nil
+	}
+	if tl.EnumList != nil {
+		tl.EnumList = d.enumList
+		tl.EnumValueList = d.enumValueList
+	}
+	if tl.ProcList != nil {
+		tl.ProcList = d.procList
+		tl.ProcParamList = d.procParamList
+	}
+	tl.TableList = d.tableList
+	tl.ColumnList = d.columnList
+	tl.ForeignKeyList = d.foreignKeyList
+	tl.IndexList = d.indexList
+	tl.IndexColumnList = d.indexColumnList
+	tl.QueryColumnList = func(*internal.ArgType, []string) ([]*models.Column, error) {
+		return nil, errors.New("query mode requires a database, not a schema snapshot")
+	}
+	return tl, nil
+}
+
+// record wraps the catalog funcs of base to record their results.
+func (d *DriverSnapshot) record(base internal.TypeLoader) internal.TypeLoader {
+	tl := base
+	tl.Schema = func(args *internal.ArgType) (string, error) {
+		schema, err := base.SchemaName(args)
+		d.Schema = schema
+		return schema, err
+	}
+	if base.EnumList != nil {
+		tl.EnumList = func(db models.XODB, schema string) ([]*models.Enum, error) {
+			enums, err := base.EnumList(db, schema)
+			for _, e := range enums {
+				d.Enums = append(d.Enums, &SnapshotEnum{Name: e.EnumName, Values: []*SnapshotEnumValue{}})
+			}
+			return enums, err
+		}
+	}
+	if base.EnumValueList != nil {
+		tl.EnumValueList = func(db models.XODB, schema, enum string) ([]*models.EnumValue, error) {
+			values, err := base.EnumValueList(db, schema, enum)
+			if e := d.enum(enum); e != nil {
+				for _, v := range values {
+					e.Values = append(e.Values, &SnapshotEnumValue{Value: v.EnumValue, ConstValue: v.ConstValue})
+				}
+			}
+			return values, err
+		}
+	}
+	if base.ProcList != nil {
+		tl.ProcList = func(db models.XODB, schema string) ([]*models.Proc, error) {
+			procs, err := base.ProcList(db, schema)
+			for _, p := range procs {
+				d.Procs = append(d.Procs, &SnapshotProc{Name: p.ProcName, ReturnType: p.ReturnType, Params: []string{}})
+			}
+			return procs, err
+		}
+	}
+	if base.ProcParamList != nil {
+		tl.ProcParamList = func(db models.XODB, schema, proc string) ([]*models.ProcParam, error) {
+			params, err := base.ProcParamList(db, schema, proc)
+			if p := d.proc(proc); p != nil {
+				for _, pp := range params {
+					p.Params = append(p.Params, pp.ParamType)
+				}
+			}
+			return params, err
+		}
+	}
+	tl.TableList = func(db models.XODB, schema, relkind string) ([]*models.Table, error) {
+		tables, err := base.TableList(db, schema, relkind)
+		for _, t := range tables {
+			d.Tables = append(d.Tables, &SnapshotTable{
+				Name:     t.TableName,
+				Relkind:  relkind,
+				Type:     t.Type,
+				ManualPk: t.ManualPk,
+				Columns:  []*SnapshotColumn{},
+			})
+		}
+		return tables, err
+	}
+	tl.ColumnList = func(db models.XODB, schema, table string) ([]*models.Column, error) {
+		columns, err := base.ColumnList(db, schema, table)
+		if t := d.table(table); t != nil {
+			for _, c := range columns {
+				sc := &SnapshotColumn{
+					Ordinal:    c.FieldOrdinal,
+					Name:       c.ColumnName,
+					Type:       c.DataType,
+					NotNull:    c.NotNull,
+					PrimaryKey: c.IsPrimaryKey,
+				}
+				if c.DefaultValue.Valid {
+					def := c.DefaultValue.String
+					sc.Default = &def
+				}
+				t.Columns = append(t.Columns, sc)
+			}
+		}
+		return columns, err
+	}
+	tl.ForeignKeyList = func(db models.XODB, schema, table string) ([]*models.ForeignKey, error) {
+		fkeys, err := base.ForeignKeyList(db, schema, table)
+		if t := d.table(table); t != nil {
+			for _, fk := range fkeys {
+				t.ForeignKeys = append(t.ForeignKeys, &SnapshotForeignKey{
+					Name:      fk.ForeignKeyName,
+					Column:    fk.ColumnName,
+					RefIndex:  fk.RefIndexName,
+					RefTable:  fk.RefTableName,
+					RefColumn: fk.RefColumnName,
+					KeyID:     fk.KeyID,
+					SeqNo:     fk.SeqNo,
+					OnUpdate:  fk.OnUpdate,
+					OnDelete:  fk.OnDelete,
+					Match:     fk.Match,
+				})
+			}
+		}
+		return fkeys, err
+	}
+	tl.IndexList = func(db models.XODB, schema, table string) ([]*models.Index, error) {
+		indexes, err := base.IndexList(db, schema, table)
+		if t := d.table(table); t != nil {
+			for _, ix := range indexes {
+				t.Indexes = append(t.Indexes, &SnapshotIndex{
+					Name:      ix.IndexName,
+					IsUnique:  ix.IsUnique,
+					IsPrimary: ix.IsPrimary,
+					SeqNo:     ix.SeqNo,
+					Origin:    ix.Origin,
+					IsPartial: ix.IsPartial,
+					Columns:   []*SnapshotIndexColumn{},
+				})
+			}
+		}
+		return indexes, err
+	}
+	tl.IndexColumnList = func(db models.XODB, schema, table, index string) ([]*models.IndexColumn, error) {
+		columns, err := base.IndexColumnList(db, schema, table, index)
+		if ix := d.index(table, index); ix != nil {
+			for _, c := range columns {
+				ix.Columns = append(ix.Columns, &SnapshotIndexColumn{SeqNo: c.SeqNo, Cid: c.Cid, Name: c.ColumnName})
+			}
+		}
+		return columns, err
+	}
+	return tl
+}
+
+// enum returns the enum name of the snapshot.
+func (d *DriverSnapshot) enum(name string) *SnapshotEnum {
+	for _, e := range d.Enums {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// proc returns the proc name of the snapshot.
+func (d *DriverSnapshot) proc(name string) *SnapshotProc {
+	for _, p := range d.Procs {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// table returns the table name of the snapshot.
+func (d *DriverSnapshot) table(name string) *SnapshotTable {
+	for _, t := range d.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// index returns the index name of the table of the snapshot.
+func (d *DriverSnapshot) index(table, name string) *SnapshotIndex {
+	if t := d.table(table); t != nil {
+		for _, ix := range t.Indexes {
+			if ix.Name == name {
+				return ix
+			}
+		}
+	}
+	return nil
+}
+
+// enumList returns the enums of the snapshot.
+func (d *DriverSnapshot) enumList(models.XODB, string) ([]*models.Enum, error) {
+	enums := []*models.Enum{}
+	for _, e := range d.Enums {
+		enums = append(enums, &models.Enum{EnumName: e.Name})
+	}
+	return enums, nil
+}
+
+// enumValueList returns the values of the enum of the snapshot.
+func (d *DriverSnapshot) enumValueList(_ models.XODB, _ string, enum string) ([]*models.EnumValue, error) {
+	values := []*models.EnumValue{}
+	if e := d.enum(enum); e != nil {
+		for _, v := range e.Values {
+			values = append(values, &models.EnumValue{EnumValue: v.Value, ConstValue: v.ConstValue})
+		}
+	}
+	return values, nil
+}
+
+// procList returns the procs of the snapshot.
+func (d *DriverSnapshot) procList(models.XODB, string) ([]*models.Proc, error) {
+	procs := []*models.Proc{}
+	for _, p := range d.Procs {
+		procs = append(procs, &models.Proc{ProcName: p.Name, ReturnType: p.ReturnType})
+	}
+	return procs, nil
+}
+
+// procParamList returns the params of the proc of the snapshot.
+func (d *DriverSnapshot) procParamList(_ models.XODB, _ string, proc string) ([]*models.ProcParam, error) {
+	params := []*models.ProcParam{}
+	if p := d.proc(proc); p != nil {
+		for _, pp := range p.Params {
+			params = append(params, &models.ProcParam{ParamType: pp})
+		}
+	}
+	return params, nil
+}
+
+// tableList returns the tables of kind relkind of the snapshot.
+func (d *DriverSnapshot) tableList(_ models.XODB, _ string, relkind string) ([]*models.Table, error) {
+	var tables []*models.Table
+	for _, t := range d.Tables {
+		if t.Relkind == relkind {
+			tables = append(tables, &models.Table{TableName: t.Name, Type: t.Type, ManualPk: t.ManualPk})
+		}
+	}
+	return tables, nil
+}
+
+// columnList returns the columns of the table of the snapshot.
+func (d *DriverSnapshot) columnList(_ models.XODB, _ string, table string) ([]*models.Column, error) {
+	columns := []*models.Column{}
+	if t := d.table(table); t != nil {
+		for _, c := range t.Columns {
+			col := &models.Column{
+				FieldOrdinal: c.Ordinal,
+				ColumnName:   c.Name,
+				DataType:     c.Type,
+				NotNull:      c.NotNull,
+				IsPrimaryKey: c.PrimaryKey,
+			}
+			if c.Default != nil {
+				col.DefaultValue = sql.NullString{String: *c.Default, Valid: true}
+			}
+			columns = append(columns, col)
+		}
+	}
+	return columns, nil
+}
+
+// foreignKeyList returns the foreign keys of the table of the snapshot.
+func (d *DriverSnapshot) foreignKeyList(_ models.XODB, _ string, table string) ([]*models.ForeignKey, error) {
+	fkeys := []*models.ForeignKey{}
+	if t := d.table(table); t != nil {
+		for _, fk := range t.ForeignKeys {
+			fkeys = append(fkeys, &models.ForeignKey{
+				ForeignKeyName: fk.Name,
+				ColumnName:     fk.Column,
+				RefIndexName:   fk.RefIndex,
+				RefTableName:   fk.RefTable,
+				RefColumnName:  fk.RefColumn,
+				KeyID:          fk.KeyID,
+				SeqNo:          fk.SeqNo,
+				OnUpdate:       fk.OnUpdate,
+				OnDelete:       fk.OnDelete,
+				Match:          fk.Match,
+			})
+		}
+	}
+	return fkeys, nil
+}
+
+// indexList returns the indexes of the table of the snapshot.
+func (d *DriverSnapshot) indexList(_ models.XODB, _ string, table string) ([]*models.Index, error) {
+	indexes := []*models.Index{}
+	if t := d.table(table); t != nil {
+		for _, ix := range t.Indexes {
+			indexes = append(indexes, &models.Index{
+				IndexName: ix.Name,
+				IsUnique:  ix.IsUnique,
+				IsPrimary: ix.IsPrimary,
+				SeqNo:     ix.SeqNo,
+				Origin:    ix.Origin,
+				IsPartial: ix.IsPartial,
+			})
+		}
+	}
+	return indexes, nil
+}
+
+// indexColumnList returns the columns of the index of the table of the
+// snapshot.
+func (d *DriverSnapshot) indexColumnList(_ models.XODB, _ string, table, index string) ([]*models.IndexColumn, error) {
+	columns := []*models.IndexColumn{}
+	if ix := d.index(table, index); ix != nil {
+		for _, c := range ix.Columns {
+			columns = append(columns, &models.IndexColumn{SeqNo: c.SeqNo, Cid: c.Cid, ColumnName: c.Name})
+		}
+	}
+	return columns, nil
+}
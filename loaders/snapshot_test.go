@@ -0,0 +1,241 @@
+package loaders
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xo/xo/internal"
+	"github.com/xo/xo/models"
+)
+
+// snapshotCatalog is the catalog loaded by a TypeLoader.
+type snapshotCatalog struct {
+	Schema       string
+	Enums        []*models.Enum
+	EnumValues   map[string][]*models.EnumValue
+	Tables       []*models.Table
+	Columns      map[string][]*models.Column
+	ForeignKeys  map[string][]*models.ForeignKey
+	Indexes      map[string][]*models.Index
+	IndexColumns map[string][]*models.IndexColumn
+}
+
+// loadCatalog loads the catalog of the schema of tl, in the order
+// LoadSchema loads it.
+func loadCatalog(t *testing.T, tl internal.TypeLoader) *snapshotCatalog {
+	schema, err := tl.SchemaName(&internal.ArgType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &snapshotCatalog{
+		Schema:       schema,
+		EnumValues:   map[string][]*models.EnumValue{},
+		Columns:      map[string][]*models.Column{},
+		ForeignKeys:  map[string][]*models.ForeignKey{},
+		Indexes:      map[string][]*models.Index{},
+		IndexColumns: map[string][]*models.IndexColumn{},
+	}
+	if tl.EnumList != nil {
+		enums, err := tl.EnumList(nil, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range enums {
+			c.Enums = append(c.Enums, e)
+			values, err := tl.EnumValueList(nil, schema, e.EnumName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.EnumValues[e.EnumName] = append([]*models.EnumValue{}, values...)
+		}
+	}
+
+	for _, relkind := range []string{tl.Relkind(internal.Table), tl.Relkind(internal.View)} {
+		tables, err := tl.TableList(nil, schema, relkind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, table := range tables {
+			c.Tables = append(c.Tables, table)
+			name := table.TableName
+
+			columns, err := tl.ColumnList(nil, schema, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Columns[name] = append([]*models.Column{}, columns...)
+
+			fkeys, err := tl.ForeignKeyList(nil, schema, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.ForeignKeys[name] = append([]*models.ForeignKey{}, fkeys...)
+
+			indexes, err := tl.IndexList(nil, schema, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Indexes[name] = append([]*models.Index{}, indexes...)
+			for _, ix := range indexes {
+				columns, err := tl.IndexColumnList(nil, schema, name, ix.IndexName)
+				if err != nil {
+					t.Fatal(err)
+				}
+				c.IndexColumns[name+"."+ix.IndexName] = append([]*models.IndexColumn{}, columns...)
+			}
+		}
+	}
+
+	return c
+}
+
+func Test_SnapshotRoundTrip(t *testing.T) {
+	tests := []struct {
+		dialect string
+		src     string
+	}{
+		{
+			dialect: "postgres",
+			src: `CREATE TYPE book_type AS ENUM ('FICTION', 'NONFICTION');
+CREATE TABLE authors (
+  author_id SERIAL PRIMARY KEY,
+  name text NOT NULL DEFAULT ''
+);
+CREATE INDEX authors_name_idx ON authors (name);
+CREATE TABLE books (
+  book_id SERIAL PRIMARY KEY,
+  author_id integer NOT NULL REFERENCES authors,
+  isbn varchar(255) NOT NULL UNIQUE,
+  book_type book_type NOT NULL DEFAULT 'FICTION'
+);
+CREATE TABLE editions (
+  author_id integer,
+  isbn varchar(255),
+  edition integer NOT NULL,
+  PRIMARY KEY (isbn, edition),
+  FOREIGN KEY (isbn) REFERENCES books (isbn) ON DELETE CASCADE
+);
+CREATE VIEW author_names AS SELECT author_id, name FROM authors;`,
+		},
+		{
+			dialect: "sqlite3",
+			src: `CREATE TABLE authors (
+  author_id integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+  name text NOT NULL DEFAULT ''
+);
+CREATE TABLE books (
+  book_id integer NOT NULL PRIMARY KEY,
+  author_id integer NOT NULL REFERENCES authors (author_id) ON UPDATE CASCADE,
+  isbn varchar(255) NOT NULL UNIQUE
+);
+CREATE INDEX books_author_id_idx ON books (author_id) WHERE author_id > 0;`,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "xo-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, test := range tests {
+		t.Run(test.dialect, func(t *testing.T) {
+			s := &ddlSchema{dialect: test.dialect}
+			if err := ddlParse(s, test.src); err != nil {
+				t.Fatal(err)
+			}
+			base := s.loader(internal.SchemaLoaders[test.dialect].(internal.TypeLoader))
+			base.Schema = func(*internal.ArgType) (string, error) {
+				return s.schemaName(""), nil
+			}
+
+			// record the catalog
+			snapshot := &Snapshot{}
+			recorder, err := snapshot.Record(test.dialect, base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exp := loadCatalog(t, recorder)
+			if len(exp.Tables) == 0 || len(exp.Indexes) == 0 {
+				t.Fatalf("expected tables and indexes, got: %+v", exp)
+			}
+
+			// write and read it back
+			buf, err := json.MarshalIndent(snapshot, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := filepath.Join(dir, test.dialect+".json")
+			if err := ioutil.WriteFile(name, buf, 0644); err != nil {
+				t.Fatal(err)
+			}
+			read, err := ReadSnapshot("snapshot://" + name)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if len(read.Drivers) != 1 || read.Drivers[0].Driver != test.dialect {
+				t.Fatalf("expected one %s driver, got: %+v", test.dialect, read.Drivers)
+			}
+
+			loader, err := read.Drivers[0].Loader()
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			got := loadCatalog(t, loader)
+			if !reflect.DeepEqual(got, exp) {
+				a, _ := json.MarshalIndent(exp, "", "  ")
+				b, _ := json.MarshalIndent(got, "", "  ")
+				t.Errorf("expected:\n%s\ngot:\n%s", a, b)
+			}
+		})
+	}
+}
+
+func Test_ReadSnapshotErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xo-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		desc string
+		buf  string
+		err  string
+	}{
+		{
+			desc: "invalid json",
+			buf:  `{"version":`,
+			err:  "invalid snapshot %s: unexpected end of JSON input",
+		},
+		{
+			desc: "unsupported version",
+			buf:  `{"version":2,"drivers":[{"driver":"postgres"}]}`,
+			err:  "snapshot %s: unsupported version 2",
+		},
+		{
+			desc: "no drivers",
+			buf:  `{"version":1}`,
+			err:  "snapshot %s: no drivers",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			name := filepath.Join(dir, "snapshot.json")
+			if err := ioutil.WriteFile(name, []byte(test.buf), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := ReadSnapshot("snapshot://" + name)
+			if exp := fmt.Sprintf(test.err, name); err == nil || err.Error() != exp {
+				t.Errorf("expected error %q, got: %v", exp, err)
+			}
+		})
+	}
+}
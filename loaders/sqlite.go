@@ -96,6 +96,11 @@ func SqParseType(args *internal.ArgType, dt string, nullable bool) (int, string,
 	case "timestamp", "datetime", "date", "timestamp with time zone", "time with time zone", "time without time zone", "timestamp without time zone":
 		nilVal = "xoutil.SqTime{}"
 		typ = "xoutil.SqTime"
+		if nullable {
+			// xoutil.SqTime cannot be NULL
+			nilVal = "NullTime{}"
+			typ = "NullTime"
+		}
 
 	default:
 		// case "varchar", "character", "varying character", "nchar", "native character", "nvarchar", "text", "clob", "datetime", "date", "time":
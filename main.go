@@ -25,9 +25,32 @@ func main() {
 		return
 	}
 
+	// generate from the project configuration file
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		err := cli.GenerateConfig(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// dump the schema snapshot
+	if len(os.Args) > 1 && os.Args[1] == "dump-schema" {
+		err := cli.DumpSchema(os.Args[2:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// parse args
 	var arguments cli.Arguments
-	arg.MustParse(&arguments)
+	p := arg.MustParse(&arguments)
+	if len(arguments.DSNS) == 0 {
+		p.Fail("dsns is required")
+	}
 
 	// generate the code
 	err := cli.Generate(arguments)
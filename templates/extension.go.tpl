@@ -4,13 +4,33 @@
 	// {{ .Name }}Filter related to {{ .Name }}QueryArguments
 	// struct field name contain table column name in Camel style and logic operator(lt, gt etc)
 	// only indexed column and special column defined in ExtraFilters declared in file extra_rules.yaml
+	// the conditions and the And, Or and Not groups are connected by Conjunction
 	type {{ .Name }}Filter struct {
-		Conjunction	*string  // enum in "AND", "OR", nil(consider as single condition)
+		Conjunction	*string  // enum in "AND", "OR", nil(consider as AND)
+		And *[]*{{ .Name }}Filter `json:"and"` // all the filters match
+		Or *[]*{{ .Name }}Filter `json:"or"` // any of the filters matches
+		Not *{{ .Name }}Filter `json:"not"` // the filter does not match
+	{{- range .ForeignKeys -}}
+		{{- if (existsqlfilter .RefType) }}
+		{{ fkfiltername . }} *{{ .RefType.Name }}Filter `json:"{{ togqlname (fkfiltername .) }}"` // the {{ .RefType.Name }} of {{ .Field.Name }} matches
+		{{- end -}}
+	{{- end -}}
+	{{- range .RefFKs -}}
+		{{- if (existsqlfilter .Type) }}
+		{{ .FkReverseField }}Some *{{ .Type.Name }}Filter `json:"{{ togqlname .FkReverseField }}Some"` // one of the {{ .FkReverseField }} matches
+		{{ .FkReverseField }}None *{{ .Type.Name }}Filter `json:"{{ togqlname .FkReverseField }}None"` // none of the {{ .FkReverseField }} matches
+		{{- end -}}
+	{{- end }}
 	{{- range .Fields -}}
 		{{- $ftyp := (sqlfilter $table . $idxFields) -}}
 		{{- if (and (ne .Name $.PrimaryKey.Name) (ne $ftyp "unsupported")) -}}
-			{{- if (or (eq $ftyp "Number") (eq $ftyp "String")) }}
+			{{- if (or (eq $ftyp "Number") (eq $ftyp "String") (eq $ftyp "Bool") (eq $ftyp "Enum")) }}
 				{{ .Name }} {{ sqltogopointertype .Type .Col.IsPrimaryKey }} `json:"{{ togqlname .Name }}"` // equal to {{ .Name }}
+				{{ .Name }}Ne {{ sqltogopointertype .Type .Col.IsPrimaryKey }} `json:"{{ togqlname .Name }}_ne"` // not equal to {{ .Name }}
+			{{- end -}}
+			{{- if (or (eq $ftyp "Number") (eq $ftyp "String") (eq $ftyp "Enum")) }}
+				{{ .Name }}In {{ sqltogoslicetype .Type .Col.IsPrimaryKey }} `json:"{{ togqlname .Name }}_in"` // IN
+				{{ .Name }}Nin {{ sqltogoslicetype .Type .Col.IsPrimaryKey }} `json:"{{ togqlname .Name }}_nin"` // NOT IN
 			{{- end -}}
 			{{- if (eq $ftyp "String") }}
 				{{ .Name }}Like {{ sqltogopointertype .Type .Col.IsPrimaryKey }} `json:"{{ togqlname .Name }}_like"` // LIKE
@@ -23,6 +43,10 @@
 				{{ .Name }}Lte {{ sqltogopointertype .Type .Col.IsPrimaryKey }} `json:"{{ togqlname .Name }}_lte"` // less than and equal to {{ .Name }}
 				{{ .Name }}Gt {{ sqltogopointertype .Type .Col.IsPrimaryKey }} `json:"{{ togqlname .Name }}_gt"` // greater than {{ .Name }}
 				{{ .Name }}Gte {{ sqltogopointertype .Type .Col.IsPrimaryKey }} `json:"{{ togqlname .Name }}_gte"` // greater than and equal to {{ .Name }}
+				{{ .Name }}Between {{ sqltogoslicetype .Type .Col.IsPrimaryKey }} `json:"{{ togqlname .Name }}_between"` // BETWEEN the two values
+			{{- end -}}
+			{{- if not .Col.NotNull }}
+				{{ .Name }}IsNull *bool `json:"{{ togqlname .Name }}_is_null"` // IS NULL if true, IS NOT NULL if false
 			{{- end -}}
 		{{- end -}}
 	{{- end }}
@@ -32,6 +56,7 @@
 	type {{ .Name }}QueryArguments struct{
 		Cursor
 		Where *{{ .Name }}Filter
+		Order *[]*{{ .Name }}Order
 
 		// non-export field
 		filterArgs *filterArguments
@@ -45,20 +70,149 @@
 		conjunction := ""
 		conjCnt := 0
 		var filterPairs []*filterPair
+		var relations []*filterRelation
+		var groups []*filterArguments
 		if filter.Conjunction != nil{
 			conjunction = *filter.Conjunction
 			if _, ok := sqlConjunctionMap[conjunction]; !ok{
 				return nil, fmt.Errorf("unsupported conjunction:%v", filter.Conjunction)
 			}
 		}
+		// group returns the filters connected by the conjunction as one group
+		group := func(filters []*{{ .Name }}Filter, conjunction string) (*filterArguments, error) {
+			args := &filterArguments{conjunction: conjunction}
+			for _, f := range filters {
+				filterArgs, err := get{{ .Name }}Filter(f)
+				if err != nil {
+					return nil, err
+				}
+				if filterArgs != nil {
+					args.groups = append(args.groups, filterArgs)
+				}
+			}
+			if len(args.groups) == 0 {
+				return nil, nil
+			}
+			args.conjCnt = len(args.groups)
+			return args, nil
+		}
+		for _, g := range []struct {
+			filters *[]*{{ .Name }}Filter
+			conjunction string
+		}{ {filter.And, "AND"}, {filter.Or, "OR"} } {
+			if g.filters == nil {
+				continue
+			}
+			filterArgs, err := group(*g.filters, g.conjunction)
+			if err != nil {
+				return nil, err
+			}
+			if filterArgs != nil {
+				conjCnt++
+				groups = append(groups, filterArgs)
+			}
+		}
+		if filter.Not != nil {
+			filterArgs, err := get{{ .Name }}Filter(filter.Not)
+			if err != nil {
+				return nil, err
+			}
+			if filterArgs != nil {
+				conjCnt++
+				filterArgs.not = !filterArgs.not
+				groups = append(groups, filterArgs)
+			}
+		}
+	{{- range .ForeignKeys -}}
+		{{- if (existsqlfilter .RefType) }}
+		if filter.{{ fkfiltername . }} != nil {
+			filterArgs, err := get{{ .RefType.Name }}Filter(filter.{{ fkfiltername . }})
+			if err != nil {
+				return nil, err
+			}
+			if filterArgs != nil {
+				conjCnt++
+				relations = append(relations, &filterRelation{table: `{{ schema .RefType.Schema .RefType.Table.TableName }}`, column: "{{ .RefField.Col.ColumnName }}", ref: "{{ .Field.Col.ColumnName }}", deleted: "{{ deletedcolumn .RefType }}", filter: filterArgs})
+			}
+		}
+		{{- end -}}
+	{{- end -}}
+	{{- range .RefFKs -}}
+		{{- if (existsqlfilter .Type) }}
+		for _, r := range []struct {
+			filter *{{ .Type.Name }}Filter
+			not bool
+		}{ {filter.{{ .FkReverseField }}Some, false}, {filter.{{ .FkReverseField }}None, true} } {
+			if r.filter == nil {
+				continue
+			}
+			filterArgs, err := get{{ .Type.Name }}Filter(r.filter)
+			if err != nil {
+				return nil, err
+			}
+			if filterArgs != nil {
+				conjCnt++
+				relations = append(relations, &filterRelation{table: `{{ schema .Type.Schema .Type.Table.TableName }}`, column: "{{ .Field.Col.ColumnName }}", ref: "{{ .RefField.Col.ColumnName }}", deleted: "{{ deletedcolumn .Type }}", filter: filterArgs, not: r.not})
+			}
+		}
+		{{- end -}}
+	{{- end }}
 	{{- range .Fields -}}
 		{{- $ftyp := (sqlfilter $table . $idxFields) -}}
 		{{- if (and (ne .Name $.PrimaryKey.Name) (ne $ftyp "unsupported")) -}}
-			{{- if (or (eq $ftyp "Number") (eq $ftyp "String")) }}
+			{{- if (or (eq $ftyp "Number") (eq $ftyp "String") (eq $ftyp "Bool")) }}
 				if filter.{{ .Name }} != nil{
 					conjCnt++
 					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: "=", value: *filter.{{ .Name }}})
 				}
+				if filter.{{ .Name }}Ne != nil{
+					conjCnt++
+					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: "<>", value: *filter.{{ .Name }}Ne})
+				}
+			{{- end -}}
+			{{- if (or (eq $ftyp "Number") (eq $ftyp "String")) }}
+				if filter.{{ .Name }}In != nil{
+					conjCnt++
+					values := make([]interface{}, len(*filter.{{ .Name }}In))
+					for i, v := range *filter.{{ .Name }}In {
+						values[i] = v
+					}
+					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: "IN", value: values})
+				}
+				if filter.{{ .Name }}Nin != nil{
+					conjCnt++
+					values := make([]interface{}, len(*filter.{{ .Name }}Nin))
+					for i, v := range *filter.{{ .Name }}Nin {
+						values[i] = v
+					}
+					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: "NOT IN", value: values})
+				}
+			{{- end -}}
+			{{- if (eq $ftyp "Enum") }}
+				if filter.{{ .Name }} != nil{
+					conjCnt++
+					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: "=", value: {{ .Type }}FromGraphQL(*filter.{{ .Name }})})
+				}
+				if filter.{{ .Name }}Ne != nil{
+					conjCnt++
+					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: "<>", value: {{ .Type }}FromGraphQL(*filter.{{ .Name }}Ne)})
+				}
+				if filter.{{ .Name }}In != nil{
+					conjCnt++
+					values := make([]interface{}, len(*filter.{{ .Name }}In))
+					for i, v := range *filter.{{ .Name }}In {
+						values[i] = {{ .Type }}FromGraphQL(v)
+					}
+					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: "IN", value: values})
+				}
+				if filter.{{ .Name }}Nin != nil{
+					conjCnt++
+					values := make([]interface{}, len(*filter.{{ .Name }}Nin))
+					for i, v := range *filter.{{ .Name }}Nin {
+						values[i] = {{ .Type }}FromGraphQL(v)
+					}
+					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: "NOT IN", value: values})
+				}
 			{{- end -}}
 			{{- if (eq $ftyp "String") }}
 				if filter.{{ .Name }}Like != nil{
@@ -93,6 +247,14 @@
 					conjCnt++
 					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: ">=", value: *filter.{{ .Name }}Gte})
 				}
+				if filter.{{ .Name }}Between != nil{
+					if len(*filter.{{ .Name }}Between) != 2 {
+						return nil, fmt.Errorf("invalid filter {{ togqlname .Name }}_between: need 2 values but have: %v", len(*filter.{{ .Name }}Between))
+					}
+					conjCnt++
+					values := []interface{}{ (*filter.{{ .Name }}Between)[0], (*filter.{{ .Name }}Between)[1] }
+					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: "BETWEEN", value: values})
+				}
 			{{- end -}}
 			{{- if (eq $ftyp "Time") }}
 				if filter.{{ .Name}}Lt != nil{
@@ -109,19 +271,31 @@
 					conjCnt++
 					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: ">=", value: filter.{{ .Name }}Gte.Time})
 				}
+				if filter.{{ .Name }}Between != nil{
+					if len(*filter.{{ .Name }}Between) != 2 {
+						return nil, fmt.Errorf("invalid filter {{ togqlname .Name }}_between: need 2 values but have: %v", len(*filter.{{ .Name }}Between))
+					}
+					conjCnt++
+					values := []interface{}{ (*filter.{{ .Name }}Between)[0].Time, (*filter.{{ .Name }}Between)[1].Time }
+					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: "BETWEEN", value: values})
+				}
+			{{- end -}}
+			{{- if not .Col.NotNull }}
+				if filter.{{ .Name }}IsNull != nil{
+					conjCnt++
+					option := "IS NOT NULL"
+					if *filter.{{ .Name }}IsNull {
+						option = "IS NULL"
+					}
+					filterPairs = append(filterPairs, &filterPair{fieldName: "{{ .Col.ColumnName }}", option: option})
+				}
 			{{- end -}}
 		{{- end -}}
 	{{- end }}
 		if conjCnt == 0{
 			return nil, nil
 		}
-		if len(conjunction)>0 && conjCnt < 2{
-			return nil, fmt.Errorf("invalid filter conjunction: %v need more than 2 parameter but have: %v", *filter.Conjunction, conjCnt)
-		}
-		if len(conjunction) == 0 && conjCnt != 1{
-			return nil, fmt.Errorf("multi field:%v should be connected by conjunction AND or OR", conjCnt)
-		}
-		filterArgs := &filterArguments{filterPairs: filterPairs, conjunction: conjunction, conjCnt: conjCnt}
+		filterArgs := &filterArguments{table: `{{ $table }}`, filterPairs: filterPairs, relations: relations, groups: groups, conjunction: conjunction, conjCnt: conjCnt}
 		return filterArgs, nil
 	}
 
@@ -129,9 +303,128 @@
 	// {{ .Name }}QueryArguments composed by Cursor, {{ .Name }}Filter and sql filter string
 	type {{ .Name }}QueryArguments struct {
 		Cursor
+		Order *[]*{{ .Name }}Order
 	}
 {{ end }}
 
+// {{ .Name }}OrderField is a column of {{ $table }} to order by, related to graphql enum: {{ .Name }}OrderField
+type {{ .Name }}OrderField string
+
+// {{ .Name }}OrderField values.
+const (
+{{- range .Fields }}
+	{{ $.Name }}OrderField{{ .Name }} {{ $.Name }}OrderField = "{{ toupper .Col.ColumnName }}"
+{{- end }}
+)
+
+// {{ .Name }}Order is a term of the order of {{ $table }}, ascending and with
+// the nulls greatest by default
+type {{ .Name }}Order struct {
+	Field     {{ .Name }}OrderField
+	Direction *OrderDirection
+	Nulls     *OrderNulls
+}
+
+// get{{ .Name }}Order returns the order of queryArgs, by its Order terms, or
+// else by the OrderBy column, and then by the primary key
+func get{{ .Name }}Order(queryArgs *{{ .Name }}QueryArguments) ([]orderTerm, error) {
+	columns := map[{{ .Name }}OrderField]struct{
+		column string
+		nullable bool
+	}{
+	{{- range .Fields }}
+		{{ $.Name }}OrderField{{ .Name }}: { `{{ colname .Col }}`, {{ not .Col.NotNull }} },
+	{{- end }}
+	}
+
+	var order []orderTerm
+	desc := queryArgs.Desc != nil && *queryArgs.Desc
+	if queryArgs.Order != nil && len(*queryArgs.Order) > 0 {
+		for _, o := range *queryArgs.Order {
+			c, ok := columns[o.Field]
+			if !ok {
+				return nil, fmt.Errorf("unable to order by %s, field not found", o.Field)
+			}
+			t, err := newOrderTerm(c.column, c.nullable, o.Direction, o.Nulls)
+			if err != nil {
+				return nil, err
+			}
+			order = append(order, t)
+		}
+	} else if queryArgs.OrderBy != nil && *queryArgs.OrderBy != "" {
+		c, ok := columns[{{ .Name }}OrderField(strings.ToUpper(*queryArgs.OrderBy))]
+		if !ok {
+			return nil, fmt.Errorf("unable to order by %s, field not found", *queryArgs.OrderBy)
+		}
+		order = append(order, orderTerm{column: c.column, desc: desc, nullable: c.nullable, nullsFirst: desc})
+	}
+	return orderByKey(order, `{{ colname .PrimaryKey.Col }}`, desc), nil
+}
+
+{{- $numbers := (aggregatefields . "Number") }}
+{{- $values := (aggregatefields . "Number" "Time") }}
+
+// {{ .Name }}Aggregate is the aggregate of the rows of {{ $table }} in a group,
+// Group holds the values of the grouped by columns
+type {{ .Name }}Aggregate struct {
+	Group *{{ .Name }}
+	Count int
+{{- if $numbers }}
+	Sum {{ .Name }}AggregateNumbers
+	Avg {{ .Name }}AggregateNumbers
+{{- end }}
+{{- if $values }}
+	Min {{ .Name }}AggregateValues
+	Max {{ .Name }}AggregateValues
+{{- end }}
+}
+{{- if $numbers }}
+
+// {{ .Name }}AggregateNumbers holds an aggregate of each number column of {{ $table }},
+// nil if there are no values
+type {{ .Name }}AggregateNumbers struct {
+{{- range $numbers }}
+	{{ .Name }} *float64
+{{- end }}
+}
+{{- end }}
+{{- if $values }}
+
+// {{ .Name }}AggregateValues holds an aggregate of each number and time column of {{ $table }},
+// nil if there are no values
+type {{ .Name }}AggregateValues struct {
+{{- range $values }}
+	{{ .Name }} {{ sqlaggregatetype .Type }}
+{{- end }}
+}
+{{- end }}
+
+// get{{ .Name }}GroupBy returns the columns of groupBy, and dests which returns
+// the fields of the group to scan the columns into
+func get{{ .Name }}GroupBy(groupBy []{{ .Name }}OrderField) ([]string, func(group *{{ .Name }}) []interface{}, error) {
+	var columns []string
+	var fields []func(group *{{ .Name }}) interface{}
+	for _, f := range groupBy {
+		switch f {
+	{{- range .Fields }}
+		case {{ $.Name }}OrderField{{ .Name }}:
+			columns = append(columns, `{{ colname .Col }}`)
+			fields = append(fields, func(group *{{ $.Name }}) interface{} { return &group.{{ .Name }} })
+	{{- end }}
+		default:
+			return nil, nil, fmt.Errorf("unable to group by %s, field not found", f)
+		}
+	}
+	dests := func(group *{{ .Name }}) []interface{} {
+		res := make([]interface{}, len(fields))
+		for i, field := range fields {
+			res[i] = field(group)
+		}
+		return res
+	}
+	return columns, dests, nil
+}
+
 // Apply{{ .Name }}QueryArgsDefaults assigns default cursor values to non-nil fields.
 func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{ .Name }}QueryArguments {
 	if queryArgs == nil {
@@ -174,9 +467,14 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
 {{- if (enableextension) }}
     const graphQL{{ .Name }}Queries = `
     {{- if (existsqlfilter .) }}
-        all{{ plural .Name }}(where: {{ .Name }}Filter, offset: Int, limit: Int, orderBy: String, desc: Boolean): {{ .Name }}Connection!
+        all{{ plural .Name }}(where: {{ .Name }}Filter, offset: Int, limit: Int, orderBy: String, desc: Boolean, order: [{{ .Name }}Order!], first: Int, after: ID, last: Int, before: ID): {{ .Name }}Connection!
+    {{- else }}
+        all{{ plural .Name }}(offset: Int, limit: Int, orderBy: String, desc: Boolean, order: [{{ .Name }}Order!], first: Int, after: ID, last: Int, before: ID): {{ .Name }}Connection!
+    {{- end -}}
+    {{- if (existsqlfilter .) }}
+        {{ togqlname .Name }}Aggregate(where: {{ .Name }}Filter, groupBy: [{{ .Name }}OrderField!]): [{{ .Name }}Aggregate!]!
     {{- else }}
-        all{{ plural .Name }}(offset: Int, limit: Int, orderBy: String, desc: Boolean): {{ .Name }}Connection!
+        {{ togqlname .Name }}Aggregate(groupBy: [{{ .Name }}OrderField!]): [{{ .Name }}Aggregate!]!
     {{- end -}}
     {{- range $x, $index := .Indexes }}
         {{ togqlname .FuncName }}(
@@ -211,9 +509,9 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
 
     {{- range .RefFKs -}}
     {{- if (existsqlfilter .Type) }}
-            {{ togqlname .FkReverseField }}(where: {{ .Type.Name }}Filter, offset: Int, limit: Int, orderBy: String, desc: Boolean): {{ .Type.Name }}Connection!
+            {{ togqlname .FkReverseField }}(where: {{ .Type.Name }}Filter, offset: Int, limit: Int, orderBy: String, desc: Boolean, order: [{{ .Type.Name }}Order!], first: Int, after: ID, last: Int, before: ID): {{ .Type.Name }}Connection!
     {{- else }}
-            {{ togqlname .FkReverseField }}(offset: Int, limit: Int, orderBy: String, desc: Boolean): {{ .Type.Name }}Connection!
+            {{ togqlname .FkReverseField }}(offset: Int, limit: Int, orderBy: String, desc: Boolean, order: [{{ .Type.Name }}Order!], first: Int, after: ID, last: Int, before: ID): {{ .Type.Name }}Connection!
     {{- end -}}
     {{- end -}}
     {{- ""}}
@@ -230,14 +528,74 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
             node: {{ .Name }}
             cursor: ID!
         }
+
+        enum {{ .Name }}OrderField {
+    {{- range .Fields }}
+            {{ toupper .Col.ColumnName }}
+    {{- end }}
+        }
+
+        input {{ .Name }}Order {
+            field: {{ .Name }}OrderField!
+            direction: OrderDirection
+            nulls: OrderNulls
+        }
+
+        type {{ .Name }}Aggregate {
+            group: {{ .Name }}! // the values of the grouped by columns
+            count: Int!
+    {{- if (aggregatefields . "Number") }}
+            sum: {{ .Name }}AggregateNumbers!
+            avg: {{ .Name }}AggregateNumbers!
+    {{- end }}
+    {{- if (aggregatefields . "Number" "Time") }}
+            min: {{ .Name }}AggregateValues!
+            max: {{ .Name }}AggregateValues!
+    {{- end }}
+        }
+    {{- if (aggregatefields . "Number") }}
+
+        type {{ .Name }}AggregateNumbers {
+        {{- range (aggregatefields . "Number") }}
+            {{ togqlname .Name }}: Float
+        {{- end }}
+        }
+    {{- end }}
+    {{- if (aggregatefields . "Number" "Time") }}
+
+        type {{ .Name }}AggregateValues {
+        {{- range (aggregatefields . "Number" "Time") }}
+            {{ togqlname .Name }}: {{ aggregatetogqltype (sqlaggregatetype .Type) }}
+        {{- end }}
+        }
+    {{- end }}
     {{ if (existsqlfilter .) }}
         input {{ .Name }}Filter {
             conjunction: FilterConjunction
+            and: [{{ .Name }}Filter!] // all the filters match
+            or: [{{ .Name }}Filter!] // any of the filters matches
+            not: {{ .Name }}Filter // the filter does not match
+    {{- range .ForeignKeys -}}
+        {{- if (existsqlfilter .RefType) }}
+            {{ togqlname (fkfiltername .) }}: {{ .RefType.Name }}Filter // the {{ .RefType.Name }} matches
+        {{- end -}}
+    {{- end -}}
+    {{- range .RefFKs -}}
+        {{- if (existsqlfilter .Type) }}
+            {{ togqlname .FkReverseField }}Some: {{ .Type.Name }}Filter // one of the {{ togqlname .FkReverseField }} matches
+            {{ togqlname .FkReverseField }}None: {{ .Type.Name }}Filter // none of the {{ togqlname .FkReverseField }} matches
+        {{- end -}}
+    {{- end }}
     {{- range .Fields -}}
         {{- $ftyp := (sqlfilter $table . $idxFields) -}}
         {{- if (and (ne .Name $.PrimaryKey.Name) (ne $ftyp "unsupported")) -}}
-            {{- if (or (eq $ftyp "Number") (eq $ftyp "String")) }}
+            {{- if (or (eq $ftyp "Number") (eq $ftyp "String") (eq $ftyp "Bool") (eq $ftyp "Enum")) }}
             {{ togqlname .Name  }}: {{ sqltogqloptionaltype .Type .Col.IsPrimaryKey }}
+            {{ togqlname .Name  }}_ne: {{ sqltogqloptionaltype .Type .Col.IsPrimaryKey }} // <>
+            {{- end -}}
+            {{- if (or (eq $ftyp "Number") (eq $ftyp "String") (eq $ftyp "Enum")) }}
+            {{ togqlname .Name  }}_in: [{{ sqltogqloptionaltype .Type .Col.IsPrimaryKey }}!] // IN
+            {{ togqlname .Name  }}_nin: [{{ sqltogqloptionaltype .Type .Col.IsPrimaryKey }}!] // NOT IN
             {{- end -}}
             {{- if (eq $ftyp "String") }}
             {{ togqlname .Name }}_like: {{ sqltogqloptionaltype .Type .Col.IsPrimaryKey }} // LIKE
@@ -250,6 +608,10 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
             {{ togqlname .Name  }}_lte: {{ sqltogqloptionaltype .Type .Col.IsPrimaryKey }}
             {{ togqlname .Name  }}_gt: {{ sqltogqloptionaltype .Type .Col.IsPrimaryKey }}
             {{ togqlname .Name  }}_gte: {{ sqltogqloptionaltype .Type .Col.IsPrimaryKey }}
+            {{ togqlname .Name  }}_between: [{{ sqltogqloptionaltype .Type .Col.IsPrimaryKey }}!] // BETWEEN the two values
+            {{- end -}}
+            {{- if not .Col.NotNull }}
+            {{ togqlname .Name  }}_is_null: Boolean // IS NULL if true, IS NOT NULL if false
             {{- end -}}
         {{- end -}}
     {{- end }}
@@ -345,7 +707,33 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                 {{- else }}
                     panic("TODO: implement in extension.go.tpl {{ printf "input: %s, output %s" $it $ot }}")
                 {{- end }}
-                node, err := r.ext.storage.{{ .RefType.Name }}By{{ .RefField.Name }}(r.ext.db, {{$varname}})
+            {{- if (enabledataloader) }}
+                if r.ext.loader != nil {
+                    v, err := r.ext.loader.load("{{ .RefType.Name }}By{{ .RefField.Name }}", {{ $varname }}, func(keys []interface{}) (map[interface{}]interface{}, error) {
+                        ks := make([]{{ $ot }}, len(keys))
+                        for i, k := range keys {
+                            ks[i] = k.({{ $ot }})
+                        }
+                        nodes, err := r.ext.storage.{{ .RefType.Name }}By{{ .RefField.Name }}Batch({{ dbarg "r.ext.db" }}, ks)
+                        if err != nil {
+                            return nil, err
+                        }
+                        res := make(map[interface{}]interface{}, len(nodes))
+                        for _, node := range nodes {
+                            res[node.{{ .RefField.Name }}] = node
+                        }
+                        return res, nil
+                    })
+                    if err != nil {
+                        return nil, errors.Wrap(err, "unable to retrieve {{ fkname $field.Name }}")
+                    }
+                    if v == nil {
+                        return nil, errors.Wrap(ErrNotFound, "unable to retrieve {{ fkname $field.Name }}")
+                    }
+                    return New{{ .RefType.Name }}Resolver(v.(*{{ .RefType.Name }}), r.ext), nil
+                }
+            {{- end }}
+                node, err := r.ext.storage.{{ .RefType.Name }}By{{ .RefField.Name }}({{ dbarg "r.ext.db" }}, {{$varname}})
                 if err != nil {
                     return nil, errors.Wrap(err, "unable to retrieve {{ fkname $field.Name }}")
                 }
@@ -380,10 +768,6 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
             }
         {{- end }}
 
-        if queryArgs != nil && (queryArgs.After != nil || queryArgs.First != nil || queryArgs.Before != nil || queryArgs.Last != nil) {
-            return nil, errors.New("not implemented yet, use offset + limit for pagination")
-        }
-
         queryArgs = Apply{{ .Type.Name }}QueryArgsDefaults(queryArgs)
     {{ if (existsqlfilter .Type) }}
         filterArgs, err := get{{ .Type.Name }}Filter(queryArgs.Where)
@@ -395,22 +779,80 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
         {{ $varname := (togqlname .RefField.Name) -}}
         {{ $varname }} := r.node.{{.RefField.Name}}
 
-        data, err := r.ext.storage.{{ plural .Type.Name }}By{{.Field.Name}}FK(r.ext.db, {{ $varname }}, queryArgs)
+    {{- if (enabledataloader) }}
+        if r.ext.loader != nil && !queryArgs.isKeyset() {
+            return r.{{ togqlname .FkReverseField }}Batch(ctx, {{ $varname }}, queryArgs)
+        }
+    {{- end }}
+
+        data, err := r.ext.storage.{{ plural .Type.Name }}By{{.Field.Name}}FK({{ dbarg "r.ext.db" }}, {{ $varname }}, queryArgs)
         if err != nil {
             return nil, errors.Wrap(err, "unable to get {{plural .Type.Name}}")
         }
 
-        count, err := r.ext.storage.Count{{ plural .Type.Name }}By{{.Field.Name}}FK(r.ext.db, {{ $varname }}, queryArgs)
+        count, err := r.ext.storage.Count{{ plural .Type.Name }}By{{.Field.Name}}FK({{ dbarg "r.ext.db" }}, {{ $varname }}, queryArgs)
         if err != nil {
             return nil, errors.Wrap(err, "unable to get {{plural .Type.Name}} count")
         }
 
-        return &{{.Type.Name}}ConnectionResolver{
-            ext: r.ext,
-            data: data,
-            count: int32(count),
-        }, nil
+        res := New{{ .Type.Name }}ConnectionResolver(data, count, r.ext)
+        res.hasNextPage, res.hasPreviousPage = queryArgs.pageInfo(len(data), count)
+        return res, nil
     }
+    {{- if (enabledataloader) }}
+
+    // {{ togqlname .FkReverseField }}Batch loads the {{ plural .Type.Name }} of the {{ $.Name }} with the loader,
+    // along with those of the other {{ plural $.Name }} requested with the same queryArgs.
+    func (r {{ $.Name }}Resolver) {{ togqlname .FkReverseField }}Batch(ctx context.Context, key {{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (*{{ .Type.Name }}ConnectionResolver, error) {
+        buf, err := json.Marshal(queryArgs)
+        if err != nil {
+            return nil, errors.Wrap(err, "unable to get {{plural .Type.Name}}")
+        }
+        keys := func(keys []interface{}) []{{ .RefField.Type }} {
+            ks := make([]{{ .RefField.Type }}, len(keys))
+            for i, k := range keys {
+                ks[i] = k.({{ .RefField.Type }})
+            }
+            return ks
+        }
+
+        v, err := r.ext.loader.load("{{ plural .Type.Name }}By{{ .Field.Name }}FK:"+string(buf), key, func(ks []interface{}) (map[interface{}]interface{}, error) {
+            rows, err := r.ext.storage.{{ plural .Type.Name }}By{{ .Field.Name }}FKBatch({{ dbarg "r.ext.db" }}, keys(ks), queryArgs)
+            if err != nil {
+                return nil, err
+            }
+            res := make(map[interface{}]interface{}, len(ks))
+            for _, k := range ks {
+                res[k] = rows[k.({{ .RefField.Type }})]
+            }
+            return res, nil
+        })
+        if err != nil {
+            return nil, errors.Wrap(err, "unable to get {{plural .Type.Name}}")
+        }
+        data := v.([]*{{ .Type.Name }})
+
+        v, err = r.ext.loader.load("Count{{ plural .Type.Name }}By{{ .Field.Name }}FK:"+string(buf), key, func(ks []interface{}) (map[interface{}]interface{}, error) {
+            counts, err := r.ext.storage.Count{{ plural .Type.Name }}By{{ .Field.Name }}FKBatch({{ dbarg "r.ext.db" }}, keys(ks), queryArgs)
+            if err != nil {
+                return nil, err
+            }
+            res := make(map[interface{}]interface{}, len(ks))
+            for _, k := range ks {
+                res[k] = counts[k.({{ .RefField.Type }})]
+            }
+            return res, nil
+        })
+        if err != nil {
+            return nil, errors.Wrap(err, "unable to get {{plural .Type.Name}} count")
+        }
+        count := v.(int)
+
+        res := New{{ .Type.Name }}ConnectionResolver(data, count, r.ext)
+        res.hasNextPage, res.hasPreviousPage = queryArgs.pageInfo(len(data), count)
+        return res, nil
+    }
+    {{- end }}
     {{- end }}
 
     {{- range $x := .Indexes }}
@@ -475,6 +917,8 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
             }
         {{ else if eq .Type "string" -}}
             arg{{ $index }} := args.{{ .Name }}
+        {{ else if (or (isenum .Type) (istypeoverride .Type)) -}}
+            arg{{ $index }} := {{ gotosql .Type (print "args." .Name) }}
         {{ else if eq .Type "sql.NullInt64" -}}
             if args.{{.Name}} == nil {
                 return nil, nil
@@ -489,12 +933,12 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
         {{- end }}
         {{ end }}
 
-            data, err := r.ext.storage.{{ .FuncName }}(r.ext.db, 
+            data, err := r.ext.storage.{{ .FuncName }}({{ dbarg "r.ext.db" }}, 
             {{- range $index, $field := .Fields -}}
                 arg{{ $index }},
             {{- end -}})
             if err != nil {
-                if err == sql.ErrNoRows {
+                if err == ErrNotFound {
                     return nil, errors.Errorf(`{{ $.Table.TableName }} [`
                             {{- range $index, $field := .Fields -}}
                                 {{- if eq $index 0 -}}
@@ -512,11 +956,14 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
             }
 
         {{ if .Index.IsUnique }}
-            return New{{ .Type.Name }}Resolver( data, r.ext), nil
+            return New{{ .Type.Name }}Resolver( data, {{ if (enabledataloader) }}r.ext.withLoader(){{ else }}r.ext{{ end }}), nil
         {{ else }}
             ret := make([]{{ .Type.Name }}Resolver, len(data))
+        {{- if (enabledataloader) }}
+            ext := r.ext.withLoader()
+        {{- end }}
             for i, row := range data {
-		        ret[i] = {{ .Type.Name }}Resolver{ext: r.ext, node: row}
+		        ret[i] = {{ .Type.Name }}Resolver{ext: {{ if (enabledataloader) }}ext{{ else }}r.ext{{ end }}, node: row}
             }
             return &ret, nil
         {{ end }}
@@ -531,6 +978,8 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
 
         data  []*{{ .Name }}
         count int32
+
+        hasNextPage, hasPreviousPage bool
     }
 
     // New{{ .Name }}ConnectionResolver return a GraphQL resolver for {{ .Name }}Connection
@@ -551,8 +1000,8 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
         return &PageInfoResolver{
             startCursor:     encodeCursor("{{ .Name }}", int(r.data[0].{{ .PrimaryKey.Name }})),
             endCursor:       encodeCursor("{{ .Name }}", int(r.data[len(r.data)-1].{{ .PrimaryKey.Name }})),
-            hasNextPage:     false, // TODO
-            hasPreviousPage: false, // TODO
+            hasNextPage:     r.hasNextPage,
+            hasPreviousPage: r.hasPreviousPage,
         }
     }
 
@@ -604,6 +1053,79 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
         return encodeCursor("{{ .Name }}", int(r.node.{{ .PrimaryKey.Name }}))
     }
 
+    // {{ .Name }}AggregateResolver defines a GraphQL resolver for {{ .Name }}Aggregate
+    type {{ .Name }}AggregateResolver struct {
+        ext resolverExtensions
+        node *{{ .Name }}Aggregate
+    }
+
+    // New{{ .Name }}AggregateResolver return a GraphQL resolver for {{ .Name }}Aggregate
+    func New{{ .Name }}AggregateResolver(node *{{ .Name }}Aggregate, ext resolverExtensions) *{{ .Name }}AggregateResolver {
+        return &{{ .Name }}AggregateResolver{
+            ext:  ext,
+            node: node,
+        }
+    }
+
+    // Group returns the {{ .Name }} holding the values of the grouped by columns
+    func (r {{ .Name }}AggregateResolver) Group() *{{ .Name }}Resolver {
+        return New{{ .Name }}Resolver(r.node.Group, r.ext)
+    }
+
+    // Count returns the count of the rows
+    func (r {{ .Name }}AggregateResolver) Count() int32 {
+        return int32(r.node.Count)
+    }
+    {{- if (aggregatefields . "Number") }}
+
+    // Sum returns the sums of the number columns
+    func (r {{ .Name }}AggregateResolver) Sum() *{{ .Name }}AggregateNumbersResolver {
+        return &{{ .Name }}AggregateNumbersResolver{node: &r.node.Sum}
+    }
+
+    // Avg returns the averages of the number columns
+    func (r {{ .Name }}AggregateResolver) Avg() *{{ .Name }}AggregateNumbersResolver {
+        return &{{ .Name }}AggregateNumbersResolver{node: &r.node.Avg}
+    }
+
+    // {{ .Name }}AggregateNumbersResolver defines a GraphQL resolver for {{ .Name }}AggregateNumbers
+    type {{ .Name }}AggregateNumbersResolver struct {
+        node *{{ .Name }}AggregateNumbers
+    }
+    {{- range (aggregatefields . "Number") }}
+
+    func (r {{ $.Name }}AggregateNumbersResolver) {{ .Name }}() *float64 { return r.node.{{ .Name }} }
+    {{- end }}
+    {{- end }}
+    {{- if (aggregatefields . "Number" "Time") }}
+
+    // Min returns the minimums of the number and time columns
+    func (r {{ .Name }}AggregateResolver) Min() *{{ .Name }}AggregateValuesResolver {
+        return &{{ .Name }}AggregateValuesResolver{node: &r.node.Min}
+    }
+
+    // Max returns the maximums of the number and time columns
+    func (r {{ .Name }}AggregateResolver) Max() *{{ .Name }}AggregateValuesResolver {
+        return &{{ .Name }}AggregateValuesResolver{node: &r.node.Max}
+    }
+
+    // {{ .Name }}AggregateValuesResolver defines a GraphQL resolver for {{ .Name }}AggregateValues
+    type {{ .Name }}AggregateValuesResolver struct {
+        node *{{ .Name }}AggregateValues
+    }
+    {{- range (aggregatefields . "Number" "Time") }}
+        {{- $atyp := (sqlaggregatetype .Type) }}
+
+    func (r {{ $.Name }}AggregateValuesResolver) {{ .Name }}() {{ aggregatetogotype $atyp }} {
+        if r.node.{{ .Name }} == nil {
+            return nil
+        }
+        v := {{ aggregatetogql $atyp (print "r.node." .Name) }}
+        return &v
+    }
+    {{- end }}
+    {{- end }}
+
     // Insert{{ .Name }}Input defines the insert {{ .Name }} mutation input
     type Insert{{ .Name }}Input struct {
     {{- range .Fields -}}
@@ -653,10 +1175,6 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
     }
 
     func (r *RootResolver) all{{ plural .Name }}(ctx context.Context, queryArgs *{{ .Name }}QueryArguments) (*{{ .Name }}ConnectionResolver, error) {
-        if queryArgs != nil && (queryArgs.After != nil || queryArgs.First != nil || queryArgs.Before != nil || queryArgs.Last != nil) {
-            return nil, errors.New("not implemented yet, use offset + limit for pagination")
-        }
-
         queryArgs = Apply{{ .Name }}QueryArgsDefaults(queryArgs)
     {{ if (existsqlfilter .) }}
         filterArgs, err := get{{ .Name }}Filter(queryArgs.Where)
@@ -665,21 +1183,62 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
         }
         queryArgs.filterArgs = filterArgs
     {{ end }}
-        all{{ .Name }}, err := r.ext.storage.GetAll{{ .Name }}(r.ext.db, queryArgs)
+        all{{ .Name }}, err := r.ext.storage.GetAll{{ .Name }}({{ dbarg "r.ext.db" }}, queryArgs)
         if err != nil {
             return nil, errors.Wrap(err, "unable to get {{ .Name }}")
         }
 
-        count, err := r.ext.storage.CountAll{{ .Name }}(r.ext.db, queryArgs)
+        count, err := r.ext.storage.CountAll{{ .Name }}({{ dbarg "r.ext.db" }}, queryArgs)
         if err != nil {
             return nil, errors.Wrap(err, "unable to get count")
         }
 
-        return &{{ .Name }}ConnectionResolver{
-            ext:   r.ext,
-            data:  all{{ .Name }},
-            count: int32(count),
-        }, nil
+        res := New{{ .Name }}ConnectionResolver(all{{ .Name }}, count, {{ if (enabledataloader) }}r.ext.withLoader(){{ else }}r.ext{{ end }})
+        res.hasNextPage, res.hasPreviousPage = queryArgs.pageInfo(len(all{{ .Name }}), count)
+        return res, nil
+    }
+
+    // {{ .Name }}Aggregate is a graphQL endpoint of {{ .Name }}Aggregate
+    func (r *RootResolver) {{ .Name }}Aggregate(ctx context.Context, args struct{
+    {{- if (existsqlfilter .) }}
+        Where *{{ .Name }}Filter
+    {{- end }}
+        GroupBy *[]{{ .Name }}OrderField
+    }) ([]*{{ .Name }}AggregateResolver, error) {
+    {{- if (enableac) }}
+        if r.ext.verifier == nil {
+            return nil, errors.New("enable ac, please set verifier")
+        }
+        if err := r.ext.verifier.VerifyAC(ctx, "{{ plural .Name }}", "Aggregate", args); err != nil {
+            return nil, errors.Wrap(err, "{{ plural .Name }}:Aggregate")
+        }
+    {{- end }}
+
+        var groupBy []{{ .Name }}OrderField
+        if args.GroupBy != nil {
+            groupBy = *args.GroupBy
+        }
+        aggregates, err := r.ext.storage.Aggregate{{ .Name }}({{ dbarg "r.ext.db" }}, &{{ .Name }}QueryArguments{
+    {{- if (existsqlfilter .) }}
+            Where: args.Where,
+    {{- end }}
+        }, groupBy)
+        if err != nil {
+            return nil, errors.Wrap(err, "unable to aggregate {{ .Name }}")
+        }
+
+        res := make([]*{{ .Name }}AggregateResolver, len(aggregates))
+        for i := range aggregates {
+            res[i] = New{{ .Name }}AggregateResolver(aggregates[i], {{ if (enabledataloader) }}r.ext.withLoader(){{ else }}r.ext{{ end }})
+        }
+
+        // event record
+        if r.ext.recorder != nil{
+            if err := r.ext.recorder.RecordEvent(ctx, "{{ plural .Name }}", "Aggregate", res); err != nil {
+                r.ext.logger.Warnf("unable to record event, resource:{{ plural .Name }}, action:Aggregate, err:%v", err)
+            }
+        }
+        return res, nil
     }
 
     // Insert{{ plural .Name }} is a graphQL endpoint of Insert{{ plural .Name }}
@@ -706,7 +1265,7 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
 
     func (r *RootResolver) insert{{ .Name }}GraphQL(ctx context.Context, items []Insert{{ .Name }}Input) ([]{{ .Name }}Resolver, error) {
         results := make([]{{ .Name }}Resolver, len(items))
-        for i := range items {
+        err := r.ext.batch(ctx, len(items), func(db {{ dbtype }}, i int) error {
             input := items[i]
             {{ range $index, $field := .Fields -}}
                 {{ $it := (sqltogotype .Type .Col.IsPrimaryKey) }}
@@ -715,19 +1274,19 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                 {{- else if (and (eq $it "graphql.ID") (eq .Type "int64")) -}}
                     {{ print "f" $index }}, err := strconv.ParseInt(string({{ gotosql .Type (print "input." .Name) }}), 10, 0)
                     if err != nil {
-                        return nil, errors.New("{{ .Name }} must be an integer")
+                        return errors.New("{{ .Name }} must be an integer")
                     }
                 {{ else if (eq $it "graphql.ID") -}}
                     {{ print "f" $index }}, err := strconv.Atoi(string({{ gotosql .Type (print "input." .Name) }}))
                     if err != nil {
-                        return nil, errors.New("{{ .Name }} must be an integer")
+                        return errors.New("{{ .Name }} must be an integer")
                     }
                 {{- else if (and (eq $it "*string") (eq .Type "sql.NullInt64")) -}}
                     var {{ print "f" $index }} sql.NullInt64
                     if {{ print "input." .Name }} != nil {
                         n, err := strconv.ParseInt(*{{ print "input." .Name }}, 10, 0)
                         if err != nil {
-                            return nil, errors.New("{{ .Name }} must be an integer")
+                            return errors.New("{{ .Name }} must be an integer")
                         }
                         {{ print "f" $index }} = sql.NullInt64{Int64: n, Valid: true}
                     }
@@ -736,7 +1295,7 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                     if {{ print "input." .Name }} != nil {
                         dec, err := decimal.NewFromString(*{{ print "input." .Name }})
                         if err != nil {
-                            return nil, errors.New("{{ .Name }} must be a decimal")
+                            return errors.New("{{ .Name }} must be a decimal")
                         }
                         
                         {{ print "f" $index }} = decimal.NullDecimal{
@@ -747,17 +1306,17 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                 {{- else if (and (eq $it "string") (eq .Type "decimal.Decimal")) -}}
                     {{ print "f" $index }}, err := decimal.NewFromString({{ print "input." .Name }})
                     if err != nil {
-                        return nil, errors.New("{{ .Name }} must be a decimal")
+                        return errors.New("{{ .Name }} must be a decimal")
                     }
                 {{- else if (and (eq $it "string") (eq .Type "int64")) -}}
                     {{ print "f" $index }}, err := strconv.ParseInt({{ gotosql .Type (print "input." .Name) }}, 10, 0)
                     if err != nil {
-                        return nil, errors.New("{{ .Name }} must be an integer")
+                        return errors.New("{{ .Name }} must be an integer")
                     }
                 {{- else if (and (eq $it "string") (eq .Type "int")) -}}
                     {{ print "f" $index }}, err := strconv.Atoi({{ gotosql .Type (print "input." .Name) }})
                     if err != nil {
-                        return nil, errors.New("{{ .Name }} must be an integer")
+                        return errors.New("{{ .Name }} must be an integer")
                     }
                 {{- else -}}
                     {{ print "f" $index }} := {{ gotosql .Type (print "input." .Name) }}
@@ -770,10 +1329,22 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                     {{- end }}
                 {{ end }}
             }
-            if err := r.ext.storage.Insert{{ .Name }}ByFields(r.ext.db, node); err != nil {
-                return nil, errors.Wrap(err, "unable to insert {{ .Name }}")
+            if err := r.ext.storage.Insert{{ .Name }}ByFields({{ dbarg "db" }}, node); err != nil {
+                return errors.Wrap(err, "unable to insert {{ .Name }}")
             }
             results[i] = {{ .Name }}Resolver{ ext: r.ext, node: node }
+            return nil
+        })
+        if batchErr, ok := err.(*BatchError); ok {
+            succeeded := results[:0]
+            for i := range results {
+                if !batchErr.failed(i) {
+                    succeeded = append(succeeded, results[i])
+                }
+            }
+            return succeeded, batchErr
+        } else if err != nil {
+            return nil, err
         }
         return results, nil
     }
@@ -802,7 +1373,7 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
 
     func (r *RootResolver) update{{ .Name }}GraphQL(ctx context.Context, items []Update{{ .Name }}Input) ([]{{ .Name }}Resolver, error) {
         results := make([]{{ .Name }}Resolver, len(items))
-        for i := range items {
+        err := r.ext.batch(ctx, len(items), func(db {{ dbtype }}, i int) error {
             input := items[i]
             {{ if (eq .PrimaryKey.Type "int64") -}}
                 id, err := strconv.ParseInt(string(input.{{ .PrimaryKey.Name }}), 10, 64)
@@ -812,7 +1383,7 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                 panic("unhandled pk type {{ .Name }}.{{ .PrimaryKey.Name }} from {{ .PrimaryKey.Type }}")
             {{ end -}}
             if err != nil {
-                return nil, errors.New("{{ .PrimaryKey.Name }} must be an integer")
+                return errors.New("{{ .PrimaryKey.Name }} must be an integer")
             }
 
             {{ $length := minus (len .Fields) 1 }}
@@ -827,7 +1398,7 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                 {{ if (not $field.Col.IsPrimaryKey) }}
                     {{ if (eq $field.Type "string") }}
                         if isDeletionFields(input.Deletions, "{{ togqlname $field.Name }}") {
-                            return nil, errors.New("couldn't set {{ togqlname $field.Name }} to null")
+                            return errors.New("couldn't set {{ togqlname $field.Name }} to null")
                         }
                         if input.{{ $field.Name }} != nil {
                             fields = append(fields, `{{ (colname $field.Col) }}`)
@@ -839,13 +1410,13 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                         }
                     {{ else if (eq $field.Type "int") -}}
                         if isDeletionFields(input.Deletions, "{{ togqlname $field.Name }}") {
-                            return nil, errors.New("couldn't set {{ togqlname $field.Name }} to null")
+                            return errors.New("couldn't set {{ togqlname $field.Name }} to null")
                         }
                         if input.{{ $field.Name }} != nil {
                             fields = append(fields, `{{ (colname $field.Col) }}`)
                             params = append(params, *input.{{ $field.Name }})
                             if n, err := strconv.Atoi(*input.{{ $field.Name }}); err != nil {
-                                return nil, errors.New("{{ $field.Name }} must be an integer")
+                                return errors.New("{{ $field.Name }} must be an integer")
                             } else { node.{{ $field.Name }} = n }
                         } else {
                             retCols = append(retCols, `{{ (colname $field.Col) }}`)
@@ -853,13 +1424,13 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                         }
                     {{ else if (eq $field.Type "int64") -}}
                         if isDeletionFields(input.Deletions, "{{ togqlname $field.Name }}") {
-                            return nil, errors.New("couldn't set {{ togqlname $field.Name }} to null")
+                            return errors.New("couldn't set {{ togqlname $field.Name }} to null")
                         }
                         if input.{{ $field.Name }} != nil {
                             fields = append(fields, `{{ (colname $field.Col) }}`)
                             params = append(params, *input.{{ $field.Name }})
                             if n, err := strconv.ParseInt(*input.{{ $field.Name }}, 10, 64); err != nil {
-                                return nil, errors.New("{{ $field.Name }} must be an integer")
+                                return errors.New("{{ $field.Name }} must be an integer")
                             } else { node.{{ $field.Name }} = n }
                         } else {
                             retCols = append(retCols, `{{ (colname $field.Col) }}`)
@@ -867,7 +1438,7 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                         }
                     {{ else if (eq $field.Type "bool") -}}
                         if isDeletionFields(input.Deletions, "{{ togqlname $field.Name }}") {
-                            return nil, errors.New("couldn't set {{ togqlname $field.Name }} to null")
+                            return errors.New("couldn't set {{ togqlname $field.Name }} to null")
                         }
                         if input.{{ $field.Name }} != nil {
                             fields = append(fields, `{{ (colname $field.Col) }}`)
@@ -877,13 +1448,25 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                             retCols = append(retCols, `{{ (colname $field.Col) }}`)
                             retVars = append(retVars, &node.{{ $field.Name }})
                         }
+                    {{ else if (or (isenum $field.Type) (istypeoverride $field.Type)) -}}
+                        if isDeletionFields(input.Deletions, "{{ togqlname $field.Name }}") {
+                            return errors.New("couldn't set {{ togqlname $field.Name }} to null")
+                        }
+                        if input.{{ $field.Name }} != nil {
+                            fields = append(fields, `{{ (colname $field.Col) }}`)
+                            params = append(params, {{ gotosql $field.Type (print "*input." $field.Name) }})
+                            node.{{ $field.Name }} = {{ gotosql $field.Type (print "*input." $field.Name) }}
+                        } else {
+                            retCols = append(retCols, `{{ (colname $field.Col) }}`)
+                            retVars = append(retVars, &node.{{ $field.Name }})
+                        }
                     {{ else if (eq $field.Type "decimal.Decimal") -}}
                         if isDeletionFields(input.Deletions, "{{ togqlname $field.Name }}") {
-                            return nil, errors.New("couldn't set {{ togqlname $field.Name }} to null")
+                            return errors.New("couldn't set {{ togqlname $field.Name }} to null")
                         }
                         if input.{{ $field.Name }} != nil {
                             if n, err := decimal.NewFromString(*input.{{ $field.Name }}); err != nil {
-                                return nil, errors.New("{{ $field.Name }} must be a decimal")
+                                return errors.New("{{ $field.Name }} must be a decimal")
                             } else {
                                 fields = append(fields, `{{ (colname $field.Col) }}`)
                                 params = append(params, n)
@@ -900,7 +1483,7 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                             node.{{ $field.Name }} = decimal.NullDecimal{}
                         } else if input.{{ $field.Name }} != nil {
                             if n, err := decimal.NewFromString(*input.{{ $field.Name }}); err != nil {
-                                return nil, errors.New("{{ $field.Name }} must be a decimal")
+                                return errors.New("{{ $field.Name }} must be a decimal")
                             } else {
                                 fields = append(fields, `{{ (colname $field.Col) }}`)
                                 params = append(params, n)
@@ -912,7 +1495,7 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                         }
                     {{ else if (eq $field.Type "time.Time") -}}
                         if isDeletionFields(input.Deletions, "{{ togqlname $field.Name }}") {
-                            return nil, errors.New("couldn't set {{ togqlname $field.Name }} to null")
+                            return errors.New("couldn't set {{ togqlname $field.Name }} to null")
                         }
                         if input.{{ $field.Name }} != nil {
                             fields = append(fields, `{{ (colname $field.Col) }}`)
@@ -922,6 +1505,31 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                             retCols = append(retCols, `{{ (colname $field.Col) }}`)
                             retVars = append(retVars, &node.{{ $field.Name }})
                         }
+                    {{ else if (eq $field.Type "xoutil.SqTime") -}}
+                        if isDeletionFields(input.Deletions, "{{ togqlname $field.Name }}") {
+                            return errors.New("couldn't set {{ togqlname $field.Name }} to null")
+                        }
+                        if input.{{ $field.Name }} != nil {
+                            fields = append(fields, `{{ (colname $field.Col) }}`)
+                            params = append(params, input.{{ $field.Name }}.Time)
+                            node.{{ $field.Name }} = xoutil.SqTime{Time: input.{{ $field.Name }}.Time}
+                        } else {
+                            retCols = append(retCols, `{{ (colname $field.Col) }}`)
+                            retVars = append(retVars, &node.{{ $field.Name }})
+                        }
+                    {{ else if (eq $field.Type "mysql.NullTime") -}}
+                        if isDeletionFields(input.Deletions, "{{ togqlname $field.Name }}") {
+                            fields = append(fields, `{{ (colname $field.Col) }}`)
+                            params = append(params, mysql.NullTime{})
+                            node.{{ $field.Name }} = mysql.NullTime{}
+                        } else if input.{{ $field.Name }} != nil {
+                            fields = append(fields, `{{ (colname $field.Col) }}`)
+                            params = append(params, input.{{ $field.Name }}.Time)
+                            node.{{ $field.Name }} = mysql.NullTime{Time:input.{{ $field.Name }}.Time,Valid: true}
+                        } else {
+                            retCols = append(retCols, `{{ (colname $field.Col) }}`)
+                            retVars = append(retVars, &node.{{ $field.Name }})
+                        }
                     {{ else if (eq $field.Type "NullTime") -}}
                         if isDeletionFields(input.Deletions, "{{ togqlname $field.Name }}") {
                             fields = append(fields, `{{ (colname $field.Col) }}`)
@@ -944,7 +1552,7 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                             fields = append(fields, `{{ (colname $field.Col) }}`)
                             params = append(params, *input.{{ $field.Name }})
                             if n, err := strconv.ParseInt(*input.{{ $field.Name }}, 10, 64); err != nil {
-                                return nil, errors.New("{{ $field.Name }} must be an integer")
+                                return errors.New("{{ $field.Name }} must be an integer")
                             else { node.{{ $field.Name }} = sql.NullInt64{Int64: n, Valid: true} }
                         } else {
                             retCols = append(retCols, `{{ (colname $field.Col) }}`)
@@ -995,17 +1603,29 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                 {{ end -}}
             {{ end -}}
             if len(params) == 0 {
-                return nil, errors.New("all fields are empty, unable to update")
+                return errors.New("all fields are empty, unable to update")
             }
 
-            if err := r.ext.storage.Update{{ .Name }}ByFields(r.ext.db, node, fields, retCols, params, retVars); err != nil {
-                if err == sql.ErrNoRows {
-                    return nil, errors.Errorf(`{{ .Name }} [%d] not found`, node.{{ .PrimaryKey.Name }})
+            if err := r.ext.storage.Update{{ .Name }}ByFields({{ dbarg "db" }}, node, fields, retCols, params, retVars); err != nil {
+                if err == ErrNotFound {
+                    return errors.Errorf(`{{ .Name }} [%d] not found`, node.{{ .PrimaryKey.Name }})
                 }
-                return nil, err
+                return err
             }
 
             results[i] = {{ .Name }}Resolver{ ext: r.ext, node: node }
+            return nil
+        })
+        if batchErr, ok := err.(*BatchError); ok {
+            succeeded := results[:0]
+            for i := range results {
+                if !batchErr.failed(i) {
+                    succeeded = append(succeeded, results[i])
+                }
+            }
+            return succeeded, batchErr
+        } else if err != nil {
+            return nil, err
         }
         return results, nil
     }
@@ -1036,7 +1656,7 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
         results := make([]graphql.ID, len(items))
         inputs := make([]*{{ .Name }}, len(items))
 
-        for i := range items {
+        parse := func(i int) error {
             input := items[i]
             {{ range $index, $field := .Fields -}}
                 {{ $it := (sqltogotype .Type .Col.IsPrimaryKey) }}
@@ -1044,26 +1664,26 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
                 {{ else if (and (eq $it "graphql.ID") (eq .Type "int")) -}}
                     id, err := strconv.Atoi(string({{ gotosql .Type (print "input." .Name) }}))
                     if err != nil {
-                        return nil, errors.New("{{ .Name }} must be an integer")
+                        return errors.New("{{ .Name }} must be an integer")
                     }
                 {{- else if (and (eq $it "graphql.ID") (eq .Type "int64")) -}}
                     id, err := strconv.ParseInt(string({{ gotosql .Type (print "input." .Name) }}), 10, 0)
                     if err != nil {
-                        return nil, errors.New("{{ .Name }} must be an integer")
+                        return errors.New("{{ .Name }} must be an integer")
                     }
                 {{- else if (and (eq $it "*string") (eq .Type "sql.NullInt64")) -}}
                     var id sql.NullInt64
                     if {{ print "input." .Name }} != nil {
                         n, err := strconv.ParseInt(*{{ print "input." .Name }}, 10, 0)
                         if err != nil {
-                            return nil, errors.New("{{ .Name }} must be an integer")
+                            return errors.New("{{ .Name }} must be an integer")
                         }
                         id = sql.NullInt64{Int64: n, Valid: true}
                     }
                 {{- else if (and (eq $it "string") (eq .Type "int64")) -}}
                     id, err := strconv.ParseInt({{ gotosql .Type (print "input." .Name) }}, 10, 0)
                     if err != nil {
-                        return nil, errors.New("{{ .Name }} must be an integer")
+                        return errors.New("{{ .Name }} must be an integer")
                     }
                 {{- else -}}
                     id := {{ gotosql .Type (print "input." .Name) }}
@@ -1072,14 +1692,43 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
 
             results[i] = input.{{ .PrimaryKey.Name }}
             inputs[i] = &{{ .Name }}{ {{ .PrimaryKey.Name }}: id}
+            return nil
         }
 
+        if !r.ext.partialSuccess {
+            for i := range items {
+                if err := parse(i); err != nil {
+                    return nil, err
+                }
+            }
 
-        err := r.ext.storage.Delete{{ .Name }}s(r.ext.db, inputs)
-        if err != nil {
-            return nil, err
+            err := r.ext.inTx(ctx, func(db {{ dbtype }}) error {
+                return r.ext.storage.Delete{{ .Name }}s({{ dbarg "db" }}, inputs)
+            })
+            if err != nil {
+                return nil, err
+            }
+
+            return results, nil
         }
 
+        err := r.ext.batch(ctx, len(items), func(db {{ dbtype }}, i int) error {
+            if err := parse(i); err != nil {
+                return err
+            }
+            return r.ext.storage.Delete{{ .Name }}s({{ dbarg "db" }}, inputs[i:i+1])
+        })
+        if batchErr, ok := err.(*BatchError); ok {
+            succeeded := results[:0]
+            for i := range results {
+                if !batchErr.failed(i) {
+                    succeeded = append(succeeded, results[i])
+                }
+            }
+            return succeeded, batchErr
+        } else if err != nil {
+            return nil, err
+        }
         return results, nil
     }
 
@@ -1088,7 +1737,7 @@ func Apply{{ .Name }}QueryArgsDefaults(queryArgs *{{ .Name }}QueryArguments) *{{
             return []GraphQLResource{
                 GraphQLResource{
                     Name:     "{{ plural .Name }}",
-                    Describe: "This is a graphQL resource {{ plural .Name }}, have GetAll, Get, Insert, Update, Delete actions.",
+                    Describe: "This is a graphQL resource {{ plural .Name }}, have GetAll, Get, Aggregate, Insert, Update, Delete actions.",
                 },
             {{- range $x := .Indexes }}
                 {{- if not (isprimaryindex .) }}
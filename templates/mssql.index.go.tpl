@@ -1,11 +1,11 @@
-{{- $short := (shortname .Type.Name "err" "sqlstr" "db" "q" "res" "xoLog" .Fields) -}}
+{{- $short := (shortname .Type.Name "err" "sqlstr" "db" "ctx" "q" "res" "xoLog" .Fields) -}}
 {{- $table := (schema .Schema .Type.Table.TableName) -}}
 {{- $dname := (print (firstletterupper (driver) ) "Storage") -}}
 
 // {{ .FuncName }} retrieves a row from '{{ $table }}' as a {{ .Type.Name }}.
 //
 // Generated from index '{{ .Index.IndexName }}'.
-func (s *{{ $dname }}) {{ .FuncName }}(db XODB{{ goparamlist .Fields true true }}) ({{ if not .Index.IsUnique }}[]{{ end }}*{{ .Type.Name }}, error) {
+func (s *{{ $dname }}) {{ .FuncName }}({{ dbparam }}{{ goparamlist .Fields true true }}) ({{ if not .Index.IsUnique }}[]{{ end }}*{{ .Type.Name }}, error) {
 	var err error
 
 	// sql query
@@ -23,14 +23,14 @@ func (s *{{ $dname }}) {{ .FuncName }}(db XODB{{ goparamlist .Fields true true }
 	{{ end -}}
 	}
 
-	err = db.QueryRow(sqlstr{{ goparamlist .Fields true false }}).Scan({{ fieldnames .Type.Fields (print "&" $short) }})
+	err = {{ dbcall "QueryRow" }}sqlstr{{ goparamlist .Fields true false }}).Scan({{ fieldnames .Type.Fields (print "&" $short) }})
 	if err != nil {
-		return nil, err
+		return nil, s.translateError(`{{ .Type.Table.TableName }}`, err)
 	}
 
 	return &{{ $short }}, nil
 {{- else }}
-	q, err := db.Query(sqlstr{{ goparamlist .Fields true false }})
+	q, err := {{ dbcall "Query" }}sqlstr{{ goparamlist .Fields true false }})
 	if err != nil {
 		return nil, err
 	}
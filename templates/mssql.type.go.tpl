@@ -1,10 +1,14 @@
-{{- $short := (shortname .Name "err" "res" "sqlstr" "db" "xoLog") -}}
+{{- $short := (shortname .Name "err" "res" "sqlstr" "db" "ctx" "xoLog") -}}
 {{- $table := (schema .Schema .Table.TableName) -}}
 {{- $dname := (print (firstletterupper (driver) ) "Storage") -}}
+{{- $deleted := (deletedcolumn .) -}}
+{{- $created := (createdcolumn .) -}}
+{{- $changed := (changedcolumn .) -}}
+{{- $softdelete := (softdelete .) -}}
 
 {{ if .PrimaryKey }}
 // Insert{{ .Name }} inserts the {{ .Name }} to the database.
-func (s *{{ $dname }}) Insert{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error {
+func (s *{{ $dname }}) Insert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
 	var err error
 
 	// if already exist, bail
@@ -12,6 +16,11 @@ func (s *{{ $dname }}) Insert{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) err
 		return errors.New("insert failed: already exists")
 	}
 
+	// run the BeforeInsert hook
+	if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+		return err
+	}
+
 {{ if .Table.ManualPk  }}
 	// sql insert query, primary key must be provided
 	const sqlstr = `INSERT INTO {{ $table }} (` +
@@ -22,9 +31,9 @@ func (s *{{ $dname }}) Insert{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) err
 
 	// run query
 	s.info(sqlstr, {{ fieldnames .Fields $short }})
-	_, err = db.Exec(sqlstr, {{ fieldnames .Fields $short }})
+	_, err = {{ dbcall "Exec" }}sqlstr, {{ fieldnames .Fields $short }})
 	if err != nil {
-		return err
+		return s.translateError(`{{ $.Table.TableName }}`, err)
 	}
 
 	// set existence
@@ -39,21 +48,26 @@ func (s *{{ $dname }}) Insert{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) err
 
 	// run query
 	s.info(sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }})
-	err = db.QueryRow(sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }}).Scan(&{{ $short }}.{{ .PrimaryKey.Name }})
+	err = {{ dbcall "QueryRow" }}sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }}).Scan(&{{ $short }}.{{ .PrimaryKey.Name }})
 	if err != nil {
-		return err
+		return s.translateError(`{{ $.Table.TableName }}`, err)
 	}
 
 	// set primary key and existence
 	{{ $short }}._exists = true
 {{ end }}
 
-	return nil
+	return xoAfterInsert({{ dbarg }}, {{ $short }})
 }
 
 
 // Insert{{ .Name }}ByFields inserts the {{ .Name }} to the database.
-func (s *{{ $dname }}) Insert{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name }}) error {
+func (s *{{ $dname }}) Insert{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+	// run the BeforeInsert hook
+	if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+		return err
+	}
+
 	var err error
 
     {{ $length := minus (len .Fields) 1 }}
@@ -66,10 +80,10 @@ func (s *{{ $dname }}) Insert{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name
 	
 	{{- range $index, $field := .Fields -}}
 	    {{ if (not (and $field.Col.IsPrimaryKey (not $.Table.ManualPk))) -}}
-		    {{ if $field.Col.NotNull }}
+		    {{ if (or $field.Col.NotNull (not (hasvalid $field.Type))) }}
                 fields = append(fields, `{{ (colname $field.Col) }}`)
                 params = append(params, {{ $sn }}.{{ $field.Name }})
-		    {{ else -}}
+		    {{ else }}
                 if {{ $sn }}.{{ $field.Name }}.Valid {
                     fields = append(fields, `{{ (colname $field.Col) }}`)
                     params = append(params, {{ $sn }}.{{ $field.Name }})
@@ -100,20 +114,132 @@ func (s *{{ $dname }}) Insert{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name
                 ` VALUES (` + placeHolderStr + `)`
 
     s.info(sqlstr, params)
-    err = db.QueryRow(sqlstr, params...).Scan(retVars...)
+    err = {{ dbcall "QueryRow" }}sqlstr, params...).Scan(retVars...)
     if err != nil {
-        return err
+        return s.translateError(`{{ $.Table.TableName }}`, err)
     }
 
 	// set existence
 	{{ $short }}._exists = true
 
+	return xoAfterInsert({{ dbarg }}, {{ $short }})
+}
+
+{{- $ignore := .PrimaryKey.Name }}
+{{- if .Table.ManualPk }}{{ $ignore = "" }}{{ end }}
+{{- $ncols := len .Fields }}
+{{- if not .Table.ManualPk }}{{ $ncols = minus $ncols 1 }}{{ end }}
+{{ if eq $ncols 0 -}}
+// Insert{{ .Name }}s inserts the {{ .Name }}s to the database, one by one as
+// they have no values to insert.
+func (s *{{ $dname }}) Insert{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error {
+	for _, {{ $short }} := range {{ $short }}s {
+		if err := s.Insert{{ .Name }}({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+{{- else -}}
+// Insert{{ .Name }}s inserts the {{ .Name }}s to the database, in multi-row
+// INSERT statements of at most 1000 rows and 2100 parameters.
+{{- if not .Table.ManualPk }}
+// The primary keys of the {{ .Name }}s are set from the database.
+{{- end }}
+func (s *{{ $dname }}) Insert{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error {
+	// if already exist, bail
+	for _, {{ $short }} := range {{ $short }}s {
+		if {{ $short }}._exists {
+			return errors.New("insert failed: already exists")
+		}
+	}
+
+	// run the BeforeInsert hooks
+	for _, {{ $short }} := range {{ $short }}s {
+		if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+	}
+
+	// rows per statement, within the parameter limit of which sp_executesql
+	// takes 2, and the 1000 rows limit of VALUES
+	n := 2098 / {{ $ncols }}
+	if n > 1000 {
+		n = 1000
+	}
+
+	for len({{ $short }}s) > 0 {
+		batch := {{ $short }}s
+		if len(batch) > n {
+			batch = batch[:n]
+		}
+		{{ $short }}s = {{ $short }}s[len(batch):]
+
+		args := make([]interface{}, 0, len(batch)*{{ $ncols }})
+		for _, {{ $short }} := range batch {
+			args = append(args, {{ fieldnames .Fields $short $ignore }})
+		}
+
+{{- if .Table.ManualPk }}
+
+		// sql insert query, primary keys must be provided
+		sqlstr := `INSERT INTO {{ $table }} (` +
+			`{{ colnames .Fields }}` +
+			`) VALUES ` + xoValues("{{ mask }}", len(batch), {{ $ncols }})
+
+		// run query
+		s.info(sqlstr, args)
+		_, err := {{ dbcall "Exec" }}sqlstr, args...)
+		if err != nil {
+			return s.translateError(`{{ $.Table.TableName }}`, err)
+		}
+{{- else }}
+
+		// sql insert query, primary keys provided by identity
+		sqlstr := `INSERT INTO {{ $table }} (` +
+			`{{ colnames .Fields .PrimaryKey.Name }}` +
+			`) OUTPUT INSERTED.{{ colname .PrimaryKey.Col }} VALUES ` + xoValues("{{ mask }}", len(batch), {{ $ncols }})
+
+		// run query, the primary keys are output in the order of the rows
+		s.info(sqlstr, args)
+		rows, err := {{ dbcall "Query" }}sqlstr, args...)
+		if err != nil {
+			return s.translateError(`{{ $.Table.TableName }}`, err)
+		}
+		i := 0
+		for rows.Next() && i < len(batch) {
+			if err := rows.Scan(&batch[i].{{ .PrimaryKey.Name }}); err != nil {
+				rows.Close()
+				return s.translateError(`{{ $.Table.TableName }}`, err)
+			}
+			i++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return s.translateError(`{{ $.Table.TableName }}`, err)
+		}
+{{- end }}
+
+		// set existence
+		for _, {{ $short }} := range batch {
+			{{ $short }}._exists = true
+		}
+
+		// run the AfterInsert hooks
+		for _, {{ $short }} := range batch {
+			if err := xoAfterInsert({{ dbarg }}, {{ $short }}); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
+{{- end }}
 
 {{ if ne (fieldnames .Fields $short .PrimaryKey.Name) "" }}
 	// Update{{ .Name }} updates the {{ .Name }} in the database.
-	func (s *{{ $dname }}) Update{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error {
+	func (s *{{ $dname }}) Update{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
 		var err error
 
 		// if doesn't exist, bail
@@ -126,6 +252,11 @@ func (s *{{ $dname }}) Insert{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name
 			return errors.New("update failed: marked for deletion")
 		}
 
+		// run the BeforeUpdate hook
+		if err := xoBeforeUpdate({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+
 		// sql query
 		const sqlstr = `UPDATE {{ $table }} SET ` +
 			`{{ colnamesquery .Fields ", " .PrimaryKey.Name }}` +
@@ -133,13 +264,24 @@ func (s *{{ $dname }}) Insert{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name
 
 		// run query
 		s.info(sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }}, {{ $short }}.{{ .PrimaryKey.Name }})
-		_, err = db.Exec(sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }}, {{ $short }}.{{ .PrimaryKey.Name }})
-		return err
+		_, err = {{ dbcall "Exec" }}sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }}, {{ $short }}.{{ .PrimaryKey.Name }})
+		if err != nil {
+			return s.translateError(`{{ $.Table.TableName }}`, err)
+		}
+
+		// run the AfterUpdate hook
+		return xoAfterUpdate({{ dbarg }}, {{ $short }})
 	}
 
 
 	// Update{{ .Name }}ByFields updates the {{ .Name }} in the database.
-	func (s *{{ $dname }}) Update{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name }}, fields, retCols []string, params, retVars []interface{}) error {
+	func (s *{{ $dname }}) Update{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}, fields, retCols []string, params, retVars []interface{}) error {
+		// run the BeforeUpdate hook, saving the columns it changes
+		fields, retCols, params, retVars, err := xoBeforeUpdateFields({{ dbarg }}, {{ $short }}, fields, retCols, params, retVars)
+		if err != nil {
+			return err
+		}
+
         var setstr string
         var idxvals []interface{}
         for i, field := range fields {
@@ -150,12 +292,10 @@ func (s *{{ $dname }}) Insert{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name
             idxvals = append(idxvals, i+1)
         }
 
-        var retstr string
-        for i, retCol := range retCols {
-            if i != 0 {
-                retstr += ", "
-            }
-            retstr += "INSERTED." + retCol
+        // the primary key is always output so a missing row reports sql.ErrNoRows
+        retstr := "INSERTED.{{ colname .PrimaryKey.Col }}"
+        for _, retCol := range retCols {
+            retstr += ", INSERTED." + retCol
         }
 
         params = append(params, {{ $short }}.{{ .PrimaryKey.Name }})
@@ -164,26 +304,31 @@ func (s *{{ $dname }}) Insert{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name
             setstr + ` OUTPUT ` + retstr +
             ` WHERE {{ colname .PrimaryKey.Col }} = {{ mask }}`, idxvals...)
         s.info(sqlstr, params)
-        if err := db.QueryRow(sqlstr, params...).Scan(retVars...); err != nil {
-            return err
+        if err := {{ dbcall "QueryRow" }}sqlstr, params...).Scan(append([]interface{}{&{{ $short }}.{{ .PrimaryKey.Name }}}, retVars...)...); err != nil {
+            return s.translateError(`{{ $.Table.TableName }}`, err)
         }
 
-        return nil
+        return xoAfterUpdate({{ dbarg }}, {{ $short }})
 	}
 
 	// Save{{ .Name }} saves the {{ .Name }} to the database.
-	func (s *{{ $dname }}) Save{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error {
+	func (s *{{ $dname }}) Save{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
 		if {{ $short }}.Exists() {
-			return s.Update{{ .Name }}(db, {{ $short }})
+			return s.Update{{ .Name }}({{ dbarg }}, {{ $short }})
 		}
 
-		return s.Insert{{ .Name }}(db, {{ $short }})
+		return s.Insert{{ .Name }}({{ dbarg }}, {{ $short }})
 	}
 
 	// Upsert{{ .Name }} performs an upsert for {{ .Name }}.
-	func (s *{{ $dname }}) Upsert{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error {
+	func (s *{{ $dname }}) Upsert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
 		var err error
 
+		// run the BeforeInsert hook, an upsert writes the row as it is inserted
+		if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+
 		// sql query
 
 	    const sqlstr = `MERGE {{ $table }} AS t ` +
@@ -194,22 +339,102 @@ func (s *{{ $dname }}) Insert{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name
 
 		// run query
 		s.info(sqlstr, {{ fieldnames .Fields $short }})
-		_, err = db.Exec(sqlstr, {{ fieldnames .Fields $short }})
+		_, err = {{ dbcall "Exec" }}sqlstr, {{ fieldnames .Fields $short }})
 		if err != nil {
-			return err
+			return s.translateError(`{{ $.Table.TableName }}`, err)
 		}
 
 		// set existence
 		{{ $short }}._exists = true
 
-		return nil
+		return xoAfterInsert({{ dbarg }}, {{ $short }})
 	}
 {{ else }}
 	// Update statements omitted due to lack of fields other than primary key
 {{ end }}
 
+{{- range $ix := .Indexes }}
+{{- if and $ix.Index.IsUnique (not $ix.Index.IsPrimary) }}
+{{- $insignore := $.PrimaryKey.Name }}
+{{- if $.Table.ManualPk }}{{ $insignore = "" }}{{ end }}
+
+// Upsert{{ $ix.FuncName }} performs an upsert for {{ $.Name }} on the unique
+// index '{{ $ix.Index.IndexName }}': it inserts the {{ $.Name }}, or when a row
+// with the same {{ colnames $ix.Fields }} exists, overwrites its columns chosen by opts.
+func (s *{{ $dname }}) Upsert{{ $ix.FuncName }}({{ dbparam }}, {{ $short }} *{{ $.Name }}, opts *UpsertOptions) error {
+	var err error
+
+	err = opts.check({{ range $i, $f := $.Fields }}{{ if $i }}, {{ end }}"{{ $f.Col.ColumnName }}"{{ end }})
+	if err != nil {
+		return err
+	}
+
+	// run the BeforeInsert hook, an upsert writes the row as it is inserted
+	if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+		return err
+	}
+
+	// columns overwritten when the row exists
+	var set []string
+	{{- range $f := $.Fields }}
+		{{- $skip := $f.Col.IsPrimaryKey }}
+		{{- range $ix.Fields }}{{ if eq .Name $f.Name }}{{ $skip = true }}{{ end }}{{ end }}
+		{{- if not $skip }}
+	if opts.overwrites("{{ $f.Col.ColumnName }}") {
+		set = append(set, `{{ colname $f.Col }} = s.{{ colname $f.Col }}`)
+	}
+		{{- end }}
+	{{- end }}
+
+	// sql query
+	sqlstr := `MERGE {{ $table }} AS t ` +
+		`USING (SELECT {{ colnamesas $.Fields ", " $insignore }}) AS s ` +
+		`ON {{ range $i, $f := $ix.Fields }}{{ if $i }} AND {{ end }}t.{{ colname $f.Col }} = s.{{ colname $f.Col }}{{ end }} `
+	if len(set) != 0 {
+		sqlstr += `WHEN MATCHED THEN UPDATE SET ` + strings.Join(set, ", ") + ` `
+	}
+{{- if $.Table.ManualPk }}
+	sqlstr += `WHEN NOT MATCHED THEN INSERT ({{ colnames $.Fields $insignore }}) VALUES ({{ colprefixnames $.Fields "s" $insignore }});`
+
+	// run query
+	s.info(sqlstr, {{ fieldnames $.Fields $short $insignore }})
+	_, err = {{ dbcall "Exec" }}sqlstr, {{ fieldnames $.Fields $short $insignore }})
+	if err != nil {
+		return s.translateError(`{{ $.Table.TableName }}`, err)
+	}
+{{- else }}
+	sqlstr += `WHEN NOT MATCHED THEN INSERT ({{ colnames $.Fields $insignore }}) VALUES ({{ colprefixnames $.Fields "s" $insignore }}) ` +
+		`OUTPUT INSERTED.{{ colname $.PrimaryKey.Col }};`
+
+	// run query, retrieving the primary key of the inserted or updated row
+	s.info(sqlstr, {{ fieldnames $.Fields $short $insignore }})
+	err = {{ dbcall "QueryRow" }}sqlstr, {{ fieldnames $.Fields $short $insignore }}).Scan(&{{ $short }}.{{ $.PrimaryKey.Name }})
+	if err == sql.ErrNoRows {
+		// the existing row is left unchanged, retrieve its primary key
+		const pksqlstr = `SELECT {{ colname $.PrimaryKey.Col }} FROM {{ $table }} ` +
+			`WHERE {{ colnamesquery $ix.Fields " AND " }}`
+
+		s.info(pksqlstr, {{ fieldnames $ix.Fields $short }})
+		err = {{ dbcall "QueryRow" }}pksqlstr, {{ fieldnames $ix.Fields $short }}).Scan(&{{ $short }}.{{ $.PrimaryKey.Name }})
+	}
+	if err != nil {
+		return s.translateError(`{{ $.Table.TableName }}`, err)
+	}
+{{- end }}
+
+	// set existence
+	{{ $short }}._exists = true
+
+	return xoAfterInsert({{ dbarg }}, {{ $short }})
+}
+{{- end }}
+{{- end }}
+
 // Delete{{ .Name }} deletes the {{ .Name }} from the database.
-func (s *{{ $dname }}) Delete{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error {
+{{- if $softdelete }}
+// The rows are soft deleted, setting their {{ $deleted }} column.
+{{- end }}
+func (s *{{ $dname }}) Delete{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
 	var err error
 
 	// if doesn't exist, bail
@@ -222,41 +447,56 @@ func (s *{{ $dname }}) Delete{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) err
 		return nil
 	}
 
+	// run the BeforeDelete hook
+	if err := xoBeforeDelete({{ dbarg }}, {{ $short }}); err != nil {
+		return err
+	}
+
 	{{ if gt ( len .PrimaryKeyFields ) 1 }}
         // sql query with composite primary key
-		const sqlstr = `DELETE FROM {{ $table }}  WHERE {{ colnamesquery .PrimaryKeyFields " AND " }}`
+		const sqlstr = `{{ if $softdelete }}UPDATE {{ $table }} SET {{ parsecolname $deleted }} = CURRENT_TIMESTAMP{{ else }}DELETE FROM {{ $table }}{{ end }}  WHERE {{ colnamesquery .PrimaryKeyFields " AND " }}`
 
 		// run query
 		s.info(sqlstr, {{ fieldnames .PrimaryKeyFields $short }})
-		_, err = db.Exec(sqlstr, {{ fieldnames .PrimaryKeyFields $short }})
+		_, err = {{ dbcall "Exec" }}sqlstr, {{ fieldnames .PrimaryKeyFields $short }})
 		if err != nil {
-			return err
+			return s.translateError(`{{ $.Table.TableName }}`, err)
 		}
 	{{- else }}
         // sql query
-        const sqlstr = `DELETE FROM {{ $table }} WHERE {{ colname .PrimaryKey.Col }} = {{ colnumval 1 }}`
+        const sqlstr = `{{ if $softdelete }}UPDATE {{ $table }} SET {{ parsecolname $deleted }} = CURRENT_TIMESTAMP{{ else }}DELETE FROM {{ $table }}{{ end }} WHERE {{ colname .PrimaryKey.Col }} = {{ colnumval 1 }}`
 
         // run query
         s.info(sqlstr, {{ $short }}.{{ .PrimaryKey.Name }})
-        _, err = db.Exec(sqlstr, {{ $short }}.{{ .PrimaryKey.Name }})
+        _, err = {{ dbcall "Exec" }}sqlstr, {{ $short }}.{{ .PrimaryKey.Name }})
         if err != nil {
-            return err
+            return s.translateError(`{{ $.Table.TableName }}`, err)
         }
 	{{- end }}
 
 	// set deleted
 	{{ $short }}._deleted = true
 
-	return nil
+	return xoAfterDelete({{ dbarg }}, {{ $short }})
 }
 
 // Delete{{ .Name }}s deletes the {{ .Name }} from the database.
-func (s *{{ $dname }}) Delete{{ .Name }}s(db XODB, {{ $short }}s []*{{ .Name }}) error {
+{{- if $softdelete }}
+// The rows are soft deleted, setting their {{ $deleted }} column.
+{{- end }}
+func (s *{{ $dname }}) Delete{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error {
 	var err error
 
 	if len({{ $short }}s) == 0 {
 		return nil
 	}
+
+	// run the BeforeDelete hooks
+	for _, {{ $short }} := range {{ $short }}s {
+		if err := xoBeforeDelete({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+	}
 	
 	{{ if gt ( len .PrimaryKeyFields ) 1 }}
 	    {{- range .PrimaryKeyFields }}
@@ -264,9 +504,9 @@ func (s *{{ $dname }}) Delete{{ .Name }}s(db XODB, {{ $short }}s []*{{ .Name }})
             var placeholder{{ .Name }} string
 	    {{- end }}
 
-        for i, {{ $short }} := range {{ $short }}s {
+        for i := range {{ $short }}s {
             {{- range .PrimaryKeyFields }}
-                args{{ .Name }} = append(args{{ .Name }}, {{ $short }}.{{ .Name }})
+                args{{ .Name }} = append(args{{ .Name }}, {{ $short }}s[i].{{ .Name }})
                 if i != 0 {
                     placeholder{{ .Name }} = placeholder{{ .Name }} + ", "
                 }
@@ -286,19 +526,19 @@ func (s *{{ $dname }}) Delete{{ .Name }}s(db XODB, {{ $short }}s []*{{ .Name }})
 	    {{- end }}
 
 		// sql query with composite primary key
-		var sqlstr = `DELETE FROM {{ $table }} WHERE ` + where
+		var sqlstr = `{{ if $softdelete }}UPDATE {{ $table }} SET {{ parsecolname $deleted }} = CURRENT_TIMESTAMP{{ else }}DELETE FROM {{ $table }}{{ end }} WHERE ` + where
 
 		// run query
 		s.info(sqlstr, args)
-		_, err = db.Exec(sqlstr, args...)
+		_, err = {{ dbcall "Exec" }}sqlstr, args...)
 		if err != nil {
-			return err
+			return s.translateError(`{{ $.Table.TableName }}`, err)
 		}
 	{{- else }}
         var args []interface{}
         var placeholder string
-        for i, {{ $short }} := range {{ $short }}s {
-            args = append(args, {{ $short }}.{{ .PrimaryKey.Name }})
+        for i := range {{ $short }}s {
+            args = append(args, {{ $short }}s[i].{{ .PrimaryKey.Name }})
             if i != 0 {
                 placeholder = placeholder + ", "
             }
@@ -306,13 +546,13 @@ func (s *{{ $dname }}) Delete{{ .Name }}s(db XODB, {{ $short }}s []*{{ .Name }})
         }
 
         // sql query
-        var sqlstr = `DELETE FROM {{ $table }} WHERE {{ colname .PrimaryKey.Col }} in (` + placeholder + `)`
+        var sqlstr = `{{ if $softdelete }}UPDATE {{ $table }} SET {{ parsecolname $deleted }} = CURRENT_TIMESTAMP{{ else }}DELETE FROM {{ $table }}{{ end }} WHERE {{ colname .PrimaryKey.Col }} in (` + placeholder + `)`
 
         // run query
         s.info(sqlstr, args)
-        _, err = db.Exec(sqlstr, args...)
+        _, err = {{ dbcall "Exec" }}sqlstr, args...)
         if err != nil {
-            return err
+            return s.translateError(`{{ $.Table.TableName }}`, err)
         }
 	{{- end }}
 
@@ -321,20 +561,28 @@ func (s *{{ $dname }}) Delete{{ .Name }}s(db XODB, {{ $short }}s []*{{ .Name }})
 	    {{ $short }}._deleted = true
 	}
 
+	// run the AfterDelete hooks
+	for _, {{ $short }} := range {{ $short }}s {
+		if err := xoAfterDelete({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 {{- end }}
 
+{{ if $created }}
 // GetMostRecent{{ .Name }} returns n most recent rows from '{{ .Table.TableName }}',
-// ordered by "created_date" in descending order.
-func (s *{{ $dname }}) GetMostRecent{{ .Name }}(db XODB, n int) ([]*{{ .Name }}, error) {
+// ordered by "{{ $created }}" in descending order.
+func (s *{{ $dname }}) GetMostRecent{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error) {
 	var sqlstr = `SELECT TOP ` + strconv.Itoa(n) + 
 		` {{ colnames .Fields }} ` +
 		`FROM {{ $table }} ` +
-		`ORDER BY {{ parsecolname "created_date" }} DESC`
+		`ORDER BY {{ parsecolname $created }} DESC`
 
 	s.info(sqlstr)
-	q, err := db.Query(sqlstr)
+	q, err := {{ dbcall "Query" }}sqlstr)
 	if err != nil {
 		return nil, err
 	}
@@ -356,17 +604,19 @@ func (s *{{ $dname }}) GetMostRecent{{ .Name }}(db XODB, n int) ([]*{{ .Name }},
 
 	return res, nil
 }
+{{ end }}
 
+{{ if $changed }}
 // GetMostRecentChanged{{ .Name }} returns n most recent rows from '{{ .Table.TableName }}',
-// ordered by "changed_date" in descending order.
-func (s *{{ $dname }}) GetMostRecentChanged{{ .Name }}(db XODB, n int) ([]*{{ .Name }}, error) {
+// ordered by "{{ $changed }}" in descending order.
+func (s *{{ $dname }}) GetMostRecentChanged{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error) {
 	var sqlstr = `SELECT TOP ` + strconv.Itoa(n) + 
 		` {{ colnames .Fields }} ` +
 		`FROM {{ $table }} ` +
-		`ORDER BY {{ parsecolname "changed_date" }} DESC`
+		`ORDER BY {{ parsecolname $changed }} DESC`
 
 	s.info(sqlstr)
-	q, err := db.Query(sqlstr)
+	q, err := {{ dbcall "Query" }}sqlstr)
 	if err != nil {
 		return nil, err
 	}
@@ -388,10 +638,11 @@ func (s *{{ $dname }}) GetMostRecentChanged{{ .Name }}(db XODB, n int) ([]*{{ .N
 
 	return res, nil
 }
+{{ end }}
 
 // GetAll{{ .Name }} returns all rows from '{{ .Table.TableName }}', based on the {{ .Name }}QueryArguments.
 // If the {{ .Name }}QueryArguments is nil, it will use the default {{ .Name }}QueryArguments instead.
-func (s *{{ $dname }}) GetAll{{ .Name }}(db XODB, queryArgs *{{ .Name }}QueryArguments) ([]*{{ .Name }}, error) { // nolint: gocyclo
+func (s *{{ $dname }}) GetAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) ([]*{{ .Name }}, error) { // nolint: gocyclo
 	queryArgs = Apply{{ .Name }}QueryArgsDefaults(queryArgs)
 {{- if (existsqlfilter .) }}
 	if queryArgs.filterArgs == nil{
@@ -403,64 +654,63 @@ func (s *{{ $dname }}) GetAll{{ .Name }}(db XODB, queryArgs *{{ .Name }}QueryArg
 	}
 {{- end }}
 
-	desc := ""
-	if *queryArgs.Desc {
-		desc = "DESC"
-	}
-
+{{ if $deleted }}
 	dead := "NULL"
 	if *queryArgs.Dead {
 		dead = "NOT NULL"
 	}
+{{- end }}
 
-	orderBy := "{{ $.PrimaryKey.Col.ColumnName }}"
-	foundIndex := false
-	dbFields := map[string]bool {
-	{{- range .Fields }}
-	"{{ .Col.ColumnName }}":true,
-	{{- end }}
-	}
-
-	if *queryArgs.OrderBy != "" && *queryArgs.OrderBy != defaultOrderBy {
-		foundIndex = dbFields[*queryArgs.OrderBy]
-		if !foundIndex{
-            return nil, fmt.Errorf("unable to order by %s, field not found",*queryArgs.OrderBy)
-		}
-		orderBy=*queryArgs.OrderBy
+	order, err := get{{ .Name }}Order(queryArgs)
+	if err != nil {
+		return nil, err
 	}
 
 	var params []interface{}
 	placeHolders := ""
 {{- if (existsqlfilter .) }}
 	if queryArgs.filterArgs != nil{
-		pls := make([]string, len(queryArgs.filterArgs.filterPairs))
-		for i, pair := range queryArgs.filterArgs.filterPairs {
-		   pls[i] = fmt.Sprintf("%s %s {{ mask }}", pair.fieldName, pair.option, i+1)
-		   params = append(params, pair.value)
-	   }
-	   placeHolders = strings.Join(pls, " " + queryArgs.filterArgs.conjunction + " ")
-	   placeHolders = fmt.Sprintf("(%s) AND", placeHolders)
+		placeHolders = fmt.Sprintf("%s AND", queryArgs.filterArgs.sql(func(v interface{}) string {
+			params = append(params, v)
+			return fmt.Sprintf("{{ mask }}", len(params))
+		}, s.filterOption))
 	}
 {{- end }}
-	params = append(params, *queryArgs.Offset)
+	offset, limit := *queryArgs.Offset, *queryArgs.Limit
+	keyset, reverse := queryArgs.isKeyset(), false
+	if keyset {
+		pls, n, rev, err := queryArgs.keyset("{{ .Name }}", `{{ $table }}`, order, `{{ colname $.PrimaryKey.Col }}`, func(v interface{}) string {
+			params = append(params, v)
+			return fmt.Sprintf("{{ mask }}", len(params))
+		})
+		if err != nil {
+			return nil, err
+		}
+		placeHolders = fmt.Sprintf("%s %s", placeHolders, pls)
+		offset, limit, reverse = 0, n, rev
+	}
+
+
+	params = append(params, offset)
 	offsetPos := len(params)
 
-	params = append(params, *queryArgs.Limit)
+	params = append(params, limit)
 	limitPos := len(params)
 	
     
-	var sqlstr = fmt.Sprintf(`SELECT %s FROM %s WHERE %s {{ parsecolname "deleted_date" }} IS %s ORDER BY {{ parsecolname "%s" }}  %s OFFSET {{ mask }} ROWS FETCH NEXT {{ mask }} ROWS ONLY`,
+	var sqlstr = fmt.Sprintf(`SELECT %s FROM %s WHERE %s {{ if $deleted }}{{ parsecolname $deleted }} IS %s{{ else }}1 = 1{{ end }} ORDER BY %s OFFSET {{ mask }} ROWS FETCH NEXT {{ mask }} ROWS ONLY`,
 		`{{ colnames .Fields }} `,
 		`{{ $table }}`,
 		placeHolders,
+{{- if $deleted }}
 		dead,
-		orderBy,
-		desc,
+{{- end }}
+		orderSQL(order, reverse),
 		offsetPos,
 		limitPos)
 	s.info(sqlstr, params)
 
-	q, err := db.Query(sqlstr, params...)
+	q, err := {{ dbcall "Query" }}sqlstr, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -480,11 +730,24 @@ func (s *{{ $dname }}) GetAll{{ .Name }}(db XODB, queryArgs *{{ .Name }}QueryArg
 		res = append(res, &{{ $short }})
 	}
 
+	if keyset {
+		more := len(res) == int(limit)
+		if more {
+			res = res[:len(res)-1]
+		}
+		if reverse {
+			for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+				res[i], res[j] = res[j], res[i]
+			}
+		}
+		queryArgs.setPageInfo(more, reverse)
+	}
+
 	return res, nil
 }
 
 // CountAll{{ .Name }} returns a count of all rows from '{{ .Table.TableName }}'
-func (s *{{ $dname }}) CountAll{{ .Name }}(db XODB, queryArgs *{{ .Name }}QueryArguments) (int, error) {
+func (s *{{ $dname }}) CountAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) (int, error) {
 	queryArgs = Apply{{ .Name }}QueryArgsDefaults(queryArgs)
 {{- if (existsqlfilter .) }}
 	if queryArgs.filterArgs == nil{
@@ -496,95 +759,177 @@ func (s *{{ $dname }}) CountAll{{ .Name }}(db XODB, queryArgs *{{ .Name }}QueryA
 	}
 {{- end }}
 
+{{ if $deleted }}
 	dead := "NULL"
 	if *queryArgs.Dead {
 		dead = "NOT NULL"
 	}
+{{- end }}
 
 	var params []interface{}
 	placeHolders := ""
 {{- if (existsqlfilter .) }}
 	if queryArgs.filterArgs != nil{
-		pls := make([]string, len(queryArgs.filterArgs.filterPairs))
-		for i, pair := range queryArgs.filterArgs.filterPairs {
-		   pls[i] = fmt.Sprintf("%s %s {{ mask }}", pair.fieldName, pair.option, i+1)
-		   params = append(params, pair.value)
-	   }
-	   placeHolders = strings.Join(pls, " " + queryArgs.filterArgs.conjunction + " ")
-	   placeHolders = fmt.Sprintf("(%s) AND", placeHolders)
+		placeHolders = fmt.Sprintf("%s AND", queryArgs.filterArgs.sql(func(v interface{}) string {
+			params = append(params, v)
+			return fmt.Sprintf("{{ mask }}", len(params))
+		}, s.filterOption))
 	}
 {{- end }}
 
 	var err error
-	var sqlstr = fmt.Sprintf(`SELECT count(*) from {{ $table }} WHERE %s {{ parsecolname "deleted_date" }} IS %s`, placeHolders, dead)
+	var sqlstr = fmt.Sprintf(`SELECT count(*) from {{ $table }} WHERE %s {{ if $deleted }}{{ parsecolname $deleted }} IS %s{{ else }}1 = 1{{ end }}`, placeHolders{{ if $deleted }}, dead{{ end }})
 	s.info(sqlstr)
 
 	var count int
-	err = db.QueryRow(sqlstr, params...).Scan(&count)
+	err = {{ dbcall "QueryRow" }}sqlstr, params...).Scan(&count)
 	if err != nil {
 		return -1, err
 	}
 	return count, nil
 }
 
+// Aggregate{{ .Name }} returns the aggregates of the rows from '{{ .Table.TableName }}' grouped by the groupBy columns,
+// based on the {{ .Name }}QueryArguments.
+func (s *{{ $dname }}) Aggregate{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments, groupBy []{{ .Name }}OrderField) ([]*{{ .Name }}Aggregate, error) {
+	queryArgs = Apply{{ .Name }}QueryArgsDefaults(queryArgs)
+{{- if (existsqlfilter .) }}
+	if queryArgs.filterArgs == nil{
+		filterArgs, err := get{{ .Name }}Filter(queryArgs.Where)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to get {{ .Name }} filter")
+		}
+		queryArgs.filterArgs = filterArgs
+	}
+{{- end }}
+	columns, dests, err := get{{ .Name }}GroupBy(groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+{{ if $deleted }}
+	dead := "NULL"
+	if *queryArgs.Dead {
+		dead = "NOT NULL"
+	}
+{{- end }}
+
+	var params []interface{}
+	placeHolders := ""
+{{- if (existsqlfilter .) }}
+	if queryArgs.filterArgs != nil{
+		placeHolders = fmt.Sprintf("%s AND", queryArgs.filterArgs.sql(func(v interface{}) string {
+			params = append(params, v)
+			return fmt.Sprintf("{{ mask }}", len(params))
+		}, s.filterOption))
+	}
+{{- end }}
+
+	selects, groups := "", ""
+	if len(columns) > 0 {
+		selects = strings.Join(columns, ", ") + ", "
+		groups = fmt.Sprintf(" GROUP BY %[1]s ORDER BY %[1]s", strings.Join(columns, ", "))
+	}
+	var sqlstr = fmt.Sprintf(`SELECT %scount(*)
+	{{- range (aggregatefields . "Number") }}, sum({{ colname .Col }}), avg(cast({{ colname .Col }} AS float)){{ end }}
+	{{- range (aggregatefields . "Number" "Time") }}, min({{ colname .Col }}), max({{ colname .Col }}){{ end }} from {{ $table }} WHERE %s {{ if $deleted }}{{ parsecolname $deleted }} IS %s{{ else }}1 = 1{{ end }}%s`, selects, placeHolders{{ if $deleted }}, dead{{ end }}, groups)
+	s.info(sqlstr, params)
+
+	q, err := {{ dbcall "Query" }}sqlstr, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	var res []*{{ .Name }}Aggregate
+	for q.Next() {
+		a := &{{ .Name }}Aggregate{Group: &{{ .Name }}{}}
+
+		// scan
+		err = q.Scan(append(dests(a.Group), &a.Count
+		{{- range (aggregatefields . "Number") }}, &a.Sum.{{ .Name }}, &a.Avg.{{ .Name }}{{ end }}
+		{{- range (aggregatefields . "Number" "Time") }}, &a.Min.{{ .Name }}, &a.Max.{{ .Name }}{{ end }})...)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, a)
+	}
+
+	return res, nil
+}
+
 {{ range .ForeignKeys }}
 	{{- $fnname := (print (plural $.Name) "By" .Field.Name "FK") -}}
 	{{- if not (isdup $fnname "mssql") }}
 	// {{ $fnname }} retrieves rows from {{ $table }} by foreign key {{.Field.Name}}.
 	// Generated from foreign key {{.Name}}.
-	func (s *{{ $dname }}) {{ $fnname }}(db XODB, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $.Name }}QueryArguments) ([]*{{$.Name}}, error) {
+	func (s *{{ $dname }}) {{ $fnname }}({{ dbparam }}, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $.Name }}QueryArguments) ([]*{{$.Name}}, error) {
 		queryArgs = Apply{{ $.Name }}QueryArgsDefaults(queryArgs)
-
-		desc := ""
-		if *queryArgs.Desc {
-			desc = "DESC"
+		order, err := get{{ $.Name }}Order(queryArgs)
+		if err != nil {
+			return nil, err
 		}
 
+{{ if $deleted }}
 		dead := "NULL"
 		if *queryArgs.Dead {
 			dead = "NOT NULL"
 		}
+{{- end }}
 
 		var params []interface{}
 		placeHolders := ""
 {{- if (existsqlfilter .Type) }}
 		if queryArgs.filterArgs != nil{
-			pos := 0
-			pls := make([]string, 0, len(queryArgs.filterArgs.filterPairs))
-			for _, pair := range queryArgs.filterArgs.filterPairs {
-				if pair.fieldName == "{{ .Field.Col.ColumnName }}"{
-					return nil, fmt.Errorf("already have condition on field:{{ .Field.Name }}, because of foregin key {{ .Name }}")
-				}
-				pos++
-				pls = append(pls, fmt.Sprintf("%s %s {{ mask }}", pair.fieldName, pair.option, pos))
-				params = append(params, pair.value)
+			if queryArgs.filterArgs.hasField("{{ .Field.Col.ColumnName }}"){
+				return nil, fmt.Errorf("already have condition on field:{{ .Field.Name }}, because of foregin key {{ .Name }}")
 			}
-			placeHolders = strings.Join(pls, " " + queryArgs.filterArgs.conjunction + " ")
-			placeHolders = fmt.Sprintf("(%s) AND", placeHolders)
+			placeHolders = fmt.Sprintf("%s AND", queryArgs.filterArgs.sql(func(v interface{}) string {
+				params = append(params, v)
+				return fmt.Sprintf("{{ mask }}", len(params))
+			}, s.filterOption))
 		}
 {{- end }}
 		params = append(params, {{ togqlname .Field.Name }})
 		placeHolders = fmt.Sprintf(`%s {{ colname .Field.Col }} = {{ mask }} AND `, placeHolders, len(params))
 
-		params = append(params, *queryArgs.Offset)
+		offset, limit := *queryArgs.Offset, *queryArgs.Limit
+		keyset, reverse := queryArgs.isKeyset(), false
+		if keyset {
+			pls, n, rev, err := queryArgs.keyset("{{ $.Name }}", `{{ $table }}`, order, `{{ colname $.PrimaryKey.Col }}`, func(v interface{}) string {
+				params = append(params, v)
+				return fmt.Sprintf("{{ mask }}", len(params))
+			})
+			if err != nil {
+				return nil, err
+			}
+			placeHolders = fmt.Sprintf("%s %s", placeHolders, pls)
+			offset, limit, reverse = 0, n, rev
+		}
+
+
+		params = append(params, offset)
 		offsetPos := len(params)
 
-		params = append(params, *queryArgs.Limit)
+		params = append(params, limit)
 		limitPos := len(params)
 
 		var sqlstr = fmt.Sprintf(
-			`SELECT %s FROM %s WHERE %s {{ parsecolname "deleted_date" }} IS %s ORDER BY {{ parsecolname "%s" }} %s OFFSET {{ mask }} ROWS FETCH NEXT {{ mask }} ROWS ONLY`,
+			`SELECT %s FROM %s WHERE %s {{ if $deleted }}{{ parsecolname $deleted }} IS %s{{ else }}1 = 1{{ end }} ORDER BY %s OFFSET {{ mask }} ROWS FETCH NEXT {{ mask }} ROWS ONLY`,
 			`{{ colnames $.Fields }} `,
 			`{{ $table }}`,
 			placeHolders,
+{{- if $deleted }}
 			dead,
-			"{{ $.PrimaryKey.Col.ColumnName }}",
-			desc,
+{{- end }}
+			orderSQL(order, reverse),
 			offsetPos,
 			limitPos)
 
 	    s.info(sqlstr, params...)
-		q, err := db.Query(sqlstr, params...)
+		q, err := {{ dbcall "Query" }}sqlstr, params...)
 		if err != nil {
 			return nil, err
 		}
@@ -604,46 +949,56 @@ func (s *{{ $dname }}) CountAll{{ .Name }}(db XODB, queryArgs *{{ .Name }}QueryA
 			res = append(res, &{{ $short }})
 		}
 
+		if keyset {
+			more := len(res) == int(limit)
+			if more {
+				res = res[:len(res)-1]
+			}
+			if reverse {
+				for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+					res[i], res[j] = res[j], res[i]
+				}
+			}
+			queryArgs.setPageInfo(more, reverse)
+		}
+
 		return res, nil
 	}
 
 	// Count{{ $fnname }} count rows from {{ $table }} by foreign key {{.Field.Name}}.
 	// Generated from foreign key {{.Name}}.
-	func (s *{{ $dname }}) Count{{ $fnname }}(db XODB, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $.Name }}QueryArguments) (int, error) {
+	func (s *{{ $dname }}) Count{{ $fnname }}({{ dbparam }}, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $.Name }}QueryArguments) (int, error) {
 		queryArgs = Apply{{ $.Name }}QueryArgsDefaults(queryArgs)
 
+{{ if $deleted }}
 		dead := "NULL"
 		if *queryArgs.Dead {
 			dead = "NOT NULL"
 		}
+{{- end }}
 
 		var params []interface{}
 		placeHolders := ""
 {{- if (existsqlfilter .Type) }}
 		if queryArgs.filterArgs != nil{
-			pos := 0
-			pls := make([]string, 0, len(queryArgs.filterArgs.filterPairs))
-			for _, pair := range queryArgs.filterArgs.filterPairs {
-				if pair.fieldName == "{{ .Field.Col.ColumnName }}"{
-					return -1, fmt.Errorf("already have condition on field:{{ .Field.Name }}, because of foregin key {{ .Name }}")
-				}
-				pos++
-				pls = append(pls, fmt.Sprintf("%s %s {{ mask }}", pair.fieldName, pair.option, pos))
-				params = append(params, pair.value)
+			if queryArgs.filterArgs.hasField("{{ .Field.Col.ColumnName }}"){
+				return -1, fmt.Errorf("already have condition on field:{{ .Field.Name }}, because of foregin key {{ .Name }}")
 			}
-			placeHolders = strings.Join(pls, " " + queryArgs.filterArgs.conjunction + " ")
-			placeHolders = fmt.Sprintf("(%s) AND", placeHolders)
+			placeHolders = fmt.Sprintf("%s AND", queryArgs.filterArgs.sql(func(v interface{}) string {
+				params = append(params, v)
+				return fmt.Sprintf("{{ mask }}", len(params))
+			}, s.filterOption))
 		}
 {{- end }}
 		params = append(params, {{ togqlname .Field.Name }})
 		placeHolders = fmt.Sprintf(`%s {{ colname .Field.Col }} = {{ mask }} AND `, placeHolders, len(params))
 
 		var err error
-		var sqlstr = fmt.Sprintf(`SELECT count(*) from {{ $table }} WHERE %s {{ parsecolname "deleted_date" }} IS %s`, placeHolders, dead)
+		var sqlstr = fmt.Sprintf(`SELECT count(*) from {{ $table }} WHERE %s {{ if $deleted }}{{ parsecolname $deleted }} IS %s{{ else }}1 = 1{{ end }}`, placeHolders{{ if $deleted }}, dead{{ end }})
 		s.info(sqlstr)
 
 		var count int
-		err = db.QueryRow(sqlstr, params...).Scan(&count)
+		err = {{ dbcall "QueryRow" }}sqlstr, params...).Scan(&count)
 		if err != nil {
 			return -1, err
 		}
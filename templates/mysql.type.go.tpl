@@ -1,34 +1,14 @@
-{{- $short := (shortname .Name "err" "res" "sqlstr" "db" "xoLog") -}}
+{{- $short := (shortname .Name "err" "res" "sqlstr" "db" "ctx" "xoLog") -}}
 {{- $table := (schema .Schema .Table.TableName) -}}
-{{- if .Comment -}}
-// {{ .Comment }}
-{{- else -}}
-// {{ .Name }} represents a row from '{{ $table }}'.
-{{- end }}
-type {{ .Name }} struct {
-{{- range .Fields }}
-	{{ .Name }} {{ retype .Type }} `json:"{{ .Col.ColumnName }}"` // {{ .Col.ColumnName }}
-{{- end }}
-{{- if .PrimaryKey }}
-
-	// xo fields
-	_exists, _deleted bool
-{{ end }}
-}
+{{- $dname := (print (firstletterupper (driver) ) "Storage") -}}
+{{- $deleted := (deletedcolumn .) -}}
+{{- $created := (createdcolumn .) -}}
+{{- $changed := (changedcolumn .) -}}
+{{- $softdelete := (softdelete .) -}}
 
 {{ if .PrimaryKey }}
-// Exists determines if the {{ .Name }} exists in the database.
-func ({{ $short }} *{{ .Name }}) Exists() bool {
-	return {{ $short }}._exists
-}
-
-// Deleted provides information if the {{ .Name }} has been deleted from the database.
-func ({{ $short }} *{{ .Name }}) Deleted() bool {
-	return {{ $short }}._deleted
-}
-
-// Insert inserts the {{ .Name }} to the database.
-func ({{ $short }} *{{ .Name }}) Insert(db XODB) error {
+// Insert{{ .Name }} inserts the {{ .Name }} to the database.
+func (s *{{ $dname }}) Insert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
 	var err error
 
 	// if already exist, bail
@@ -36,8 +16,12 @@ func ({{ $short }} *{{ .Name }}) Insert(db XODB) error {
 		return errors.New("insert failed: already exists")
 	}
 
+	// run the BeforeInsert hook
+	if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+		return err
+	}
 
-{{ if .Table.ManualPk  }}
+{{ if .Table.ManualPk }}
 	// sql insert query, primary key must be provided
 	const sqlstr = `INSERT INTO {{ $table }} (` +
 		`{{ colnames .Fields }}` +
@@ -47,13 +31,10 @@ func ({{ $short }} *{{ .Name }}) Insert(db XODB) error {
 
 	// run query
 	s.info(sqlstr, {{ fieldnames .Fields $short }})
-	_, err = db.Exec(sqlstr, {{ fieldnames .Fields $short }})
+	_, err = {{ dbcall "Exec" }}sqlstr, {{ fieldnames .Fields $short }})
 	if err != nil {
-		return err
+		return s.translateError(`{{ $.Table.TableName }}`, err)
 	}
-
-	// set existence
-	{{ $short }}._exists = true
 {{ else }}
 	// sql insert query, primary key provided by autoincrement
 	const sqlstr = `INSERT INTO {{ $table }} (` +
@@ -64,9 +45,9 @@ func ({{ $short }} *{{ .Name }}) Insert(db XODB) error {
 
 	// run query
 	s.info(sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }})
-	res, err := db.Exec(sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }})
+	res, err := {{ dbcall "Exec" }}sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }})
 	if err != nil {
-		return err
+		return s.translateError(`{{ $.Table.TableName }}`, err)
 	}
 
 	// retrieve id
@@ -75,17 +56,270 @@ func ({{ $short }} *{{ .Name }}) Insert(db XODB) error {
 		return err
 	}
 
-	// set primary key and existence
+	// set primary key
 	{{ $short }}.{{ .PrimaryKey.Name }} = {{ .PrimaryKey.Type }}(id)
-	{{ $short }}._exists = true
 {{ end }}
 
+	// set existence
+	{{ $short }}._exists = true
+
+	return xoAfterInsert({{ dbarg }}, {{ $short }})
+}
+
+// Insert{{ .Name }}ByFields inserts the {{ .Name }} to the database.
+func (s *{{ $dname }}) Insert{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+	// run the BeforeInsert hook
+	if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+		return err
+	}
+
+	var err error
+
+    {{ $length := minus (len .Fields) 1 }}
+    {{ $sn := shortname .Name }}
+    params := make([]interface{}, 0, {{ $length }})
+    fields := make([]string, 0, {{ $length }})
+    retCols := make([]string, 0, {{ $length }})
+    retVars := make([]interface{}, 0, {{ $length }})
+
+	{{- range $index, $field := .Fields -}}
+	    {{ if (not (and $field.Col.IsPrimaryKey (not $.Table.ManualPk))) -}}
+		    {{ if (or $field.Col.NotNull (not (hasvalid $field.Type))) }}
+                fields = append(fields, `{{ (colname $field.Col) }}`)
+                params = append(params, {{ $sn }}.{{ $field.Name }})
+		    {{ else }}
+                if {{ $sn }}.{{ $field.Name }}.Valid {
+                    fields = append(fields, `{{ (colname $field.Col) }}`)
+                    params = append(params, {{ $sn }}.{{ $field.Name }})
+                } else {
+                    retCols = append(retCols, `{{ (colname $field.Col) }}`)
+                    retVars = append(retVars, &{{ $sn }}.{{ $field.Name }})
+                }
+            {{ end -}}
+        {{ end -}}
+    {{ end -}}
+
+    if len(params) == 0 {
+        // FIXME(jackie): maybe we should allow this?
+        return errors.New("all fields are empty, unable to insert")
+    }
+
+    placeHolders := make([]string, len(params))
+    for i := range params {
+        placeHolders[i] = "{{ mask }}"
+    }
+
+    sqlstr := `INSERT INTO {{ $table }} (` +
+               strings.Join(fields, ",") +
+               `) VALUES (` + strings.Join(placeHolders, ",") +
+               `)`
+
+	// run query
+    s.info(sqlstr, params)
+    {{ if .Table.ManualPk -}}
+    _, err = {{ dbcall "Exec" }}sqlstr, params...)
+    if err != nil {
+        return s.translateError(`{{ $.Table.TableName }}`, err)
+    }
+    {{- else -}}
+    res, err := {{ dbcall "Exec" }}sqlstr, params...)
+    if err != nil {
+        return s.translateError(`{{ $.Table.TableName }}`, err)
+    }
+
+	// retrieve id
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	{{ $short }}.{{ .PrimaryKey.Name }} = {{ .PrimaryKey.Type }}(id)
+    {{- end }}
+
+    // {{ driver }} has no RETURNING clause, read back the columns filled by the database
+    if len(retCols) > 0 {
+        sqlstr = `SELECT ` + strings.Join(retCols, ", ") + ` FROM {{ $table }} WHERE {{ colname .PrimaryKey.Col }} = {{ colnumval 1 }}`
+        s.info(sqlstr, {{ $short }}.{{ .PrimaryKey.Name }})
+        err = {{ dbcall "QueryRow" }}sqlstr, {{ $short }}.{{ .PrimaryKey.Name }}).Scan(retVars...)
+        if err != nil {
+            return s.translateError(`{{ $.Table.TableName }}`, err)
+        }
+    }
+
+	// set existence
+	{{ $short }}._exists = true
+
+	return xoAfterInsert({{ dbarg }}, {{ $short }})
+}
+
+{{- $ignore := .PrimaryKey.Name }}
+{{- if .Table.ManualPk }}{{ $ignore = "" }}{{ end }}
+{{- $ncols := len .Fields }}
+{{- if not .Table.ManualPk }}{{ $ncols = minus $ncols 1 }}{{ end }}
+{{- $maxparams := 65535 }}
+{{- if eq (driver) "sqlite3" }}{{ $maxparams = 999 }}{{ end }}
+{{ if eq $ncols 0 -}}
+// Insert{{ .Name }}s inserts the {{ .Name }}s to the database, one by one as
+// they have no values to insert.
+func (s *{{ $dname }}) Insert{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error {
+	for _, {{ $short }} := range {{ $short }}s {
+		if err := s.Insert{{ .Name }}({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+{{- else if and (not .Table.ManualPk) (ne (driver) "sqlite3") -}}
+// Insert{{ .Name }}s inserts the {{ .Name }}s to the database, one statement
+// per row: mysql does not guarantee consecutive autoincrement ids to the rows
+// of a multi-row INSERT, with an auto_increment_increment other than 1 or
+// interleaved autoincrement locks.
+// The primary keys of the {{ .Name }}s are set from the database.
+func (s *{{ $dname }}) Insert{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error {
+	// if already exist, bail
+	for _, {{ $short }} := range {{ $short }}s {
+		if {{ $short }}._exists {
+			return errors.New("insert failed: already exists")
+		}
+	}
+
+	// run the BeforeInsert hooks
+	for _, {{ $short }} := range {{ $short }}s {
+		if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+	}
+
+	// sql insert query, primary key provided by autoincrement
+	const sqlstr = `INSERT INTO {{ $table }} (` +
+		`{{ colnames .Fields .PrimaryKey.Name }}` +
+		`) VALUES (` +
+		`{{ colvals .Fields .PrimaryKey.Name }}` +
+		`)`
+
+	for _, {{ $short }} := range {{ $short }}s {
+		// run query
+		s.info(sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }})
+		res, err := {{ dbcall "Exec" }}sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }})
+		if err != nil {
+			return s.translateError(`{{ $.Table.TableName }}`, err)
+		}
+
+		// retrieve id
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		// set primary key and existence
+		{{ $short }}.{{ .PrimaryKey.Name }} = {{ .PrimaryKey.Type }}(id)
+		{{ $short }}._exists = true
+
+		// run the AfterInsert hook
+		if err := xoAfterInsert({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+{{- else -}}
+// Insert{{ .Name }}s inserts the {{ .Name }}s to the database, in multi-row
+// INSERT statements of at most {{ $maxparams }} parameters.
+{{- if not .Table.ManualPk }}
+// The primary keys of the {{ .Name }}s are set from the database.
+{{- end }}
+func (s *{{ $dname }}) Insert{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error {
+	// if already exist, bail
+	for _, {{ $short }} := range {{ $short }}s {
+		if {{ $short }}._exists {
+			return errors.New("insert failed: already exists")
+		}
+	}
+
+	// run the BeforeInsert hooks
+	for _, {{ $short }} := range {{ $short }}s {
+		if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+	}
+
+	// rows per statement, within the parameter limit
+	const n = {{ $maxparams }} / {{ $ncols }}
+
+	for len({{ $short }}s) > 0 {
+		batch := {{ $short }}s
+		if len(batch) > n {
+			batch = batch[:n]
+		}
+		{{ $short }}s = {{ $short }}s[len(batch):]
+
+		args := make([]interface{}, 0, len(batch)*{{ $ncols }})
+		for _, {{ $short }} := range batch {
+			args = append(args, {{ fieldnames .Fields $short $ignore }})
+		}
+
+{{- if .Table.ManualPk }}
+
+		// sql insert query, primary keys must be provided
+		sqlstr := `INSERT INTO {{ $table }} (` +
+			`{{ colnames .Fields }}` +
+			`) VALUES ` + xoValues("{{ mask }}", len(batch), {{ $ncols }})
+
+		// run query
+		s.info(sqlstr, args)
+		_, err := {{ dbcall "Exec" }}sqlstr, args...)
+		if err != nil {
+			return s.translateError(`{{ $.Table.TableName }}`, err)
+		}
+{{- else }}
+
+		// sql insert query, primary keys provided by autoincrement
+		sqlstr := `INSERT INTO {{ $table }} (` +
+			`{{ colnames .Fields .PrimaryKey.Name }}` +
+			`) VALUES ` + xoValues("{{ mask }}", len(batch), {{ $ncols }})
+
+		// run query
+		s.info(sqlstr, args)
+		res, err := {{ dbcall "Exec" }}sqlstr, args...)
+		if err != nil {
+			return s.translateError(`{{ $.Table.TableName }}`, err)
+		}
+
+		// retrieve id
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+
+		// set primary keys, sqlite3 returns the id of the last row and the
+		// rows of a statement have consecutive ids
+		id -= int64(len(batch) - 1)
+		for i := range batch {
+			batch[i].{{ .PrimaryKey.Name }} = {{ .PrimaryKey.Type }}(id + int64(i))
+		}
+{{- end }}
+
+		// set existence
+		for _, {{ $short }} := range batch {
+			{{ $short }}._exists = true
+		}
+
+		// run the AfterInsert hooks
+		for _, {{ $short }} := range batch {
+			if err := xoAfterInsert({{ dbarg }}, {{ $short }}); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
+{{- end }}
 
 {{ if ne (fieldnamesmulti .Fields $short .PrimaryKeyFields) "" }}
-	// Update updates the {{ .Name }} in the database.
-	func ({{ $short }} *{{ .Name }}) Update(db XODB) error {
+	// Update{{ .Name }} updates the {{ .Name }} in the database.
+	func (s *{{ $dname }}) Update{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
 		var err error
 
 		// if doesn't exist, bail
@@ -98,6 +332,11 @@ func ({{ $short }} *{{ .Name }}) Insert(db XODB) error {
 			return errors.New("update failed: marked for deletion")
 		}
 
+		// run the BeforeUpdate hook
+		if err := xoBeforeUpdate({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+
 		{{ if gt ( len .PrimaryKeyFields ) 1 }}
 			// sql query with composite primary key
 			const sqlstr = `UPDATE {{ $table }} SET ` +
@@ -106,35 +345,257 @@ func ({{ $short }} *{{ .Name }}) Insert(db XODB) error {
 
 			// run query
 			s.info(sqlstr, {{ fieldnamesmulti .Fields $short .PrimaryKeyFields }}, {{ fieldnames .PrimaryKeyFields $short}})
-			_, err = db.Exec(sqlstr, {{ fieldnamesmulti .Fields $short .PrimaryKeyFields }}, {{ fieldnames .PrimaryKeyFields $short}})
-			return err
+			_, err = {{ dbcall "Exec" }}sqlstr, {{ fieldnamesmulti .Fields $short .PrimaryKeyFields }}, {{ fieldnames .PrimaryKeyFields $short}})
+			if err != nil {
+				return s.translateError(`{{ $.Table.TableName }}`, err)
+			}
+
+			// run the AfterUpdate hook
+			return xoAfterUpdate({{ dbarg }}, {{ $short }})
 		{{- else }}
 			// sql query
 			const sqlstr = `UPDATE {{ $table }} SET ` +
 				`{{ colnamesquery .Fields ", " .PrimaryKey.Name }}` +
-				` WHERE {{ colname .PrimaryKey.Col }} = ?`
+				` WHERE {{ colname .PrimaryKey.Col }} = {{ collastvals .Fields .PrimaryKey.Name }}`
 
 			// run query
 			s.info(sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }}, {{ $short }}.{{ .PrimaryKey.Name }})
-			_, err = db.Exec(sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }}, {{ $short }}.{{ .PrimaryKey.Name }})
-			return err
+			_, err = {{ dbcall "Exec" }}sqlstr, {{ fieldnames .Fields $short .PrimaryKey.Name }}, {{ $short }}.{{ .PrimaryKey.Name }})
+			if err != nil {
+				return s.translateError(`{{ $.Table.TableName }}`, err)
+			}
+
+			// run the AfterUpdate hook
+			return xoAfterUpdate({{ dbarg }}, {{ $short }})
 		{{- end }}
 	}
 
-	// Save saves the {{ .Name }} to the database.
-	func ({{ $short }} *{{ .Name }}) Save(db XODB) error {
+	// Update{{ .Name }}ByFields updates the {{ .Name }} in the database.
+	func (s *{{ $dname }}) Update{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}, fields, retCols []string, params, retVars []interface{}) error {
+		// run the BeforeUpdate hook, saving the columns it changes
+		fields, retCols, params, retVars, err := xoBeforeUpdateFields({{ dbarg }}, {{ $short }}, fields, retCols, params, retVars)
+		if err != nil {
+			return err
+		}
+
+        var setstr string
+        for i, field := range fields {
+            if i != 0 {
+                setstr += ", "
+            }
+            setstr += field + ` = {{ mask }}`
+        }
+
+        params = append(params, {{ $short }}.{{ .PrimaryKey.Name }})
+        var sqlstr = `UPDATE {{ $table }} SET ` +
+            setstr + ` WHERE {{ colname .PrimaryKey.Col }} = {{ mask }}`
+        s.info(sqlstr, params)
+        if _, err := {{ dbcall "Exec" }}sqlstr, params...); err != nil {
+            return s.translateError(`{{ $.Table.TableName }}`, err)
+        }
+
+        // {{ driver }} has no RETURNING clause, read back the remaining columns,
+        // the primary key is always selected so a missing row reports sql.ErrNoRows
+        sqlstr = `SELECT ` + strings.Join(append([]string{`{{ colname .PrimaryKey.Col }}`}, retCols...), ", ") +
+            ` FROM {{ $table }} WHERE {{ colname .PrimaryKey.Col }} = {{ colnumval 1 }}`
+        s.info(sqlstr, {{ $short }}.{{ .PrimaryKey.Name }})
+        err = {{ dbcall "QueryRow" }}sqlstr, {{ $short }}.{{ .PrimaryKey.Name }}).Scan(append([]interface{}{&{{ $short }}.{{ .PrimaryKey.Name }}}, retVars...)...)
+        if err != nil {
+            return s.translateError(`{{ $.Table.TableName }}`, err)
+        }
+
+        return xoAfterUpdate({{ dbarg }}, {{ $short }})
+	}
+
+	// Save{{ .Name }} saves the {{ .Name }} to the database.
+	func (s *{{ $dname }}) Save{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
 		if {{ $short }}.Exists() {
-			return {{ $short }}.Update(db)
+			return s.Update{{ .Name }}({{ dbarg }}, {{ $short }})
+		}
+
+		return s.Insert{{ .Name }}({{ dbarg }}, {{ $short }})
+	}
+
+	// Upsert{{ .Name }} performs an upsert for {{ .Name }}.
+	func (s *{{ $dname }}) Upsert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+		var err error
+
+		// run the BeforeInsert hook, an upsert writes the row as it is inserted
+		if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+
+		// sql query
+	{{- if eq (driver) "sqlite3" }}
+		const sqlstr = `INSERT INTO {{ $table }} (` +
+			`{{ colnames .Fields }}` +
+			`) VALUES (` +
+			`{{ colvals .Fields }}` +
+			`) ON CONFLICT ({{ colnames .PrimaryKeyFields }}) DO UPDATE SET ` +
+			`{{ colprefixnamesquery .Fields "" "excluded" ", " .PrimaryKey.Name }}`
+	{{- else }}
+		const sqlstr = `INSERT INTO {{ $table }} (` +
+			`{{ colnames .Fields }}` +
+			`) VALUES (` +
+			`{{ colvals .Fields }}` +
+			`) ON DUPLICATE KEY UPDATE ` +
+			`{{ colvaluesquery .Fields ", " .PrimaryKey.Name }}`
+	{{- end }}
+
+		// run query
+		s.info(sqlstr, {{ fieldnames .Fields $short }})
+		_, err = {{ dbcall "Exec" }}sqlstr, {{ fieldnames .Fields $short }})
+		if err != nil {
+			return s.translateError(`{{ $.Table.TableName }}`, err)
 		}
 
-		return {{ $short }}.Insert(db)
+		// set existence
+		{{ $short }}._exists = true
+
+		return xoAfterInsert({{ dbarg }}, {{ $short }})
 	}
 {{ else }}
 	// Update statements omitted due to lack of fields other than primary key
 {{ end }}
 
-// Delete deletes the {{ .Name }} from the database.
-func ({{ $short }} *{{ .Name }}) Delete(db XODB) error {
+{{- range $ix := .Indexes }}
+{{- if and $ix.Index.IsUnique (not $ix.Index.IsPrimary) }}
+{{- $insignore := $.PrimaryKey.Name }}
+{{- if $.Table.ManualPk }}{{ $insignore = "" }}{{ end }}
+{{- $nullable := false }}
+{{- range $ix.Fields }}{{ if not .Col.NotNull }}{{ $nullable = true }}{{ end }}{{ end }}
+
+// Upsert{{ $ix.FuncName }} performs an upsert for {{ $.Name }} on the unique
+// index '{{ $ix.Index.IndexName }}': it inserts the {{ $.Name }}, or when a row
+// with the same {{ colnames $ix.Fields }} exists, overwrites its columns chosen by opts.
+{{- if ne (driver) "sqlite3" }}
+// mysql overwrites the row on a conflict of any of the unique keys.
+{{- end }}
+func (s *{{ $dname }}) Upsert{{ $ix.FuncName }}({{ dbparam }}, {{ $short }} *{{ $.Name }}, opts *UpsertOptions) error {
+	var err error
+
+	err = opts.check({{ range $i, $f := $.Fields }}{{ if $i }}, {{ end }}"{{ $f.Col.ColumnName }}"{{ end }})
+	if err != nil {
+		return err
+	}
+
+	// run the BeforeInsert hook, an upsert writes the row as it is inserted
+	if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+		return err
+	}
+
+	// columns overwritten when the row exists
+	var set []string
+	{{- range $f := $.Fields }}
+		{{- $skip := $f.Col.IsPrimaryKey }}
+		{{- range $ix.Fields }}{{ if eq .Name $f.Name }}{{ $skip = true }}{{ end }}{{ end }}
+		{{- if not $skip }}
+	if opts.overwrites("{{ $f.Col.ColumnName }}") {
+		set = append(set, `{{ colname $f.Col }} = {{ if eq (driver) "sqlite3" }}excluded.{{ colname $f.Col }}{{ else }}VALUES({{ colname $f.Col }}){{ end }}`)
+	}
+		{{- end }}
+	{{- end }}
+
+{{- if eq (driver) "sqlite3" }}
+
+	// sql query
+	sqlstr := `INSERT INTO {{ $table }} (` +
+		`{{ colnames $.Fields $insignore }}` +
+		`) VALUES (` +
+		`{{ colvals $.Fields $insignore }}` +
+		`) ON CONFLICT ({{ colnames $ix.Fields }}) `
+	if len(set) == 0 {
+		sqlstr += `DO NOTHING`
+	} else {
+		sqlstr += `DO UPDATE SET ` + strings.Join(set, ", ")
+	}
+{{- else }}
+{{- if $.Table.ManualPk }}
+
+	// leave the existing row unchanged when no column is overwritten
+	if len(set) == 0 {
+		set = append(set, `{{ colname (index $ix.Fields 0).Col }} = {{ colname (index $ix.Fields 0).Col }}`)
+	}
+{{- else }}
+
+	// return the primary key of the existing row as the insert id, the row
+	// is left unchanged when no column is overwritten
+	set = append(set, `{{ colname $.PrimaryKey.Col }} = LAST_INSERT_ID({{ colname $.PrimaryKey.Col }})`)
+{{- end }}
+
+	// sql query
+	sqlstr := `INSERT INTO {{ $table }} (` +
+		`{{ colnames $.Fields $insignore }}` +
+		`) VALUES (` +
+		`{{ colvals $.Fields $insignore }}` +
+		`) ON DUPLICATE KEY UPDATE ` + strings.Join(set, ", ")
+{{- end }}
+
+	// run query
+	s.info(sqlstr, {{ fieldnames $.Fields $short $insignore }})
+{{- if or $.Table.ManualPk (and (eq (driver) "sqlite3") (not $nullable)) }}
+	_, err = {{ dbcall "Exec" }}sqlstr, {{ fieldnames $.Fields $short $insignore }})
+{{- else }}
+	res, err := {{ dbcall "Exec" }}sqlstr, {{ fieldnames $.Fields $short $insignore }})
+{{- end }}
+	if err != nil {
+		return s.translateError(`{{ $.Table.TableName }}`, err)
+	}
+{{- if $.Table.ManualPk }}
+{{- else if ne (driver) "sqlite3" }}
+
+	// retrieve the primary key of the inserted or existing row
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	{{ $short }}.{{ $.PrimaryKey.Name }} = {{ $.PrimaryKey.Type }}(id)
+{{- else if $nullable }}
+
+	// retrieve the primary key of the inserted or existing row, the row is
+	// inserted when a column of the index is NULL as NULLs never conflict
+	if xoIsNull({{ fieldnames $ix.Fields $short }}) {
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		{{ $short }}.{{ $.PrimaryKey.Name }} = {{ $.PrimaryKey.Type }}(id)
+	} else {
+		const pksqlstr = `SELECT {{ colname $.PrimaryKey.Col }} FROM {{ $table }} ` +
+			`WHERE {{ colnamesquery $ix.Fields " AND " }}`
+
+		s.info(pksqlstr, {{ fieldnames $ix.Fields $short }})
+		err = {{ dbcall "QueryRow" }}pksqlstr, {{ fieldnames $ix.Fields $short }}).Scan(&{{ $short }}.{{ $.PrimaryKey.Name }})
+		if err != nil {
+			return s.translateError(`{{ $.Table.TableName }}`, err)
+		}
+	}
+{{- else }}
+
+	// retrieve the primary key of the inserted or existing row
+	const pksqlstr = `SELECT {{ colname $.PrimaryKey.Col }} FROM {{ $table }} ` +
+		`WHERE {{ colnamesquery $ix.Fields " AND " }}`
+
+	s.info(pksqlstr, {{ fieldnames $ix.Fields $short }})
+	err = {{ dbcall "QueryRow" }}pksqlstr, {{ fieldnames $ix.Fields $short }}).Scan(&{{ $short }}.{{ $.PrimaryKey.Name }})
+	if err != nil {
+		return s.translateError(`{{ $.Table.TableName }}`, err)
+	}
+{{- end }}
+
+	// set existence
+	{{ $short }}._exists = true
+
+	return xoAfterInsert({{ dbarg }}, {{ $short }})
+}
+{{- end }}
+{{- end }}
+
+// Delete{{ .Name }} deletes the {{ .Name }} from the database.
+{{- if $softdelete }}
+// The rows are soft deleted, setting their {{ $deleted }} column.
+{{- end }}
+func (s *{{ $dname }}) Delete{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
 	var err error
 
 	// if doesn't exist, bail
@@ -147,32 +608,532 @@ func ({{ $short }} *{{ .Name }}) Delete(db XODB) error {
 		return nil
 	}
 
+	// run the BeforeDelete hook
+	if err := xoBeforeDelete({{ dbarg }}, {{ $short }}); err != nil {
+		return err
+	}
+
 	{{ if gt ( len .PrimaryKeyFields ) 1 }}
 		// sql query with composite primary key
-		const sqlstr = `DELETE FROM {{ $table }} WHERE {{ colnamesquery .PrimaryKeyFields " AND " }}`
+		const sqlstr = `{{ if $softdelete }}UPDATE {{ $table }} SET {{ parsecolname $deleted }} = CURRENT_TIMESTAMP{{ else }}DELETE FROM {{ $table }}{{ end }} WHERE {{ colnamesquery .PrimaryKeyFields " AND " }}`
 
 		// run query
 		s.info(sqlstr, {{ fieldnames .PrimaryKeyFields $short }})
-		_, err = db.Exec(sqlstr, {{ fieldnames .PrimaryKeyFields $short }})
+		_, err = {{ dbcall "Exec" }}sqlstr, {{ fieldnames .PrimaryKeyFields $short }})
 		if err != nil {
-			return err
+			return s.translateError(`{{ $.Table.TableName }}`, err)
 		}
 	{{- else }}
 		// sql query
-		const sqlstr = `DELETE FROM {{ $table }} WHERE {{ colname .PrimaryKey.Col }} = ?`
+		const sqlstr = `{{ if $softdelete }}UPDATE {{ $table }} SET {{ parsecolname $deleted }} = CURRENT_TIMESTAMP{{ else }}DELETE FROM {{ $table }}{{ end }} WHERE {{ colname .PrimaryKey.Col }} = {{ colnumval 1 }}`
 
 		// run query
 		s.info(sqlstr, {{ $short }}.{{ .PrimaryKey.Name }})
-		_, err = db.Exec(sqlstr, {{ $short }}.{{ .PrimaryKey.Name }})
+		_, err = {{ dbcall "Exec" }}sqlstr, {{ $short }}.{{ .PrimaryKey.Name }})
 		if err != nil {
-			return err
+			return s.translateError(`{{ $.Table.TableName }}`, err)
 		}
 	{{- end }}
 
 	// set deleted
 	{{ $short }}._deleted = true
 
+	return xoAfterDelete({{ dbarg }}, {{ $short }})
+}
+
+// Delete{{ .Name }}s deletes the {{ .Name }} from the database.
+{{- if $softdelete }}
+// The rows are soft deleted, setting their {{ $deleted }} column.
+{{- end }}
+func (s *{{ $dname }}) Delete{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error {
+	var err error
+
+	if len({{ $short }}s) == 0 {
+		return nil
+	}
+
+	// run the BeforeDelete hooks
+	for _, {{ $short }} := range {{ $short }}s {
+		if err := xoBeforeDelete({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+	}
+
+	{{ if gt ( len .PrimaryKeyFields ) 1 }}
+        var args []interface{}
+        var where string
+        for i := range {{ $short }}s {
+            if i != 0 {
+                where += " OR "
+            }
+            where += `({{ colnamesquery .PrimaryKeyFields " AND " }})`
+            args = append(args, {{ fieldnames .PrimaryKeyFields (print $short "s[i]") }})
+        }
+
+		// sql query with composite primary key
+		var sqlstr = `{{ if $softdelete }}UPDATE {{ $table }} SET {{ parsecolname $deleted }} = CURRENT_TIMESTAMP{{ else }}DELETE FROM {{ $table }}{{ end }} WHERE ` + where
+
+		// run query
+		s.info(sqlstr, args)
+		_, err = {{ dbcall "Exec" }}sqlstr, args...)
+		if err != nil {
+			return s.translateError(`{{ $.Table.TableName }}`, err)
+		}
+	{{- else }}
+        args := make([]interface{}, len({{ $short }}s))
+        placeHolders := make([]string, len({{ $short }}s))
+        for i := range {{ $short }}s {
+            args[i] = {{ $short }}s[i].{{ .PrimaryKey.Name }}
+            placeHolders[i] = "{{ mask }}"
+        }
+
+        // sql query
+        var sqlstr = `{{ if $softdelete }}UPDATE {{ $table }} SET {{ parsecolname $deleted }} = CURRENT_TIMESTAMP{{ else }}DELETE FROM {{ $table }}{{ end }} WHERE {{ colname .PrimaryKey.Col }} in (` + strings.Join(placeHolders, ", ") + `)`
+
+        // run query
+        s.info(sqlstr, args)
+        _, err = {{ dbcall "Exec" }}sqlstr, args...)
+        if err != nil {
+            return s.translateError(`{{ $.Table.TableName }}`, err)
+        }
+	{{- end }}
+
+	// set deleted
+	for _, {{ $short }} := range {{ $short }}s {
+	    {{ $short }}._deleted = true
+	}
+
+	// run the AfterDelete hooks
+	for _, {{ $short }} := range {{ $short }}s {
+		if err := xoAfterDelete({{ dbarg }}, {{ $short }}); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 {{- end }}
 
+{{ if $created }}
+// GetMostRecent{{ .Name }} returns n most recent rows from '{{ .Table.TableName }}',
+// ordered by "{{ $created }}" in descending order.
+func (s *{{ $dname }}) GetMostRecent{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error) {
+	const sqlstr = `SELECT ` +
+		`{{ colnames .Fields }} ` +
+		`FROM {{ $table }} ` +
+		`ORDER BY {{ parsecolname $created }} DESC LIMIT {{ colnumval 1 }}`
+
+	s.info(sqlstr, n)
+	q, err := {{ dbcall "Query" }}sqlstr, n)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	var res []*{{ .Name }}
+	for q.Next() {
+		{{ $short }} := {{ .Name }}{}
+
+		// scan
+		err = q.Scan({{ fieldnames .Fields (print "&" $short) }})
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &{{ $short }})
+	}
+
+	return res, nil
+}
+{{ end }}
+
+{{ if $changed }}
+// GetMostRecentChanged{{ .Name }} returns n most recent rows from '{{ .Table.TableName }}',
+// ordered by "{{ $changed }}" in descending order.
+func (s *{{ $dname }}) GetMostRecentChanged{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error) {
+	const sqlstr = `SELECT ` +
+		`{{ colnames .Fields }} ` +
+		`FROM {{ $table }} ` +
+		`ORDER BY {{ parsecolname $changed }} DESC LIMIT {{ colnumval 1 }}`
+
+	s.info(sqlstr, n)
+	q, err := {{ dbcall "Query" }}sqlstr, n)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	var res []*{{ .Name }}
+	for q.Next() {
+		{{ $short }} := {{ .Name }}{}
+
+		// scan
+		err = q.Scan({{ fieldnames .Fields (print "&" $short) }})
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &{{ $short }})
+	}
+
+	return res, nil
+}
+{{ end }}
+
+// GetAll{{ .Name }} returns all rows from '{{ .Table.TableName }}', based on the {{ .Name }}QueryArguments.
+// If the {{ .Name }}QueryArguments is nil, it will use the default {{ .Name }}QueryArguments instead.
+func (s *{{ $dname }}) GetAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) ([]*{{ .Name }}, error) { // nolint: gocyclo
+	queryArgs = Apply{{ .Name }}QueryArgsDefaults(queryArgs)
+{{- if (existsqlfilter .) }}
+	if queryArgs.filterArgs == nil{
+		filterArgs, err := get{{ .Name }}Filter(queryArgs.Where)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to get {{ .Name }} filter")
+		}
+		queryArgs.filterArgs = filterArgs
+	}
+{{- end }}
+
+{{ if $deleted }}
+	dead := "NULL"
+	if *queryArgs.Dead {
+		dead = "NOT NULL"
+	}
+{{- end }}
+
+	order, err := get{{ .Name }}Order(queryArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	var params []interface{}
+	placeHolders := ""
+{{- if (existsqlfilter .) }}
+	if queryArgs.filterArgs != nil{
+		placeHolders = fmt.Sprintf("%s AND", queryArgs.filterArgs.sql(func(v interface{}) string {
+			params = append(params, v)
+			return "{{ mask }}"
+		}, s.filterOption))
+	}
+{{- end }}
+	offset, limit := *queryArgs.Offset, *queryArgs.Limit
+	keyset, reverse := queryArgs.isKeyset(), false
+	if keyset {
+		pls, n, rev, err := queryArgs.keyset("{{ .Name }}", `{{ $table }}`, order, `{{ colname $.PrimaryKey.Col }}`, func(v interface{}) string {
+			params = append(params, v)
+			return "{{ mask }}"
+		})
+		if err != nil {
+			return nil, err
+		}
+		placeHolders = fmt.Sprintf("%s %s", placeHolders, pls)
+		offset, limit, reverse = 0, n, rev
+	}
+
+
+	params = append(params, limit)
+	params = append(params, offset)
+
+	var sqlstr = fmt.Sprintf(`SELECT %s FROM %s WHERE %s {{ if $deleted }}{{ parsecolname $deleted }} IS %s{{ else }}1 = 1{{ end }} ORDER BY %s LIMIT {{ mask }} OFFSET {{ mask }}`,
+		`{{ colnames .Fields }} `,
+		`{{ $table }}`,
+		placeHolders,
+{{- if $deleted }}
+		dead,
+{{- end }}
+		orderSQL(order, reverse))
+	s.info(sqlstr, params)
+
+	q, err := {{ dbcall "Query" }}sqlstr, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	var res []*{{ .Name }}
+	for q.Next() {
+		{{ $short }} := {{ .Name }}{}
+
+		// scan
+		err = q.Scan({{ fieldnames .Fields (print "&" $short) }})
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &{{ $short }})
+	}
+
+	if keyset {
+		more := len(res) == int(limit)
+		if more {
+			res = res[:len(res)-1]
+		}
+		if reverse {
+			for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+				res[i], res[j] = res[j], res[i]
+			}
+		}
+		queryArgs.setPageInfo(more, reverse)
+	}
+
+	return res, nil
+}
+
+// CountAll{{ .Name }} returns a count of all rows from '{{ .Table.TableName }}'
+func (s *{{ $dname }}) CountAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) (int, error) {
+	queryArgs = Apply{{ .Name }}QueryArgsDefaults(queryArgs)
+{{- if (existsqlfilter .) }}
+	if queryArgs.filterArgs == nil{
+		filterArgs, err := get{{ .Name }}Filter(queryArgs.Where)
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to get {{ .Name }} filter")
+		}
+		queryArgs.filterArgs = filterArgs
+	}
+{{- end }}
+
+{{ if $deleted }}
+	dead := "NULL"
+	if *queryArgs.Dead {
+		dead = "NOT NULL"
+	}
+{{- end }}
+
+	var params []interface{}
+	placeHolders := ""
+{{- if (existsqlfilter .) }}
+	if queryArgs.filterArgs != nil{
+		placeHolders = fmt.Sprintf("%s AND", queryArgs.filterArgs.sql(func(v interface{}) string {
+			params = append(params, v)
+			return "{{ mask }}"
+		}, s.filterOption))
+	}
+{{- end }}
+
+	var err error
+	var sqlstr = fmt.Sprintf(`SELECT count(*) from {{ $table }} WHERE %s {{ if $deleted }}{{ parsecolname $deleted }} IS %s{{ else }}1 = 1{{ end }}`, placeHolders{{ if $deleted }}, dead{{ end }})
+	s.info(sqlstr, params)
+
+	var count int
+	err = {{ dbcall "QueryRow" }}sqlstr, params...).Scan(&count)
+	if err != nil {
+		return -1, err
+	}
+	return count, nil
+}
+
+// Aggregate{{ .Name }} returns the aggregates of the rows from '{{ .Table.TableName }}' grouped by the groupBy columns,
+// based on the {{ .Name }}QueryArguments.
+func (s *{{ $dname }}) Aggregate{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments, groupBy []{{ .Name }}OrderField) ([]*{{ .Name }}Aggregate, error) {
+	queryArgs = Apply{{ .Name }}QueryArgsDefaults(queryArgs)
+{{- if (existsqlfilter .) }}
+	if queryArgs.filterArgs == nil{
+		filterArgs, err := get{{ .Name }}Filter(queryArgs.Where)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to get {{ .Name }} filter")
+		}
+		queryArgs.filterArgs = filterArgs
+	}
+{{- end }}
+	columns, dests, err := get{{ .Name }}GroupBy(groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+{{ if $deleted }}
+	dead := "NULL"
+	if *queryArgs.Dead {
+		dead = "NOT NULL"
+	}
+{{- end }}
+
+	var params []interface{}
+	placeHolders := ""
+{{- if (existsqlfilter .) }}
+	if queryArgs.filterArgs != nil{
+		placeHolders = fmt.Sprintf("%s AND", queryArgs.filterArgs.sql(func(v interface{}) string {
+			params = append(params, v)
+			return "{{ mask }}"
+		}, s.filterOption))
+	}
+{{- end }}
+
+	selects, groups := "", ""
+	if len(columns) > 0 {
+		selects = strings.Join(columns, ", ") + ", "
+		groups = fmt.Sprintf(" GROUP BY %[1]s ORDER BY %[1]s", strings.Join(columns, ", "))
+	}
+	var sqlstr = fmt.Sprintf(`SELECT %scount(*)
+	{{- range (aggregatefields . "Number") }}, sum({{ colname .Col }}), avg({{ colname .Col }}){{ end }}
+	{{- range (aggregatefields . "Number" "Time") }}, min({{ colname .Col }}), max({{ colname .Col }}){{ end }} from {{ $table }} WHERE %s {{ if $deleted }}{{ parsecolname $deleted }} IS %s{{ else }}1 = 1{{ end }}%s`, selects, placeHolders{{ if $deleted }}, dead{{ end }}, groups)
+	s.info(sqlstr, params)
+
+	q, err := {{ dbcall "Query" }}sqlstr, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	var res []*{{ .Name }}Aggregate
+	for q.Next() {
+		a := &{{ .Name }}Aggregate{Group: &{{ .Name }}{}}
+
+		// scan
+		err = q.Scan(append(dests(a.Group), &a.Count
+		{{- range (aggregatefields . "Number") }}, &a.Sum.{{ .Name }}, &a.Avg.{{ .Name }}{{ end }}
+		{{- range (aggregatefields . "Number" "Time") }}, &a.Min.{{ .Name }}, &a.Max.{{ .Name }}{{ end }})...)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, a)
+	}
+
+	return res, nil
+}
+
+{{ range .ForeignKeys }}
+	{{- $fnname := (print (plural $.Name) "By" .Field.Name "FK") -}}
+	{{- if not (isdup $fnname (driver)) }}
+	// {{ $fnname }} retrieves rows from {{ $table }} by foreign key {{.Field.Name}}.
+	// Generated from foreign key {{.Name}}.
+	func (s *{{ $dname }}) {{ $fnname }}({{ dbparam }}, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $.Name }}QueryArguments) ([]*{{$.Name}}, error) {
+		queryArgs = Apply{{ $.Name }}QueryArgsDefaults(queryArgs)
+		order, err := get{{ $.Name }}Order(queryArgs)
+		if err != nil {
+			return nil, err
+		}
+
+{{ if $deleted }}
+		dead := "NULL"
+		if *queryArgs.Dead {
+			dead = "NOT NULL"
+		}
+{{- end }}
+
+		var params []interface{}
+		placeHolders := ""
+{{- if (existsqlfilter .Type) }}
+		if queryArgs.filterArgs != nil{
+			if queryArgs.filterArgs.hasField("{{ .Field.Col.ColumnName }}"){
+				return nil, fmt.Errorf("already have condition on field:{{ .Field.Name }}, because of foregin key {{ .Name }}")
+			}
+			placeHolders = fmt.Sprintf("%s AND", queryArgs.filterArgs.sql(func(v interface{}) string {
+				params = append(params, v)
+				return "{{ mask }}"
+			}, s.filterOption))
+		}
+{{- end }}
+		params = append(params, {{ togqlname .Field.Name }})
+		placeHolders = fmt.Sprintf(`%s {{ colname .Field.Col }} = {{ mask }} AND `, placeHolders)
+
+		offset, limit := *queryArgs.Offset, *queryArgs.Limit
+		keyset, reverse := queryArgs.isKeyset(), false
+		if keyset {
+			pls, n, rev, err := queryArgs.keyset("{{ $.Name }}", `{{ $table }}`, order, `{{ colname $.PrimaryKey.Col }}`, func(v interface{}) string {
+				params = append(params, v)
+				return "{{ mask }}"
+			})
+			if err != nil {
+				return nil, err
+			}
+			placeHolders = fmt.Sprintf("%s %s", placeHolders, pls)
+			offset, limit, reverse = 0, n, rev
+		}
+
+
+		params = append(params, limit)
+		params = append(params, offset)
+
+		var sqlstr = fmt.Sprintf(
+			`SELECT %s FROM %s WHERE %s {{ if $deleted }}{{ parsecolname $deleted }} IS %s{{ else }}1 = 1{{ end }} ORDER BY %s LIMIT {{ mask }} OFFSET {{ mask }}`,
+			`{{ colnames $.Fields }} `,
+			`{{ $table }}`,
+			placeHolders,
+{{- if $deleted }}
+			dead,
+{{- end }}
+			orderSQL(order, reverse))
+
+	    s.info(sqlstr, params...)
+		q, err := {{ dbcall "Query" }}sqlstr, params...)
+		if err != nil {
+			return nil, err
+		}
+		defer q.Close()
+
+		// load results
+		var res []*{{ $.Name }}
+		for q.Next() {
+			{{ $short }} := {{ $.Name }}{}
+
+			// scan
+			err = q.Scan({{ fieldnames $.Fields (print "&" $short) }})
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, &{{ $short }})
+		}
+
+		if keyset {
+			more := len(res) == int(limit)
+			if more {
+				res = res[:len(res)-1]
+			}
+			if reverse {
+				for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+					res[i], res[j] = res[j], res[i]
+				}
+			}
+			queryArgs.setPageInfo(more, reverse)
+		}
+
+		return res, nil
+	}
+
+	// Count{{ $fnname }} count rows from {{ $table }} by foreign key {{.Field.Name}}.
+	// Generated from foreign key {{.Name}}.
+	func (s *{{ $dname }}) Count{{ $fnname }}({{ dbparam }}, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $.Name }}QueryArguments) (int, error) {
+		queryArgs = Apply{{ $.Name }}QueryArgsDefaults(queryArgs)
+
+{{ if $deleted }}
+		dead := "NULL"
+		if *queryArgs.Dead {
+			dead = "NOT NULL"
+		}
+{{- end }}
+
+		var params []interface{}
+		placeHolders := ""
+{{- if (existsqlfilter .Type) }}
+		if queryArgs.filterArgs != nil{
+			if queryArgs.filterArgs.hasField("{{ .Field.Col.ColumnName }}"){
+				return -1, fmt.Errorf("already have condition on field:{{ .Field.Name }}, because of foregin key {{ .Name }}")
+			}
+			placeHolders = fmt.Sprintf("%s AND", queryArgs.filterArgs.sql(func(v interface{}) string {
+				params = append(params, v)
+				return "{{ mask }}"
+			}, s.filterOption))
+		}
+{{- end }}
+		params = append(params, {{ togqlname .Field.Name }})
+		placeHolders = fmt.Sprintf(`%s {{ colname .Field.Col }} = {{ mask }} AND `, placeHolders)
+
+		var err error
+		var sqlstr = fmt.Sprintf(`SELECT count(*) from {{ $table }} WHERE %s {{ if $deleted }}{{ parsecolname $deleted }} IS %s{{ else }}1 = 1{{ end }}`, placeHolders{{ if $deleted }}, dead{{ end }})
+		s.info(sqlstr, params)
+
+		var count int
+		err = {{ dbcall "QueryRow" }}sqlstr, params...).Scan(&count)
+		if err != nil {
+			return -1, err
+		}
+		return count, nil
+	}
+	{{end}}
+{{ end}}
@@ -60,3 +60,37 @@ func ({{ $short }} *{{ $type }}) Scan(src interface{}) error {
 	return {{ $short }}.UnmarshalText(buf)
 }
 
+// GraphQL returns the GraphQL enum value of the {{ $type }}.
+func ({{ $short }} {{ $type }}) GraphQL() string {
+	switch {{ $short }} {
+{{- range .Values }}
+	case {{ if $reverseNames }}{{ .Name }}{{ $type }}{{ else }}{{ $type }}{{ .Name }}{{ end }}:
+		return "{{ togqlenumvalue .Val.EnumValue }}"
+{{- end }}
+	}
+
+	return ""
+}
+
+// {{ $type }}FromGraphQL returns the {{ $type }} of the GraphQL enum value.
+func {{ $type }}FromGraphQL(value string) {{ $type }} {
+	switch value {
+{{- range .Values }}
+	case "{{ togqlenumvalue .Val.EnumValue }}":
+		return {{ if $reverseNames }}{{ .Name }}{{ $type }}{{ else }}{{ $type }}{{ .Name }}{{ end }}
+{{- end }}
+	}
+
+	return 0
+}
+{{- if (enableextension) }}
+
+// graphQL{{ $type }}Enum is the GraphQL enum of {{ $type }}.
+const graphQL{{ $type }}Enum = `
+    enum {{ $type }} {
+{{- range .Values }}
+        {{ togqlenumvalue .Val.EnumValue }}
+{{- end }}
+    }
+`
+{{- end }}
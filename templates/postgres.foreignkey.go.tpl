@@ -1,9 +1,218 @@
 {{- $short := (shortname .Type.Name) -}}
 {{- $dname := (print (firstletterupper (driver) ) "Storage") -}}
-// {{ .Name }}By{{ .Type.Name }}{{ .RefField.Name }} returns the {{ .RefType.Name }} associated with the {{ .Type.Name }}'s {{ .Field.Name }} ({{ .Field.Col.ColumnName }}).
+// {{ .Name }}In{{ .Type.Name }} returns the {{ .RefType.Name }} associated with the {{ .Type.Name }}'s {{ .Field.Name }} ({{ .Field.Col.ColumnName }}).
 //
 // Generated from foreign key '{{ .ForeignKey.ForeignKeyName }}'.
-func (s *{{ $dname }}) {{ .Name }}By{{ .Type.Name }}{{ .RefField.Name }}(db XODB, {{ $short }} *{{ .Type.Name }}) (*{{ .RefType.Name }}, error) {
-	return s.{{ .RefType.Name }}By{{ .RefField.Name }}(db, {{ convext $short .Field .RefField }})
+func (s *{{ $dname }}) {{ .Name }}In{{ .Type.Name }}({{ dbparam }}, {{ $short }} *{{ .Type.Name }}) (*{{ .RefType.Name }}, error) {
+	return s.{{ .RefType.Name }}By{{ .RefField.Name }}({{ dbarg }}, {{ convext $short .Field .RefField }})
 }
+{{- if (enabledataloader) }}
+{{- $reftable := (schema .RefType.Schema .RefType.Table.TableName) -}}
+{{- $table := (schema .Type.Schema .Type.Table.TableName) -}}
+{{- $refshort := (shortname .RefType.Name "err" "res" "sqlstr" "db" "ctx" "q" "keys" "params" "placeHolders") -}}
+{{- $deleted := (deletedcolumn .Type) -}}
+{{- $rowshort := (shortname .Type.Name "err" "res" "sqlstr" "db" "ctx" "q" "keys" "params" "placeHolders" "param" "key") -}}
+{{- $fnname := (print (plural .Type.Name) "By" .Field.Name "FK") -}}
+{{- if not (isdup (print .RefType.Name "By" .RefField.Name "Batch") (print (driver) "batch")) }}
 
+// {{ .RefType.Name }}By{{ .RefField.Name }}Batch retrieves the rows from '{{ $reftable }}' matching any of keys,
+// it lets the GraphQL resolvers load the {{ .RefType.Name }} of several rows with one query.
+func (s *{{ $dname }}) {{ .RefType.Name }}By{{ .RefField.Name }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}) ([]*{{ .RefType.Name }}, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	params := make([]interface{}, len(keys))
+	placeHolders := make([]string, len(keys))
+	for i, key := range keys {
+		params[i] = key
+{{- if eq (mask) "?" }}
+		placeHolders[i] = "{{ mask }}"
+{{- else }}
+		placeHolders[i] = fmt.Sprintf("{{ mask }}", i+1)
+{{- end }}
+	}
+
+	// sql query
+	var sqlstr = `SELECT ` +
+		`{{ colnames .RefType.Fields }} ` +
+		`FROM {{ $reftable }} ` +
+		`WHERE {{ colname .RefField.Col }} IN (` + strings.Join(placeHolders, ", ") + `)`
+
+	// run query
+	s.info(sqlstr, params)
+	q, err := {{ dbcall "Query" }}sqlstr, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	var res []*{{ .RefType.Name }}
+	for q.Next() {
+		{{ $refshort }} := {{ .RefType.Name }}{
+		{{- if .RefType.PrimaryKey }}
+			_exists: true,
+		{{ end -}}
+		}
+
+		// scan
+		err = q.Scan({{ fieldnames .RefType.Fields (print "&" $refshort) }})
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &{{ $refshort }})
+	}
+
+	return res, nil
+}
+{{- end }}
+{{- if not (isdup (print $fnname "Batch") (print (driver) "batch")) }}
+
+// {{ $fnname }}Batch retrieves the rows from {{ $table }} of several foreign keys {{ .Field.Name }} at once,
+// the offset and limit of queryArgs apply to the rows of each key.
+func (s *{{ $dname }}) {{ $fnname }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}][]*{{ .Type.Name }}, error) {
+	queryArgs = Apply{{ .Type.Name }}QueryArgsDefaults(queryArgs)
+
+	res := make(map[{{ .RefField.Type }}][]*{{ .Type.Name }}, len(keys))
+	if len(keys) == 0 {
+		return res, nil
+	}
+
+	placeHolders, params, err := s.{{ togqlname $fnname }}BatchWhere(keys, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+	param := func(v interface{}) string {
+		params = append(params, v)
+{{- if eq (mask) "?" }}
+		return "{{ mask }}"
+{{- else }}
+		return fmt.Sprintf("{{ mask }}", len(params))
+{{- end }}
+	}
+
+	order, err := get{{ .Type.Name }}Order(queryArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	// rows are numbered within each key to apply the offset and limit
+	var sqlstr = fmt.Sprintf(`SELECT %s FROM (`+
+		`SELECT %s, ROW_NUMBER() OVER (PARTITION BY {{ colname .Field.Col }} ORDER BY %s) xo_rn `+
+		`FROM {{ $table }} WHERE %s`+
+		`) xo_t WHERE xo_rn > %s AND xo_rn <= %s ORDER BY {{ colname .Field.Col }}, xo_rn`,
+		`{{ colnames .Type.Fields }}`,
+		`{{ colnames .Type.Fields }}`,
+		orderSQL(order, false),
+		placeHolders,
+		param(*queryArgs.Offset),
+		param(*queryArgs.Offset+*queryArgs.Limit))
+
+	// run query
+	s.info(sqlstr, params)
+	q, err := {{ dbcall "Query" }}sqlstr, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	for q.Next() {
+		{{ $rowshort }} := {{ .Type.Name }}{
+		{{- if .Type.PrimaryKey }}
+			_exists: true,
+		{{ end -}}
+		}
+
+		// scan
+		err = q.Scan({{ fieldnames .Type.Fields (print "&" $rowshort) }})
+		if err != nil {
+			return nil, err
+		}
+
+		key := {{ convext $rowshort .Field .RefField }}
+		res[key] = append(res[key], &{{ $rowshort }})
+	}
+
+	return res, nil
+}
+
+// Count{{ $fnname }}Batch counts the rows from {{ $table }} of several foreign keys {{ .Field.Name }} at once.
+func (s *{{ $dname }}) Count{{ $fnname }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}]int, error) {
+	queryArgs = Apply{{ .Type.Name }}QueryArgsDefaults(queryArgs)
+
+	res := make(map[{{ .RefField.Type }}]int, len(keys))
+	if len(keys) == 0 {
+		return res, nil
+	}
+
+	placeHolders, params, err := s.{{ togqlname $fnname }}BatchWhere(keys, queryArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	var sqlstr = fmt.Sprintf(`SELECT {{ colname .Field.Col }}, count(*) FROM {{ $table }} WHERE %s GROUP BY {{ colname .Field.Col }}`, placeHolders)
+
+	// run query
+	s.info(sqlstr, params)
+	q, err := {{ dbcall "Query" }}sqlstr, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	// load results
+	for q.Next() {
+		var key {{ .RefField.Type }}
+		var count int
+		if err = q.Scan(&key, &count); err != nil {
+			return nil, err
+		}
+		res[key] = count
+	}
+
+	return res, nil
+}
+
+// {{ togqlname $fnname }}BatchWhere returns the WHERE clause and its parameters selecting
+// the rows from {{ $table }} of the foreign keys {{ .Field.Name }} matching queryArgs.
+func (s *{{ $dname }}) {{ togqlname $fnname }}BatchWhere(keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (string, []interface{}, error) {
+	var params []interface{}
+	param := func(v interface{}) string {
+		params = append(params, v)
+{{- if eq (mask) "?" }}
+		return "{{ mask }}"
+{{- else }}
+		return fmt.Sprintf("{{ mask }}", len(params))
+{{- end }}
+	}
+
+{{ if $deleted }}
+	dead := "NULL"
+	if *queryArgs.Dead {
+		dead = "NOT NULL"
+	}
+{{- end }}
+
+	placeHolders := ""
+{{- if (existsqlfilter .Type) }}
+	if queryArgs.filterArgs != nil {
+		if queryArgs.filterArgs.hasField("{{ .Field.Col.ColumnName }}") {
+			return "", nil, fmt.Errorf("already have condition on field:{{ .Field.Name }}, because of foregin key {{ .ForeignKey.ForeignKeyName }}")
+		}
+		placeHolders = fmt.Sprintf("%s AND", queryArgs.filterArgs.sql(param, s.filterOption))
+	}
+{{- end }}
+
+	keyPls := make([]string, len(keys))
+	for i, key := range keys {
+		keyPls[i] = param(key)
+	}
+
+	placeHolders = fmt.Sprintf(`%s {{ colname .Field.Col }} IN (%s){{ if $deleted }} AND {{ parsecolname $deleted }} IS %s{{ end }}`, placeHolders, strings.Join(keyPls, ", "){{ if $deleted }}, dead{{ end }})
+	return placeHolders, params, nil
+}
+{{- end }}
+{{- end }}
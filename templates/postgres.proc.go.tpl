@@ -4,7 +4,7 @@
 
 {{- if ne .Proc.ReturnType "trigger" -}}
 // {{ .Name }} calls the stored procedure '{{ $proc }}({{ .ProcParams }}) {{ .Proc.ReturnType }}' on db.
-func (s *{{ $dname }}) {{ .Name }}(db XODB{{ goparamlist .Params true true }}) ({{ if $notVoid }}{{ retype .Return.Type }}, {{ end }}error) {
+func (s *{{ $dname }}) {{ .Name }}({{ dbparam }}{{ goparamlist .Params true true }}) ({{ if $notVoid }}{{ retype .Return.Type }}, {{ end }}error) {
 	var err error
 
 	// sql query
@@ -14,7 +14,7 @@ func (s *{{ $dname }}) {{ .Name }}(db XODB{{ goparamlist .Params true true }}) (
 {{- if $notVoid }}
 	var ret {{ retype .Return.Type }}
 	s.info(sqlstr{{ goparamlist .Params true false }})
-	err = db.QueryRow(sqlstr{{ goparamlist .Params true false }}).Scan(&ret)
+	err = {{ dbcall "QueryRow" }}sqlstr{{ goparamlist .Params true false }}).Scan(&ret)
 	if err != nil {
 		return {{ reniltype .Return.NilType }}, err
 	}
@@ -22,7 +22,7 @@ func (s *{{ $dname }}) {{ .Name }}(db XODB{{ goparamlist .Params true true }}) (
 	return ret, nil
 {{- else }}
 	s.info(sqlstr)
-	_, err = db.Exec(sqlstr)
+	_, err = {{ dbcall "Exec" }}sqlstr)
 	return err
 {{- end }}
 }
@@ -1,13 +1,32 @@
-{{- $short := (shortname .Type.Name "err" "sqlstr" "db" "q" "res" "xoLog" .QueryParams) -}}
 {{- $queryComments := .QueryComments -}}
 {{- $dname := (print (firstletterupper (driver) ) "Storage") -}}
+{{- if .Exec -}}
+{{- if .Comment -}}
+// {{ .Comment }}
+{{- else -}}
+// {{ .Name }} runs a custom query.
+{{- end }}
+func (s *{{ $dname }}) {{ .Name }} ({{ dbparam }}{{ range .QueryParams }}, {{ .Name }} {{ .Type }}{{ end }}) error {
+	var err error
+
+	// sql query
+	{{ if .Interpolate }}var{{ else }}const{{ end }} sqlstr = {{ range $i, $l := .Query }}{{ if $i }} +{{ end }}{{ if (index $queryComments $i) }} // {{ index $queryComments $i }}{{ end }}{{ if $i }}
+	{{end -}}`{{ $l }}`{{ end }}
+
+	// run query
+	s.info(sqlstr{{ range .QueryParams }}{{ if not .Interpolate }}, {{ .Name }}{{ end }}{{ end }})
+	_, err = {{ dbcall "Exec" }}sqlstr{{ range .QueryParams }}{{ if not .Interpolate }}, {{ .Name }}{{ end }}{{ end }})
+	return err
+}
+{{- else -}}
+{{- $short := (shortname .Type.Name "err" "sqlstr" "db" "ctx" "q" "res" "xoLog" .QueryParams) -}}
 
 {{- if .Comment -}}
 // {{ .Comment }}
 {{- else -}}
 // {{ .Name }} runs a custom query, returning results as {{ .Type.Name }}.
 {{- end }}
-func (s *{{ $dname }}) {{ .Name }} (db XODB{{ range .QueryParams }}, {{ .Name }} {{ .Type }}{{ end }}) ({{ if not .OnlyOne }}[]{{ end }}*{{ .Type.Name }}, error) {
+func (s *{{ $dname }}) {{ .Name }} ({{ dbparam }}{{ range .QueryParams }}, {{ .Name }} {{ .Type }}{{ end }}) ({{ if not .OnlyOne }}[]{{ end }}*{{ .Type.Name }}, error) {
 	var err error
 
 	// sql query
@@ -18,14 +37,14 @@ func (s *{{ $dname }}) {{ .Name }} (db XODB{{ range .QueryParams }}, {{ .Name }}
 	s.info(sqlstr{{ range .QueryParams }}{{ if not .Interpolate }}, {{ .Name }}{{ end }}{{ end }})
 {{- if .OnlyOne }}
 	var {{ $short }} {{ .Type.Name }}
-	err = db.QueryRow(sqlstr{{ range .QueryParams }}, {{ .Name }}{{ end }}).Scan({{ fieldnames .Type.Fields (print "&" $short) }})
+	err = {{ dbcall "QueryRow" }}sqlstr{{ range .QueryParams }}, {{ .Name }}{{ end }}).Scan({{ fieldnames .Type.Fields (print "&" $short) }})
 	if err != nil {
 		return nil, err
 	}
 
 	return &{{ $short }}, nil
 {{- else }}
-	q, err := db.Query(sqlstr{{ range .QueryParams }}, {{ .Name }}{{ end }})
+	q, err := {{ dbcall "Query" }}sqlstr{{ range .QueryParams }}, {{ .Name }}{{ end }})
 	if err != nil {
 		return nil, err
 	}
@@ -48,4 +67,4 @@ func (s *{{ $dname }}) {{ .Name }} (db XODB{{ range .QueryParams }}, {{ .Name }}
 	return res, nil
 {{- end }}
 }
-
+{{- end }}
@@ -1,102 +1,286 @@
+{{- define "wrapmethods" -}}
+    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "ctx" "xoLog" "w" "call") -}}
+    {{- $t := . -}}
+    {{- $table := (schema .Schema .Table.TableName) -}}
+    {{- $ctx := "context.Background()" -}}
+    {{- if (enablecontext) }}{{ $ctx = "ctx" }}{{ end -}}
+    {{- if .PrimaryKey }}
+// Insert{{ .Name }} calls Insert{{ .Name }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Insert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    call := &StorageCall{Method: "Insert{{ .Name }}", Table: `{{ $table }}`, Op: StorageWrite, Args: []interface{}{ {{- $short -}} }}
+    return w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return nil, w.s.Insert{{ .Name }}({{ dbarg }}, {{ $short }})
+    })
+}
+
+// Insert{{ .Name }}ByFields calls Insert{{ .Name }}ByFields of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Insert{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    call := &StorageCall{Method: "Insert{{ .Name }}ByFields", Table: `{{ $table }}`, Op: StorageWrite, Args: []interface{}{ {{- $short -}} }}
+    return w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return nil, w.s.Insert{{ .Name }}ByFields({{ dbarg }}, {{ $short }})
+    })
+}
+
+// Insert{{ .Name }}s calls Insert{{ .Name }}s of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Insert{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error {
+    call := &StorageCall{Method: "Insert{{ .Name }}s", Table: `{{ $table }}`, Op: StorageWrite, Args: []interface{}{ {{- $short -}}s}}
+    return w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return nil, w.s.Insert{{ .Name }}s({{ dbarg }}, {{ $short }}s)
+    })
+}
+
+// Delete{{ .Name }} calls Delete{{ .Name }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Delete{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    call := &StorageCall{Method: "Delete{{ .Name }}", Table: `{{ $table }}`, Op: StorageWrite, Args: []interface{}{ {{- $short -}} }}
+    return w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return nil, w.s.Delete{{ .Name }}({{ dbarg }}, {{ $short }})
+    })
+}
+
+// Delete{{ .Name }}s calls Delete{{ .Name }}s of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Delete{{ .Name }}s({{ dbparam }}, {{ $short }} []*{{ .Name }}) error {
+    call := &StorageCall{Method: "Delete{{ .Name }}s", Table: `{{ $table }}`, Op: StorageWrite, Args: []interface{}{ {{- $short -}} }}
+    return w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return nil, w.s.Delete{{ .Name }}s({{ dbarg }}, {{ $short }})
+    })
+}
+    {{- if ne (fieldnamesmulti .Fields $short .PrimaryKeyFields) "" }}
+
+// Update{{ .Name }} calls Update{{ .Name }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Update{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    call := &StorageCall{Method: "Update{{ .Name }}", Table: `{{ $table }}`, Op: StorageWrite, Args: []interface{}{ {{- $short -}} }}
+    return w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return nil, w.s.Update{{ .Name }}({{ dbarg }}, {{ $short }})
+    })
+}
+
+// Update{{ .Name }}ByFields calls Update{{ .Name }}ByFields of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Update{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}, fields, retCols []string, params, retVars []interface{}) error {
+    call := &StorageCall{Method: "Update{{ .Name }}ByFields", Table: `{{ $table }}`, Op: StorageWrite, Args: []interface{}{ {{- $short }}, fields, retCols, params, retVars}}
+    return w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return nil, w.s.Update{{ .Name }}ByFields({{ dbarg }}, {{ $short }}, fields, retCols, params, retVars)
+    })
+}
+
+// Save{{ .Name }} calls Save{{ .Name }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Save{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    call := &StorageCall{Method: "Save{{ .Name }}", Table: `{{ $table }}`, Op: StorageWrite, Args: []interface{}{ {{- $short -}} }}
+    return w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return nil, w.s.Save{{ .Name }}({{ dbarg }}, {{ $short }})
+    })
+}
+
+// Upsert{{ .Name }} calls Upsert{{ .Name }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Upsert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    call := &StorageCall{Method: "Upsert{{ .Name }}", Table: `{{ $table }}`, Op: StorageWrite, Args: []interface{}{ {{- $short -}} }}
+    return w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return nil, w.s.Upsert{{ .Name }}({{ dbarg }}, {{ $short }})
+    })
+}
+    {{- end }}
+    {{- end }}
+    {{- if (createdcolumn .) }}
+
+// GetMostRecent{{ .Name }} calls GetMostRecent{{ .Name }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) GetMostRecent{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error) {
+    call := &StorageCall{Method: "GetMostRecent{{ .Name }}", Table: `{{ $table }}`, Op: StorageRead, Args: []interface{}{n}}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.GetMostRecent{{ .Name }}({{ dbarg }}, n)
+    })
+    res, _ := call.Result.([]*{{ .Name }})
+    return res, err
+}
+    {{- end }}
+    {{- if (changedcolumn .) }}
+
+// GetMostRecentChanged{{ .Name }} calls GetMostRecentChanged{{ .Name }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) GetMostRecentChanged{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error) {
+    call := &StorageCall{Method: "GetMostRecentChanged{{ .Name }}", Table: `{{ $table }}`, Op: StorageRead, Args: []interface{}{n}}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.GetMostRecentChanged{{ .Name }}({{ dbarg }}, n)
+    })
+    res, _ := call.Result.([]*{{ .Name }})
+    return res, err
+}
+    {{- end }}
+
+// GetAll{{ .Name }} calls GetAll{{ .Name }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) GetAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) ([]*{{ .Name }}, error) {
+    call := &StorageCall{Method: "GetAll{{ .Name }}", Table: `{{ $table }}`, Op: StorageRead, Args: []interface{}{queryArgs}}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.GetAll{{ .Name }}({{ dbarg }}, queryArgs)
+    })
+    res, _ := call.Result.([]*{{ .Name }})
+    return res, err
+}
+
+// CountAll{{ .Name }} calls CountAll{{ .Name }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) CountAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) (int, error) {
+    call := &StorageCall{Method: "CountAll{{ .Name }}", Table: `{{ $table }}`, Op: StorageRead, Args: []interface{}{queryArgs}}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.CountAll{{ .Name }}({{ dbarg }}, queryArgs)
+    })
+    res, _ := call.Result.(int)
+    return res, err
+}
+
+// Aggregate{{ .Name }} calls Aggregate{{ .Name }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Aggregate{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments, groupBy []{{ .Name }}OrderField) ([]*{{ .Name }}Aggregate, error) {
+    call := &StorageCall{Method: "Aggregate{{ .Name }}", Table: `{{ $table }}`, Op: StorageRead, Args: []interface{}{queryArgs, groupBy}}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.Aggregate{{ .Name }}({{ dbarg }}, queryArgs, groupBy)
+    })
+    res, _ := call.Result.([]*{{ .Name }}Aggregate)
+    return res, err
+}
+    {{- range .ForeignKeys }}
+        {{- $fnname := (print (plural $t.Name) "By" .Field.Name "FK") -}}
+        {{- $param := (togqlname .Field.Name) -}}
+        {{- if not (isdup $fnname "wrapmethods") }}
+
+// {{ $fnname }} calls {{ $fnname }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) {{ $fnname }}({{ dbparam }}, {{ $param }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) ([]*{{ $t.Name }}, error) {
+    call := &StorageCall{Method: "{{ $fnname }}", Table: `{{ $table }}`, Op: StorageRead, Args: []interface{}{ {{- $param }}, queryArgs}}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.{{ $fnname }}({{ dbarg }}, {{ $param }}, queryArgs)
+    })
+    res, _ := call.Result.([]*{{ $t.Name }})
+    return res, err
+}
+
+// Count{{ $fnname }} calls Count{{ $fnname }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Count{{ $fnname }}({{ dbparam }}, {{ $param }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) (int, error) {
+    call := &StorageCall{Method: "Count{{ $fnname }}", Table: `{{ $table }}`, Op: StorageRead, Args: []interface{}{ {{- $param }}, queryArgs}}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.Count{{ $fnname }}({{ dbarg }}, {{ $param }}, queryArgs)
+    })
+    res, _ := call.Result.(int)
+    return res, err
+}
+        {{- end }}
+    {{- end }}
+{{- end -}}
+
 {{- $iname := "Storage" -}}
 // {{ $iname }} is interface structure for database operation that can be called
 type {{ $iname }} interface {
+    // WithTx runs fn in a transaction begun on db, or in a savepoint when db
+    // is already a transaction.
+    WithTx(ctx context.Context, db {{ dbtype }}, fn func(tx {{ dbtype }}) error) error
 {{- range .Tables }}
-    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "xoLog") -}}
+    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "ctx" "xoLog") -}}
     {{- $t := . -}}
     {{- $table := (schema .Table.TableName) -}}
     {{- if .PrimaryKey }}
     // Insert{{ .Name }} inserts the {{ .Name }} to the database.
-    Insert{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error
+    Insert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error
     // Insert{{ .Name }}ByFields inserts the {{ .Name }} to the database.
-    Insert{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name }}) error
+    Insert{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}) error
+    // Insert{{ .Name }}s inserts the {{ .Name }}s to the database, in multi-row statements.
+    Insert{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error
     // Delete{{ .Name }} deletes the {{ .Name }} from the database.
-    Delete{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error
+    Delete{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error
     // Delete{{ .Name }}s deletes the {{ .Name }} from the database.
-    Delete{{ .Name }}s(db XODB, {{ $short }} []*{{ .Name }}) error
+    Delete{{ .Name }}s({{ dbparam }}, {{ $short }} []*{{ .Name }}) error
     {{- if ne (fieldnamesmulti .Fields $short .PrimaryKeyFields) "" }}
         // Update updates the {{ .Name }} in the database.
-        Update{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error
+        Update{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error
         // Update{{ .Name }}ByFields updates the {{ .Name }} in the database.
-        Update{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name }}, fields, retCols []string, params, retVars []interface{}) error
+        Update{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}, fields, retCols []string, params, retVars []interface{}) error
         // Save saves the {{ .Name }} to the database.
-        Save{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error
+        Save{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error
         // Upsert performs an upsert for {{ .Name }}.
-        Upsert{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error
+        Upsert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error
     {{- else }}
         // Update statements omitted due to lack of fields other than primary key
     {{- end }}
     {{- end }}
+    {{- if (createdcolumn .) }}
     // GetMostRecent{{ .Name }} returns n most recent rows from '{{ .Table.TableName }}',
-    // ordered by "created_date" in descending order.
-    GetMostRecent{{ .Name }}(db XODB, n int) ([]*{{ .Name }}, error)
+    // ordered by "{{ createdcolumn . }}" in descending order.
+    GetMostRecent{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error)
+    {{- end }}
+    {{- if (changedcolumn .) }}
     // GetMostRecentChanged{{ .Name }} returns n most recent rows from '{{ .Table.TableName }}',
-    // ordered by "changed_date" in descending order.
-    GetMostRecentChanged{{ .Name }}(db XODB, n int) ([]*{{ .Name }}, error)
+    // ordered by "{{ changedcolumn . }}" in descending order.
+    GetMostRecentChanged{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error)
+    {{- end }}
     // GetAll{{ .Name }} returns all rows from '{{ .Table.TableName }}', based on the {{ .Name }}QueryArguments.
     // If the {{ .Name }}QueryArguments is nil, it will use the default {{ .Name }}QueryArguments instead.
-    GetAll{{ .Name }}(db XODB, queryArgs *{{ .Name }}QueryArguments) ([]*{{ .Name }}, error)
+    GetAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) ([]*{{ .Name }}, error)
     // CountAll{{ .Name }} returns a count of all rows from '{{ .Table.TableName }}'
-    CountAll{{ .Name }}(db XODB, queryArgs *{{ .Name }}QueryArguments) (int, error)
+    CountAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) (int, error)
+    // Aggregate{{ .Name }} returns the aggregates of the rows from '{{ .Table.TableName }}' grouped by the groupBy columns,
+    // based on the {{ .Name }}QueryArguments.
+    Aggregate{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments, groupBy []{{ .Name }}OrderField) ([]*{{ .Name }}Aggregate, error)
     {{- range .ForeignKeys }}
 	    {{- $fnname := (print (plural $t.Name) "By" .Field.Name "FK") -}}
 	    {{- if not (isdup $fnname "interface") }}
             // {{ $fnname }} retrieves rows from {{ $table }} by foreign key {{.Field.Name}}.
             // Generated from foreign key {{.Name}}.
-            {{ $fnname }}(db XODB, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) ([]*{{$t.Name}}, error)
+            {{ $fnname }}({{ dbparam }}, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) ([]*{{$t.Name}}, error)
             // Count{{ $fnname }} count rows from {{ $table }} by foreign key {{.Field.Name}}.
             // Generated from foreign key {{.Name}}.
-            Count{{ $fnname }}(db XODB, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) (int, error)
+            Count{{ $fnname }}({{ dbparam }}, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) (int, error)
 	    {{- end }}
     {{- end }}
 {{- end }}
 
 {{- range .Views }}
-    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "xoLog") -}}
+    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "ctx" "xoLog") -}}
     {{- $t := . -}}
     {{- $table := (schema .Table.TableName) -}}
     {{- if .PrimaryKey }}
     // Insert{{ .Name }} inserts the {{ .Name }} to the database.
-    Insert{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error
+    Insert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error
     // Insert{{ .Name }}ByFields inserts the {{ .Name }} to the database.
-    Insert{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name }}}) error
+    Insert{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}}) error
+    // Insert{{ .Name }}s inserts the {{ .Name }}s to the database, in multi-row statements.
+    Insert{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error
     // Delete{{ .Name }} deletes the {{ .Name }} from the database.
-    Delete{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error
+    Delete{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error
     // Delete{{ .Name }}s deletes the {{ .Name }} from the database.
-    Delete{{ .Name }}s(db XODB, {{ $short }} []*{{ .Name }}) error
+    Delete{{ .Name }}s({{ dbparam }}, {{ $short }} []*{{ .Name }}) error
     {{- if ne (fieldnamesmulti .Fields $short .PrimaryKeyFields) "" }}
         // Update updates the {{ .Name }} in the database.
-        Update{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error
+        Update{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error
         // Update{{ .Name }}ByFields updates the {{ .Name }} in the database.
-        Update{{ .Name }}ByFields(db XODB, {{ $short }} *{{ .Name }}, fields, retCols []string, params, retVars []interface{}) error
+        Update{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}, fields, retCols []string, params, retVars []interface{}) error
         // Save saves the {{ .Name }} to the database.
-        Save{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error
+        Save{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error
         // Upsert performs an upsert for {{ .Name }}.
-        Upsert{{ .Name }}(db XODB, {{ $short }} *{{ .Name }}) error
+        Upsert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error
     {{- else }}
         // Update statements omitted due to lack of fields other than primary key
     {{- end }}
     {{- end }}
+    {{- if (createdcolumn .) }}
     // GetMostRecent{{ .Name }} returns n most recent rows from '{{ .Table.TableName }}',
-    // ordered by "created_date" in descending order.
-    GetMostRecent{{ .Name }}(db XODB, n int) ([]*{{ .Name }}, error)
+    // ordered by "{{ createdcolumn . }}" in descending order.
+    GetMostRecent{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error)
+    {{- end }}
+    {{- if (changedcolumn .) }}
     // GetMostRecentChanged{{ .Name }} returns n most recent rows from '{{ .Table.TableName }}',
-    // ordered by "changed_date" in descending order.
-    GetMostRecentChanged{{ .Name }}(db XODB, n int) ([]*{{ .Name }}, error)
+    // ordered by "{{ changedcolumn . }}" in descending order.
+    GetMostRecentChanged{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error)
+    {{- end }}
     // GetAll{{ .Name }} returns all rows from '{{ .Table.TableName }}', based on the {{ .Name }}QueryArguments.
     // If the {{ .Name }}QueryArguments is nil, it will use the default {{ .Name }}QueryArguments instead.
-    GetAll{{ .Name }}(db XODB, queryArgs *{{ .Name }}QueryArguments) ([]*{{ .Name }}, error)
+    GetAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) ([]*{{ .Name }}, error)
     // CountAll{{ .Name }} returns a count of all rows from '{{ .Table.TableName }}'
-    CountAll{{ .Name }}(db XODB, queryArgs *{{ .Name }}QueryArguments) (int, error)
+    CountAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) (int, error)
+    // Aggregate{{ .Name }} returns the aggregates of the rows from '{{ .Table.TableName }}' grouped by the groupBy columns,
+    // based on the {{ .Name }}QueryArguments.
+    Aggregate{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments, groupBy []{{ .Name }}OrderField) ([]*{{ .Name }}Aggregate, error)
     {{- range .ForeignKeys }}
 	    {{- $fnname := (print (plural $t.Name) "By" .Field.Name "FK") -}}
 	    {{- if not (isdup $fnname "interface") }}
             // {{ $fnname }} retrieves rows from {{ $table }} by foreign key {{.Field.Name}}.
             // Generated from foreign key {{.Name}}.
-            {{ $fnname }}(db XODB, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) ([]*{{$t.Name}}, error)
+            {{ $fnname }}({{ dbparam }}, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) ([]*{{$t.Name}}, error)
             // Count{{ $fnname }} count rows from {{ $table }} by foreign key {{.Field.Name}}.
             // Generated from foreign key {{.Name}}.
-            Count{{ $fnname }}(db XODB, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) (int, error)
+            Count{{ $fnname }}({{ dbparam }}, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) (int, error)
 	    {{- end }}
     {{- end }}
 {{- end }}
@@ -105,14 +289,31 @@ type {{ $iname }} interface {
     {{- $short := (shortname .Type.Name) }}
     // {{ .Name }}In{{ .Type.Name }} returns the {{ .RefType.Name }} associated with the {{ .Type.Name }}'s {{ .Field.Name }} ({{ .Field.Col.ColumnName }}).
     // Generated from foreign key '{{ .ForeignKey.ForeignKeyName }}'.
-    {{ .Name }}In{{ .Type.Name }}(db XODB, {{ $short }} *{{ .Type.Name }}) (*{{ .RefType.Name }}, error)
+    {{ .Name }}In{{ .Type.Name }}({{ dbparam }}, {{ $short }} *{{ .Type.Name }}) (*{{ .RefType.Name }}, error)
+    {{- if (enabledataloader) }}
+        {{- $fnname := (print (plural .Type.Name) "By" .Field.Name "FK") -}}
+        {{- if not (isdup (print .RefType.Name "By" .RefField.Name "Batch") "interface") }}
+    // {{ .RefType.Name }}By{{ .RefField.Name }}Batch retrieves the rows from '{{ .RefType.Table.TableName }}' matching any of keys.
+    {{ .RefType.Name }}By{{ .RefField.Name }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}) ([]*{{ .RefType.Name }}, error)
+        {{- end }}
+        {{- if not (isdup (print $fnname "Batch") "interface") }}
+    // {{ $fnname }}Batch retrieves the rows from '{{ .Type.Table.TableName }}' of several foreign keys {{ .Field.Name }} at once.
+    {{ $fnname }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}][]*{{ .Type.Name }}, error)
+    // Count{{ $fnname }}Batch counts the rows from '{{ .Type.Table.TableName }}' of several foreign keys {{ .Field.Name }} at once.
+    Count{{ $fnname }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}]int, error)
+        {{- end }}
+    {{- end }}
 {{- end }}
 
 {{- range .Indexes }}
     {{- $table := (schema .Schema .Type.Table.TableName) }}
     // {{ .FuncName }} retrieves a row from '{{ $table }}' as a {{ .Type.Name }}.
     // Generated from index '{{ .Index.IndexName }}'.
-    {{ .FuncName }}(db XODB{{ goparamlist .Fields true true }}) ({{ if not .Index.IsUnique }}[]{{ end }}*{{ .Type.Name }}, error)
+    {{ .FuncName }}({{ dbparam }}{{ goparamlist .Fields true true }}) ({{ if not .Index.IsUnique }}[]{{ end }}*{{ .Type.Name }}, error)
+    {{- if and .Index.IsUnique (not .Index.IsPrimary) .Type.PrimaryKey }}
+    // Upsert{{ .FuncName }} performs an upsert for {{ .Type.Name }} on the unique index '{{ .Index.IndexName }}'.
+    Upsert{{ .FuncName }}({{ dbparam }}, {{ shortname .Type.Name "err" "res" "sqlstr" "db" "ctx" "xoLog" }} *{{ .Type.Name }}, opts *UpsertOptions) error
+    {{- end }}
 {{- end }}
 }
 
@@ -121,6 +322,9 @@ type {{ $iname }} interface {
     // {{ $udriver }}{{ $iname }} is {{ $udriver }} for the database.
     type {{ $udriver }}{{ $iname }} struct {
 	    logger XOLogger
+    {{- if eq . "postgres" }}
+	    copy   bool
+    {{- end }}
     }
 
     func (s *{{ $udriver }}{{ $iname }}) info(format string, args ...interface{}) {
@@ -130,6 +334,235 @@ type {{ $iname }} interface {
             xoLogf(s.logger, logrus.InfoLevel, "%s %v", format, args)
         }
     }
+
+
+
+    {{- if eq . "mssql" }}
+    // WithTx runs fn in a transaction begun on db, committed when fn succeeds
+    // and rolled back otherwise. When db is already a transaction, fn runs in
+    // a savepoint of it.
+    func (s *{{ $udriver }}{{ $iname }}) WithTx(ctx context.Context, db {{ dbtype }}, fn func(tx {{ dbtype }}) error) error {
+        return xoWithTx(ctx, s.logger, xoTxDialect{
+            savepoint:  "SAVE TRANSACTION %s",
+            rollbackTo: "ROLLBACK TRANSACTION %s",
+        }, db, fn)
+    }
+    {{- else if eq . "godror" }}
+    // WithTx runs fn in a transaction begun on db, committed when fn succeeds
+    // and rolled back otherwise. When db is already a transaction, fn runs in
+    // a savepoint of it.
+    func (s *{{ $udriver }}{{ $iname }}) WithTx(ctx context.Context, db {{ dbtype }}, fn func(tx {{ dbtype }}) error) error {
+        return xoWithTx(ctx, s.logger, xoTxDialect{
+            savepoint:  "SAVEPOINT %s",
+            rollbackTo: "ROLLBACK TO SAVEPOINT %s",
+        }, db, fn)
+    }
+    {{- else }}
+    // WithTx runs fn in a transaction begun on db, committed when fn succeeds
+    // and rolled back otherwise. When db is already a transaction, fn runs in
+    // a savepoint of it.
+    {{- if eq . "postgres" }}
+    // The transactions failing to serialize, as reported by PostgreSQL and
+    // CockroachDB, are run again.
+    {{- end }}
+    func (s *{{ $udriver }}{{ $iname }}) WithTx(ctx context.Context, db {{ dbtype }}, fn func(tx {{ dbtype }}) error) error {
+        return xoWithTx(ctx, s.logger, xoTxDialect{
+            savepoint:  "SAVEPOINT %s",
+            rollbackTo: "ROLLBACK TO SAVEPOINT %s",
+            release:    "RELEASE SAVEPOINT %s",
+            retry:      {{ eq . "postgres" }},
+        }, db, fn)
+    }
+    {{- end }}
+
+    {{- if or (eq . "mysql") (eq . "sqlite3") }}
+    // filterOption converts the filter option to the {{ . }} dialect,
+    // {{ . }} has no ILIKE and its LIKE is already case-insensitive.
+    func (s *{{ $udriver }}{{ $iname }}) filterOption(option string) string {
+        switch option {
+        case "ILIKE":
+            return "LIKE"
+        case "NOT ILIKE":
+            return "NOT LIKE"
+        }
+        return option
+    }
+    {{- else }}
+    // filterOption converts the filter option to the {{ . }} dialect.
+    func (s *{{ $udriver }}{{ $iname }}) filterOption(option string) string {
+        return option
+    }
+    {{- end }}
+    {{- if eq . "postgres" }}
+
+    // translateError translates the errors of the queries on table, from the
+    // SQLSTATE codes of lib/pq, to ErrNotFound and to the constraint violations.
+    func (s *{{ $udriver }}{{ $iname }}) translateError(table string, err error) error {
+        if err == sql.ErrNoRows {
+            return ErrNotFound
+        }
+        pqErr, ok := err.(interface{ Get(k byte) string })
+        if !ok {
+            return err
+        }
+
+        switch pqErr.Get('C') {
+        case "23505": // unique_violation
+            return xoUniqueViolation(table, pqErr.Get('n'), nil, err)
+        case "23503": // foreign_key_violation
+            return xoForeignKeyViolation(pqErr.Get('n'), err)
+        case "23502": // not_null_violation
+            return &NotNullViolation{Table: table, Column: pqErr.Get('c'), Err: err}
+        }
+        return err
+    }
+    {{- else if eq . "mysql" }}
+
+    var (
+        // xoMySQLError matches the number and the message of the errors of
+        // go-sql-driver/mysql.
+        xoMySQLError = regexp.MustCompile(`^Error (\d+)(?: \(\w+\))?: (.*)$`)
+        // xoMySQLKey matches the index of a duplicate entry error.
+        xoMySQLKey = regexp.MustCompile(`for key '(?:[^']*\.)?([^'.]+)'`)
+        // xoMySQLConstraint matches the foreign key of a foreign key error.
+        xoMySQLConstraint = regexp.MustCompile("CONSTRAINT `([^`]+)`")
+        // xoMySQLColumn matches the column of a not null error.
+        xoMySQLColumn = regexp.MustCompile(`Column '([^']+)' cannot be null`)
+    )
+
+    // translateError translates the errors of the queries on table, from the
+    // error numbers of mysql, to ErrNotFound and to the constraint violations.
+    func (s *{{ $udriver }}{{ $iname }}) translateError(table string, err error) error {
+        if err == sql.ErrNoRows {
+            return ErrNotFound
+        }
+        if err == nil {
+            return nil
+        }
+        m := xoMySQLError.FindStringSubmatch(err.Error())
+        if m == nil {
+            return err
+        }
+
+        switch m[1] {
+        case "1062": // ER_DUP_ENTRY
+            return xoUniqueViolation(table, xoSubmatch(xoMySQLKey, m[2]), nil, err)
+        case "1451", "1452": // ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2
+            return xoForeignKeyViolation(xoSubmatch(xoMySQLConstraint, m[2]), err)
+        case "1048": // ER_BAD_NULL_ERROR
+            return &NotNullViolation{Table: table, Column: xoSubmatch(xoMySQLColumn, m[2]), Err: err}
+        }
+        return err
+    }
+    {{- else if eq . "sqlite3" }}
+
+    // translateError translates the errors of the queries on table, from the
+    // messages of the constraint errors of sqlite3, to ErrNotFound and to the
+    // constraint violations. sqlite3 does not name the violated foreign keys.
+    func (s *{{ $udriver }}{{ $iname }}) translateError(table string, err error) error {
+        if err == sql.ErrNoRows {
+            return ErrNotFound
+        }
+        if err == nil {
+            return nil
+        }
+
+        msg := err.Error()
+        switch {
+        case strings.HasPrefix(msg, "UNIQUE constraint failed: "):
+            var columns []string
+            for _, c := range strings.Split(strings.TrimPrefix(msg, "UNIQUE constraint failed: "), ", ") {
+                columns = append(columns, c[strings.LastIndex(c, ".")+1:])
+            }
+            return xoUniqueViolation(table, "", columns, err)
+        case msg == "FOREIGN KEY constraint failed":
+            return xoForeignKeyViolation("", err)
+        case strings.HasPrefix(msg, "NOT NULL constraint failed: "):
+            c := strings.TrimPrefix(msg, "NOT NULL constraint failed: ")
+            return &NotNullViolation{Table: table, Column: c[strings.LastIndex(c, ".")+1:], Err: err}
+        }
+        return err
+    }
+    {{- else if eq . "mssql" }}
+
+    var (
+        // xoMSSQLIndex matches the index of a duplicate key error.
+        xoMSSQLIndex = regexp.MustCompile(`(?:KEY constraint|unique index) '([^']+)'`)
+        // xoMSSQLConstraint matches the foreign key of a foreign key error.
+        xoMSSQLConstraint = regexp.MustCompile(`(?:FOREIGN KEY|REFERENCE) constraint "([^"]+)"`)
+        // xoMSSQLColumn matches the column of a not null error.
+        xoMSSQLColumn = regexp.MustCompile(`NULL into column '([^']+)'`)
+    )
+
+    // translateError translates the errors of the queries on table, from the
+    // error numbers of mssql, to ErrNotFound and to the constraint violations.
+    func (s *{{ $udriver }}{{ $iname }}) translateError(table string, err error) error {
+        if err == sql.ErrNoRows {
+            return ErrNotFound
+        }
+        msErr, ok := err.(interface{ SQLErrorNumber() int32 })
+        if !ok {
+            return err
+        }
+
+        switch msErr.SQLErrorNumber() {
+        case 2627, 2601: // unique constraint, unique index
+            return xoUniqueViolation(table, xoSubmatch(xoMSSQLIndex, err.Error()), nil, err)
+        case 547: // constraint conflict
+            if name := xoSubmatch(xoMSSQLConstraint, err.Error()); name != "" {
+                return xoForeignKeyViolation(name, err)
+            }
+        case 515: // cannot insert null
+            return &NotNullViolation{Table: table, Column: xoSubmatch(xoMSSQLColumn, err.Error()), Err: err}
+        }
+        return err
+    }
+    {{- else if or (eq . "godror") (eq . "oci8") }}
+
+    var (
+        // xoOracleError matches the code of the errors of oracle.
+        xoOracleError = regexp.MustCompile(`ORA-(\d{5})`)
+        // xoOracleConstraint matches the constraint of a constraint error.
+        xoOracleConstraint = regexp.MustCompile(`constraint \((?:[^.)]+\.)?([^)]+)\) violated`)
+        // xoOracleColumn matches the column of a not null error.
+        xoOracleColumn = regexp.MustCompile(`"([^"]+)"\)`)
+    )
+
+    // translateError translates the errors of the queries on table, from the
+    // ORA codes of oracle, to ErrNotFound and to the constraint violations.
+    func (s *{{ $udriver }}{{ $iname }}) translateError(table string, err error) error {
+        if err == sql.ErrNoRows {
+            return ErrNotFound
+        }
+        if err == nil {
+            return nil
+        }
+        m := xoOracleError.FindStringSubmatch(err.Error())
+        if m == nil {
+            return err
+        }
+
+        switch m[1] {
+        case "00001": // unique constraint violated
+            return xoUniqueViolation(table, xoSubmatch(xoOracleConstraint, err.Error()), nil, err)
+        case "02291", "02292": // parent key not found, child record found
+            return xoForeignKeyViolation(xoSubmatch(xoOracleConstraint, err.Error()), err)
+        case "01400", "01407": // cannot insert NULL, cannot update to NULL
+            return &NotNullViolation{Table: table, Column: xoSubmatch(xoOracleColumn, err.Error()), Err: err}
+        }
+        return err
+    }
+    {{- else }}
+
+    // translateError translates the errors of the queries on table to
+    // ErrNotFound.
+    func (s *{{ $udriver }}{{ $iname }}) translateError(table string, err error) error {
+        if err == sql.ErrNoRows {
+            return ErrNotFound
+        }
+        return err
+    }
+    {{- end }}
 {{- end }}
 
 // New is a construction method that return a new Storage
@@ -146,7 +579,11 @@ func New(driver string, c Config) (Storage, error) {
     {{- $driver := . -}}
     {{- $udriver := (firstletterupper $driver) }}
 	case "{{ $driver }}":
-		s = &{{ $udriver }}{{ $iname }}{ logger: logger }
+		s = &{{ $udriver }}{{ $iname }}{ logger: logger{{ if eq $driver "postgres" }}, copy: c.PostgresCopy{{ end }} }
+{{- end }}
+{{- if (enablememory) }}
+	case "memory":
+		s = NewMemoryStorage()
 {{- end }}
 	default:
 		return nil, errors.New("driver " + driver + " not support")
@@ -155,8 +592,568 @@ func New(driver string, c Config) (Storage, error) {
 	return s, nil
 }
 
+// StorageOp is the kind of operation of a Storage method.
+type StorageOp int
+
+const (
+	// StorageRead is the kind of the methods reading rows.
+	StorageRead StorageOp = iota
+	// StorageWrite is the kind of the methods writing rows, and of WithTx.
+	StorageWrite
+)
+
+// String satisfies the fmt.Stringer interface.
+func (op StorageOp) String() string {
+	if op == StorageWrite {
+		return "write"
+	}
+	return "read"
+}
+
+// StorageCall is a call of a method of a wrapped Storage, as seen by the
+// middlewares.
+type StorageCall struct {
+	// Method is the name of the Storage method, e.g. GetAllUser.
+	Method string
+	// Table is the table of the rows read or written, empty for WithTx.
+	Table string
+	// Op is the kind of operation of the method.
+	Op StorageOp
+	// Args are the arguments of the call but the context and the database
+	// handle.
+	Args []interface{}
+	// Result is the result of the method but the error, nil for the methods
+	// only returning an error. A middleware returning without calling next,
+	// e.g. a cache, sets it to a value of the result type of the method.
+	Result interface{}
+
+	run func(ctx context.Context) (interface{}, error)
+}
+
+// StorageHandler handles a call of a method of a wrapped Storage.
+type StorageHandler func(ctx context.Context, call *StorageCall) error
+
+// Middleware wraps the handler of the calls of a wrapped Storage, to observe
+// them, e.g. for metrics, traces or slow query logs, or to answer them
+// without calling next, e.g. from a cache.
+type Middleware func(next StorageHandler) StorageHandler
+
+// ObserveCalls returns a Middleware calling fn after each call of a wrapped
+// Storage with the duration and the error of the call.
+func ObserveCalls(fn func(ctx context.Context, call *StorageCall, d time.Duration, err error)) Middleware {
+	return func(next StorageHandler) StorageHandler {
+		return func(ctx context.Context, call *StorageCall) error {
+			start := time.Now()
+			err := next(ctx, call)
+			fn(ctx, call, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+// Wrap returns a Storage calling the methods of s through the middlewares,
+// the first middleware being the outermost one.
+func Wrap(s Storage, mws ...Middleware) Storage {
+	var h StorageHandler = func(ctx context.Context, call *StorageCall) error {
+		res, err := call.run(ctx)
+		call.Result = res
+		return err
+	}
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return &wrappedStorage{s: s, h: h}
+}
+
+// wrappedStorage is the Storage returned by Wrap.
+type wrappedStorage struct {
+	s Storage
+	h StorageHandler
+}
+
+// do handles call, run is the call of the method of the wrapped Storage
+// with the context passed down by the middlewares.
+func (w *wrappedStorage) do(ctx context.Context, call *StorageCall, run func(ctx context.Context) (interface{}, error)) error {
+	call.run = run
+	return w.h(ctx, call)
+}
+
+// WithTx calls WithTx of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) WithTx(ctx context.Context, db {{ dbtype }}, fn func(tx {{ dbtype }}) error) error {
+	call := &StorageCall{Method: "WithTx", Op: StorageWrite}
+	return w.do(ctx, call, func(ctx context.Context) (interface{}, error) {
+		return nil, w.s.WithTx(ctx, db, fn)
+	})
+}
+{{- range .Tables }}
+
+{{ template "wrapmethods" . }}
+{{- end }}
+{{- range .Views }}
+
+{{ template "wrapmethods" . }}
+{{- end }}
+{{- $ctx := "context.Background()" -}}
+{{- if (enablecontext) }}{{ $ctx = "ctx" }}{{ end -}}
+{{- range .Foreign }}
+    {{- $short := (shortname .Type.Name "w" "call") }}
+    {{- $reftable := (schema .RefType.Schema .RefType.Table.TableName) }}
+
+// {{ .Name }}In{{ .Type.Name }} calls {{ .Name }}In{{ .Type.Name }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) {{ .Name }}In{{ .Type.Name }}({{ dbparam }}, {{ $short }} *{{ .Type.Name }}) (*{{ .RefType.Name }}, error) {
+    call := &StorageCall{Method: "{{ .Name }}In{{ .Type.Name }}", Table: `{{ $reftable }}`, Op: StorageRead, Args: []interface{}{ {{- $short -}} }}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.{{ .Name }}In{{ .Type.Name }}({{ dbarg }}, {{ $short }})
+    })
+    res, _ := call.Result.(*{{ .RefType.Name }})
+    return res, err
+}
+    {{- if (enabledataloader) }}
+        {{- $fnname := (print (plural .Type.Name) "By" .Field.Name "FK") -}}
+        {{- $batch := (print .RefType.Name "By" .RefField.Name "Batch") -}}
+        {{- $table := (schema .Type.Schema .Type.Table.TableName) -}}
+        {{- if not (isdup $batch "wrapmethods") }}
+
+// {{ $batch }} calls {{ $batch }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) {{ $batch }}({{ dbparam }}, keys []{{ .RefField.Type }}) ([]*{{ .RefType.Name }}, error) {
+    call := &StorageCall{Method: "{{ $batch }}", Table: `{{ $reftable }}`, Op: StorageRead, Args: []interface{}{keys}}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.{{ $batch }}({{ dbarg }}, keys)
+    })
+    res, _ := call.Result.([]*{{ .RefType.Name }})
+    return res, err
+}
+        {{- end }}
+        {{- if not (isdup (print $fnname "Batch") "wrapmethods") }}
+
+// {{ $fnname }}Batch calls {{ $fnname }}Batch of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) {{ $fnname }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}][]*{{ .Type.Name }}, error) {
+    call := &StorageCall{Method: "{{ $fnname }}Batch", Table: `{{ $table }}`, Op: StorageRead, Args: []interface{}{keys, queryArgs}}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.{{ $fnname }}Batch({{ dbarg }}, keys, queryArgs)
+    })
+    res, _ := call.Result.(map[{{ .RefField.Type }}][]*{{ .Type.Name }})
+    return res, err
+}
+
+// Count{{ $fnname }}Batch calls Count{{ $fnname }}Batch of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Count{{ $fnname }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}]int, error) {
+    call := &StorageCall{Method: "Count{{ $fnname }}Batch", Table: `{{ $table }}`, Op: StorageRead, Args: []interface{}{keys, queryArgs}}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.Count{{ $fnname }}Batch({{ dbarg }}, keys, queryArgs)
+    })
+    res, _ := call.Result.(map[{{ .RefField.Type }}]int)
+    return res, err
+}
+        {{- end }}
+    {{- end }}
+{{- end }}
+{{- range .Indexes }}
+    {{- $table := (schema .Schema .Type.Table.TableName) }}
+
+// {{ .FuncName }} calls {{ .FuncName }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) {{ .FuncName }}({{ dbparam }}{{ goparamlist .Fields true true }}) ({{ if not .Index.IsUnique }}[]{{ end }}*{{ .Type.Name }}, error) {
+    call := &StorageCall{Method: "{{ .FuncName }}", Table: `{{ $table }}`, Op: StorageRead, Args: []interface{}{ {{- goparamlist .Fields false false -}} }}
+    err := w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return w.s.{{ .FuncName }}({{ dbarg }}{{ goparamlist .Fields true false }})
+    })
+    res, _ := call.Result.({{ if not .Index.IsUnique }}[]{{ end }}*{{ .Type.Name }})
+    return res, err
+}
+    {{- if and .Index.IsUnique (not .Index.IsPrimary) .Type.PrimaryKey }}
+    {{- $short := (shortname .Type.Name "err" "res" "sqlstr" "db" "ctx" "xoLog" "w" "call") }}
+
+// Upsert{{ .FuncName }} calls Upsert{{ .FuncName }} of the wrapped Storage through the middlewares.
+func (w *wrappedStorage) Upsert{{ .FuncName }}({{ dbparam }}, {{ $short }} *{{ .Type.Name }}, opts *UpsertOptions) error {
+    call := &StorageCall{Method: "Upsert{{ .FuncName }}", Table: `{{ $table }}`, Op: StorageWrite, Args: []interface{}{ {{- $short }}, opts}}
+    return w.do({{ $ctx }}, call, func(ctx context.Context) (interface{}, error) {
+        return nil, w.s.Upsert{{ .FuncName }}({{ dbarg }}, {{ $short }}, opts)
+    })
+}
+    {{- end }}
+{{- end }}
+
+// BeforeInserter is implemented by the rows running a hook before they are
+// inserted, e.g. to set their columns. An error aborts the insert.
+type BeforeInserter interface {
+	BeforeInsert({{ dbparam }}) error
+}
+
+// AfterInserter is implemented by the rows running a hook after they are
+// inserted. An error is returned by the insert, which is not undone but in a
+// transaction.
+type AfterInserter interface {
+	AfterInsert({{ dbparam }}) error
+}
+
+// BeforeUpdater is implemented by the rows running a hook before they are
+// updated, e.g. to set their columns. An error aborts the update.
+type BeforeUpdater interface {
+	BeforeUpdate({{ dbparam }}) error
+}
+
+// AfterUpdater is implemented by the rows running a hook after they are
+// updated. An error is returned by the update, which is not undone but in a
+// transaction.
+type AfterUpdater interface {
+	AfterUpdate({{ dbparam }}) error
+}
+
+// BeforeDeleter is implemented by the rows running a hook before they are
+// deleted. An error aborts the delete.
+type BeforeDeleter interface {
+	BeforeDelete({{ dbparam }}) error
+}
+
+// AfterDeleter is implemented by the rows running a hook after they are
+// deleted. An error is returned by the delete, which is not undone but in a
+// transaction.
+type AfterDeleter interface {
+	AfterDelete({{ dbparam }}) error
+}
+
+// xoBeforeInsert runs the BeforeInsert hook of row, if any.
+func xoBeforeInsert({{ dbparam }}, row interface{}) error {
+	if h, ok := row.(BeforeInserter); ok {
+		return h.BeforeInsert({{ dbarg }})
+	}
+	return nil
+}
+
+// xoAfterInsert runs the AfterInsert hook of row, if any.
+func xoAfterInsert({{ dbparam }}, row interface{}) error {
+	if h, ok := row.(AfterInserter); ok {
+		return h.AfterInsert({{ dbarg }})
+	}
+	return nil
+}
+
+// xoBeforeUpdate runs the BeforeUpdate hook of row, if any.
+func xoBeforeUpdate({{ dbparam }}, row interface{}) error {
+	if h, ok := row.(BeforeUpdater); ok {
+		return h.BeforeUpdate({{ dbarg }})
+	}
+	return nil
+}
+
+// xoRow is implemented by the types of the tables, whose fields are read by
+// column.
+type xoRow interface {
+	// xoColumns returns the columns other than the primary key.
+	xoColumns() []string
+
+	// xoField returns the pointer to the field of column, nil if there is none.
+	xoField(column string) interface{}
+}
+
+// xoBeforeUpdateFields runs the BeforeUpdate hook of row before an update
+// setting its fields columns to params and reading its retCols columns back
+// into retVars. The columns changed by the hook are saved: the params of the
+// fields are read again from row, and the other changed columns are added to
+// fields rather than read back.
+func xoBeforeUpdateFields({{ dbparam }}, row xoRow, fields, retCols []string, params, retVars []interface{}) ([]string, []string, []interface{}, []interface{}, error) {
+	columns := row.xoColumns()
+	prev := make([]interface{}, len(columns))
+	for i, column := range columns {
+		prev[i] = reflect.ValueOf(row.xoField(column)).Elem().Interface()
+	}
+	if err := xoBeforeUpdate({{ dbarg }}, row); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// the index of the fields by the pointers to their fields
+	set := make(map[interface{}]int)
+	for i, field := range fields {
+		if v := row.xoField(field); v != nil {
+			set[v] = i
+		}
+	}
+
+	n := len(fields)
+	fields, params = fields[:n:n], append([]interface{}{}, params...)
+	added := make(map[interface{}]bool)
+	for i, column := range columns {
+		v := row.xoField(column)
+		cur := reflect.ValueOf(v).Elem().Interface()
+		if reflect.DeepEqual(cur, prev[i]) {
+			continue
+		}
+		if j, ok := set[v]; ok {
+			if j < len(params) {
+				params[j] = cur
+			}
+			continue
+		}
+		fields, params = append(fields, column), append(params, cur)
+		added[v] = true
+	}
+
+	// the added columns are no longer read back
+	var cols []string
+	var vars []interface{}
+	for i, column := range retCols {
+		if v := row.xoField(column); v != nil && added[v] {
+			continue
+		}
+		cols, vars = append(cols, column), append(vars, retVars[i])
+	}
+	return fields, cols, params, vars, nil
+}
+
+// xoAfterUpdate runs the AfterUpdate hook of row, if any.
+func xoAfterUpdate({{ dbparam }}, row interface{}) error {
+	if h, ok := row.(AfterUpdater); ok {
+		return h.AfterUpdate({{ dbarg }})
+	}
+	return nil
+}
+
+// xoBeforeDelete runs the BeforeDelete hook of row, if any.
+func xoBeforeDelete({{ dbparam }}, row interface{}) error {
+	if h, ok := row.(BeforeDeleter); ok {
+		return h.BeforeDelete({{ dbarg }})
+	}
+	return nil
+}
+
+// xoAfterDelete runs the AfterDelete hook of row, if any.
+func xoAfterDelete({{ dbparam }}, row interface{}) error {
+	if h, ok := row.(AfterDeleter); ok {
+		return h.AfterDelete({{ dbarg }})
+	}
+	return nil
+}
+
+// UpsertOptions are the options of the upserts on unique indexes.
+type UpsertOptions struct {
+	// Columns are the columns overwritten when the row exists, all the
+	// columns but the primary key and the unique index ones when empty.
+	Columns []string
+	// DoNothing leaves the existing row unchanged.
+	DoNothing bool
+}
+
+// check returns an error when a column of the options is not one of cols.
+func (o *UpsertOptions) check(cols ...string) error {
+	if o == nil {
+		return nil
+	}
+
+	for _, c := range o.Columns {
+		found := false
+		for _, col := range cols {
+			if c == col {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("upsert failed: unknown column %s", c)
+		}
+	}
+	return nil
+}
+
+// overwrites returns whether the upsert overwrites the column col of the
+// existing row.
+func (o *UpsertOptions) overwrites(col string) bool {
+	switch {
+	case o == nil:
+		return true
+	case o.DoNothing:
+		return false
+	case len(o.Columns) == 0:
+		return true
+	}
+
+	for _, c := range o.Columns {
+		if c == col {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNotFound is the error of the methods retrieving or updating a row that
+// does not exist.
+var ErrNotFound = errors.New("not found")
+
+// UniqueViolation is the error of a write violating a unique index, or the
+// primary key, of a table.
+type UniqueViolation struct {
+	// Table is the table of the index.
+	Table string
+	// Index is the name of the index, or of the unique constraint.
+	Index string
+	// Columns are the columns of the index.
+	Columns []string
+	// Err is the error of the database driver.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *UniqueViolation) Error() string {
+	msg := "duplicate key value violates unique constraint"
+	if e.Index != "" {
+		msg += " " + e.Index
+	}
+	if len(e.Columns) != 0 {
+		msg += " (" + strings.Join(e.Columns, ", ") + ")"
+	}
+	if e.Table != "" {
+		msg = e.Table + ": " + msg
+	}
+	return msg
+}
+
+// Unwrap returns the error of the database driver.
+func (e *UniqueViolation) Unwrap() error {
+	return e.Err
+}
+
+// ForeignKeyViolation is the error of a write violating a foreign key, when
+// inserting or updating a row referencing a missing row, or when deleting a
+// row still referenced.
+type ForeignKeyViolation struct {
+	// Constraint is the name of the foreign key, empty when the database
+	// does not report it.
+	Constraint string
+	// Table and Column are the referencing table and column of the foreign
+	// key.
+	Table, Column string
+	// RefTable and RefColumn are the referenced table and column of the
+	// foreign key.
+	RefTable, RefColumn string
+	// Err is the error of the database driver.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ForeignKeyViolation) Error() string {
+	msg := "violates foreign key constraint"
+	if e.Constraint != "" {
+		msg += " " + e.Constraint
+	}
+	if e.Table != "" {
+		msg = e.Table + "." + e.Column + " " + msg + " of " + e.RefTable + "." + e.RefColumn
+	}
+	return msg
+}
+
+// Unwrap returns the error of the database driver.
+func (e *ForeignKeyViolation) Unwrap() error {
+	return e.Err
+}
+
+// NotNullViolation is the error of a write setting a NOT NULL column to
+// NULL.
+type NotNullViolation struct {
+	// Table is the table of the column.
+	Table string
+	// Column is the column.
+	Column string
+	// Err is the error of the database driver.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *NotNullViolation) Error() string {
+	return e.Table + ": null value in column " + e.Column + " violates not-null constraint"
+}
+
+// Unwrap returns the error of the database driver.
+func (e *NotNullViolation) Unwrap() error {
+	return e.Err
+}
+
+// xoConstraint is a unique index or a foreign key of a table, as loaded by
+// xo, to translate the errors of the database drivers.
+type xoConstraint struct {
+	name, table string
+	columns     []string
+	primary     bool
+
+	refTable, refColumn string
+}
+
+// xoUniqueIndexes are the unique indexes of the tables.
+var xoUniqueIndexes = []xoConstraint{
+{{- range .Indexes }}
+	{{- if .Index.IsUnique }}
+	{name: `{{ .Index.IndexName }}`, table: `{{ .Type.Table.TableName }}`, columns: []string{ {{- range $i, $f := .Fields }}{{ if $i }}, {{ end }}`{{ $f.Col.ColumnName }}`{{ end -}} }{{ if .Index.IsPrimary }}, primary: true{{ end }}},
+	{{- end }}
+{{- end }}
+}
+
+// xoForeignKeys are the foreign keys of the tables.
+var xoForeignKeys = []xoConstraint{
+{{- range .Foreign }}
+	{{- if not (isdup (print .Type.Table.TableName "." .ForeignKey.ForeignKeyName) "xoforeignkeys") }}
+	{name: `{{ .ForeignKey.ForeignKeyName }}`, table: `{{ .Type.Table.TableName }}`, columns: []string{`{{ .Field.Col.ColumnName }}`}, refTable: `{{ .RefType.Table.TableName }}`, refColumn: `{{ .RefField.Col.ColumnName }}`},
+	{{- end }}
+{{- end }}
+}
+
+// xoUniqueViolation returns the UniqueViolation of the unique index name of
+// table, or of its unique index on columns when the database does not report
+// the name.
+func xoUniqueViolation(table, name string, columns []string, err error) error {
+	for _, c := range xoUniqueIndexes {
+		if !strings.EqualFold(c.table, table) {
+			continue
+		}
+		if (name != "" && (strings.EqualFold(c.name, name) || (c.primary && strings.EqualFold(name, "PRIMARY")))) ||
+			(name == "" && xoSameColumns(c.columns, columns)) {
+			return &UniqueViolation{Table: c.table, Index: c.name, Columns: c.columns, Err: err}
+		}
+	}
+	return &UniqueViolation{Table: table, Index: name, Columns: columns, Err: err}
+}
+
+// xoForeignKeyViolation returns the ForeignKeyViolation of the foreign key
+// name.
+func xoForeignKeyViolation(name string, err error) error {
+	if name != "" {
+		for _, c := range xoForeignKeys {
+			if strings.EqualFold(c.name, name) {
+				return &ForeignKeyViolation{Constraint: c.name, Table: c.table, Column: c.columns[0], RefTable: c.refTable, RefColumn: c.refColumn, Err: err}
+			}
+		}
+	}
+	return &ForeignKeyViolation{Constraint: name, Err: err}
+}
+
+// xoSameColumns determines if a and b are the same columns, in any order.
+func xoSameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, x := range a {
+		found := false
+		for _, y := range b {
+			if strings.EqualFold(x, y) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// xoSubmatch returns the first submatch of re in s, empty when re does not
+// match.
+func xoSubmatch(re *regexp.Regexp, s string) string {
+	if m := re.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
 {{ range .Tables }}
-    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "xoLog") -}}
+    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "ctx" "xoLog" "column") -}}
     {{- $table := (schema .Schema .Table.TableName) -}}
     {{- if .Comment -}}
     // {{ .Comment }}
@@ -185,12 +1182,35 @@ func New(driver string, c Config) (Storage, error) {
         return {{ $short }}._deleted
     }
 
+    // xoColumns returns the columns of the {{ .Name }} other than its primary key.
+    func ({{ $short }} *{{ .Name }}) xoColumns() []string {
+        return []string{
+    {{- range .Fields }}
+        {{- if not .Col.IsPrimaryKey }}
+            {{ printf "%q" (colname .Col) }},
+        {{- end }}
+    {{- end }}
+        }
+    }
+
+    // xoField returns the pointer to the field of the column of the {{ .Name }},
+    // nil if there is none.
+    func ({{ $short }} *{{ .Name }}) xoField(column string) interface{} {
+        switch column {
+    {{- range .Fields }}
+        case "{{ .Col.ColumnName }}"{{ if ne (colname .Col) .Col.ColumnName }}, {{ printf "%q" (colname .Col) }}{{ end }}:
+            return &{{ $short }}.{{ .Name }}
+    {{- end }}
+        }
+        return nil
+    }
+
     {{- end }}
 {{- end }}
 
 
 {{ range .Views }}
-    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "xoLog") -}}
+    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "ctx" "xoLog") -}}
     {{- $table := (schema .Schema .Table.TableName) -}}
     {{- if .Comment -}}
     // {{ .Comment }}
@@ -240,6 +1260,14 @@ func New(driver string, c Config) (Storage, error) {
             AND
             OR
         }
+        enum OrderDirection{
+            ASC
+            DESC
+        }
+        enum OrderNulls{
+            FIRST
+            LAST
+        }
     `
 
     // PageInfoResolver defines the GraphQL PageInfo type
@@ -267,29 +1295,57 @@ func New(driver string, c Config) (Storage, error) {
 
     // HasPreviousPage returns if previous page is available
     func (r *PageInfoResolver) HasPreviousPage() bool {
-        return r.hasNextPage
+        return r.hasPreviousPage
     }
 
     // ResolverConfig is a config for Resolver
     type ResolverConfig struct {
         Logger   XOLogger
-        DB       XODB
+        DB       {{ dbtype }}
         S        Storage
         Recorder EventRecorder
     {{- if (enableac) }}
         Verifier Verifier
     {{- end }}
+    {{- if (enabledataloader) }}
+        // LoaderWait is how long the loader collects foreign keys before
+        // querying them in one batch, it defaults to DefaultLoaderWait.
+        LoaderWait time.Duration
+    {{- end }}
+        // BeginTx begins the transaction the batch mutations run in. When it's
+        // nil and DB is a *sql.DB, the transaction is run by S.WithTx, otherwise
+        // the batch mutations run on DB without a transaction.
+        BeginTx func(ctx context.Context) (XOTx, error)
+        // PartialSuccess runs every item of a batch mutation in its own
+        // transaction, keeping the items that succeed and returning the errors
+        // of the others in a *BatchError, instead of rolling back all of them.
+        PartialSuccess bool
+    }
+
+    // XOTx is a transaction the batch mutations run in.
+    //
+    // This should work with database/sql.Tx.
+    type XOTx interface {
+        {{ dbtype }}
+        Commit() error
+        Rollback() error
     }
 
     // resolverExtensions it's passing between root resolver and  children resolver
     type resolverExtensions struct {
         logger   XOLogger
-        db       XODB
+        db       {{ dbtype }}
         storage  Storage
         recorder EventRecorder
     {{- if (enableac) }}
         verifier Verifier
     {{- end }}
+    {{- if (enabledataloader) }}
+        loaderWait time.Duration
+        loader     *dataLoader
+    {{- end }}
+        beginTx        func(ctx context.Context) (XOTx, error)
+        partialSuccess bool
     }
 
     // RootResolver is a graphql root resolver
@@ -303,6 +1359,13 @@ func New(driver string, c Config) (Storage, error) {
         if logger == nil {
             logger = logrus.New()
         }
+    {{- if (enabledataloader) }}
+
+        loaderWait := c.LoaderWait
+        if loaderWait <= 0 {
+            loaderWait = DefaultLoaderWait
+        }
+    {{- end }}
 
         return &RootResolver{
             ext: resolverExtensions{
@@ -313,10 +1376,221 @@ func New(driver string, c Config) (Storage, error) {
     {{- if (enableac) }}
                 verifier: c.Verifier,
     {{- end }}
+    {{- if (enabledataloader) }}
+                loaderWait: loaderWait,
+    {{- end }}
+                beginTx:        c.BeginTx,
+                partialSuccess: c.PartialSuccess,
             },
         }
     }
 
+    // inTx runs fn in a transaction, committing it when fn succeeds and rolling
+    // it back otherwise.
+    func (ext resolverExtensions) inTx(ctx context.Context, fn func(db {{ dbtype }}) error) error {
+        if ext.beginTx == nil {
+            if _, ok := ext.db.(*sql.DB); ok {
+                return ext.storage.WithTx(ctx, ext.db, fn)
+            }
+            return fn(ext.db)
+        }
+
+        tx, err := ext.beginTx(ctx)
+        if err != nil {
+            return errors.Wrap(err, "unable to begin transaction")
+        }
+        if err := fn(tx); err != nil {
+            if rerr := tx.Rollback(); rerr != nil {
+                ext.logger.Warnf("unable to roll back transaction, err:%v", rerr)
+            }
+            return err
+        }
+        return errors.Wrap(tx.Commit(), "unable to commit transaction")
+    }
+
+    // batch runs fn for the n items of a batch mutation, in one transaction
+    // rolled back on the first error, or with PartialSuccess in a transaction
+    // per item, the failed items returned in a *BatchError.
+    func (ext resolverExtensions) batch(ctx context.Context, n int, fn func(db {{ dbtype }}, i int) error) error {
+        if !ext.partialSuccess {
+            return ext.inTx(ctx, func(db {{ dbtype }}) error {
+                for i := 0; i < n; i++ {
+                    if err := fn(db, i); err != nil {
+                        return err
+                    }
+                }
+                return nil
+            })
+        }
+
+        batchErr := &BatchError{}
+        for i := 0; i < n; i++ {
+            err := ext.inTx(ctx, func(db {{ dbtype }}) error {
+                return fn(db, i)
+            })
+            if err != nil {
+                batchErr.Items = append(batchErr.Items, BatchItemError{Index: i, Err: err})
+            }
+        }
+        if len(batchErr.Items) != 0 {
+            return batchErr
+        }
+        return nil
+    }
+
+    // BatchItemError is the error of an item of a batch mutation.
+    type BatchItemError struct {
+        Index int
+        Err   error
+    }
+
+    // Error satisfies the error interface.
+    func (e BatchItemError) Error() string {
+        return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+    }
+
+    // BatchError is the error of a batch mutation with PartialSuccess, the
+    // items not listed have been written.
+    type BatchError struct {
+        Items []BatchItemError
+    }
+
+    // Error satisfies the error interface.
+    func (e *BatchError) Error() string {
+        msgs := make([]string, len(e.Items))
+        for i, item := range e.Items {
+            msgs[i] = item.Error()
+        }
+        return fmt.Sprintf("%d items failed: %s", len(e.Items), strings.Join(msgs, "; "))
+    }
+
+    // Extensions returns the index and message of the failed items, reported
+    // to the GraphQL client with the error.
+    func (e *BatchError) Extensions() map[string]interface{} {
+        items := make([]map[string]interface{}, len(e.Items))
+        for i, item := range e.Items {
+            items[i] = map[string]interface{}{
+                "index":   item.Index,
+                "message": item.Err.Error(),
+            }
+        }
+        return map[string]interface{}{"items": items}
+    }
+
+    // failed returns whether the item at index failed.
+    func (e *BatchError) failed(index int) bool {
+        for _, item := range e.Items {
+            if item.Index == index {
+                return true
+            }
+        }
+        return false
+    }
+    {{- if (enabledataloader) }}
+
+    // DefaultLoaderWait is the default time the loader collects foreign keys.
+    const DefaultLoaderWait = 2 * time.Millisecond
+
+    // loaderMaxBatch is the maximum number of keys queried in one batch.
+    const loaderMaxBatch = 500
+
+    // withLoader returns the extensions with a new loader, shared by the
+    // resolvers below a root field so that their foreign key lookups are batched.
+    func (ext resolverExtensions) withLoader() resolverExtensions {
+        ext.loader = newDataLoader(ext.loaderWait)
+        return ext
+    }
+
+    // loaderFetch queries the values of keys, the keys without a value are
+    // left out of the returned map.
+    type loaderFetch func(keys []interface{}) (map[interface{}]interface{}, error)
+
+    // dataLoader collects the keys requested by concurrent resolvers and
+    // queries them in batches, one per name, caching the loaded values.
+    type dataLoader struct {
+        wait    time.Duration
+        mu      sync.Mutex
+        batches map[string]*loaderBatch
+        cache   map[string]map[interface{}]interface{}
+    }
+
+    // loaderBatch is a set of keys queried together.
+    type loaderBatch struct {
+        once    sync.Once
+        keys    []interface{}
+        seen    map[interface{}]struct{}
+        done    chan struct{}
+        results map[interface{}]interface{}
+        err     error
+    }
+
+    func newDataLoader(wait time.Duration) *dataLoader {
+        return &dataLoader{
+            wait:    wait,
+            batches: map[string]*loaderBatch{},
+            cache:   map[string]map[interface{}]interface{}{},
+        }
+    }
+
+    // load returns the value of key, queried with fetch along with the other
+    // keys requested under the same name within the wait time.
+    func (l *dataLoader) load(name string, key interface{}, fetch loaderFetch) (interface{}, error) {
+        l.mu.Lock()
+        if v, ok := l.cache[name][key]; ok {
+            l.mu.Unlock()
+            return v, nil
+        }
+
+        b, ok := l.batches[name]
+        if !ok {
+            b = &loaderBatch{seen: map[interface{}]struct{}{}, done: make(chan struct{})}
+            l.batches[name] = b
+            time.AfterFunc(l.wait, func() { l.dispatch(name, b, fetch) })
+        }
+        if _, ok := b.seen[key]; !ok {
+            b.seen[key] = struct{}{}
+            b.keys = append(b.keys, key)
+        }
+        if len(b.keys) >= loaderMaxBatch {
+            delete(l.batches, name)
+            go l.dispatch(name, b, fetch)
+        }
+        l.mu.Unlock()
+
+        <-b.done
+        if b.err != nil {
+            return nil, b.err
+        }
+        return b.results[key], nil
+    }
+
+    // dispatch queries the keys of the batch b once.
+    func (l *dataLoader) dispatch(name string, b *loaderBatch, fetch loaderFetch) {
+        b.once.Do(func() {
+            l.mu.Lock()
+            if l.batches[name] == b {
+                delete(l.batches, name)
+            }
+            l.mu.Unlock()
+
+            b.results, b.err = fetch(b.keys)
+            if b.err == nil {
+                l.mu.Lock()
+                cache, ok := l.cache[name]
+                if !ok {
+                    cache = map[interface{}]interface{}{}
+                    l.cache[name] = cache
+                }
+                for k, v := range b.results {
+                    cache[k] = v
+                }
+                l.mu.Unlock()
+            }
+            close(b.done)
+        })
+    }
+    {{- end }}
+
     // BuildSchemaString build root schema string
     func (r *RootResolver) BuildSchemaString(extraQueries, extraMutations, extraTypes string) string {
         return `
@@ -344,15 +1618,14 @@ func New(driver string, c Config) (Storage, error) {
     ` + 
     {{- range $type, $_ := .TypeMap }}
         r.Get{{ $type }}Types() +
+    {{- end }}
+    {{- range .Enums }}
+        graphQL{{ .Name }}Enum +
     {{- end }}
         GraphQLCommonTypes +
         extraTypes
     }
 
-    func encodeCursor(typeName string, id int) graphql.ID {
-        return graphql.ID(base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d", typeName, id))))
-    }
-
     // EventRecorder is event recorder
     type EventRecorder interface {
         RecordEvent(ctx context.Context, resource, action string, args interface{}) error
@@ -0,0 +1,1667 @@
+{{- define "memorytable" -}}
+    {{- $short := (shortname .Name "row" "column") }}
+
+// newMemory{{ .Name }}Table returns the in-memory table of the {{ .Name }} rows.
+func newMemory{{ .Name }}Table() *memoryTable {
+    return &memoryTable{
+        name: `{{ schema .Schema .Table.TableName }}`,
+        table: `{{ .Table.TableName }}`,
+    {{- if .PrimaryKey }}
+        pk: []string{ {{- range $i, $f := .PrimaryKeyFields }}{{ if $i }}, {{ end }}"{{ $f.Col.ColumnName }}"{{ end -}} },
+        {{- if not .Table.ManualPk }}
+        seq: "{{ .PrimaryKey.Col.ColumnName }}",
+        {{- end }}
+    {{- end }}
+        columns: []string{ {{- range $i, $f := .Fields }}{{ if $i }}, {{ end }}"{{ $f.Col.ColumnName }}"{{ end -}} },
+        deleted: "{{ deletedcolumn . }}",
+        softDelete: {{ softdelete . }},
+        uniques: []memoryUnique{
+    {{- range .Indexes }}
+        {{- if and .Index.IsUnique (not .Index.IsPrimary) }}
+            {name: "{{ .Index.IndexName }}", columns: []string{ {{- range $i, $f := .Fields }}{{ if $i }}, {{ end }}"{{ $f.Col.ColumnName }}"{{ end -}} }},
+        {{- end }}
+    {{- end }}
+        },
+        foreignKeys: []memoryForeignKey{
+    {{- range .ForeignKeys }}
+            {name: "{{ .ForeignKey.ForeignKeyName }}", column: "{{ .Field.Col.ColumnName }}", refTable: `{{ schema .RefType.Schema .RefType.Table.TableName }}`, refColumn: "{{ .RefField.Col.ColumnName }}"},
+    {{- end }}
+        },
+        new: func() interface{} {
+            return &{{ .Name }}{}
+        },
+        clone: func(row interface{}) interface{} {
+            {{ $short }} := *row.(*{{ .Name }})
+    {{- if .PrimaryKey }}
+            {{ $short }}._exists, {{ $short }}._deleted = true, false
+    {{- end }}
+            return &{{ $short }}
+        },
+        field: func(row interface{}, column string) interface{} {
+            {{ $short }} := row.(*{{ .Name }})
+            switch column {
+    {{- range .Fields }}
+            case "{{ .Col.ColumnName }}"{{ if ne (colname .Col) .Col.ColumnName }}, {{ printf "%q" (colname .Col) }}{{ end }}:
+                return &{{ $short }}.{{ .Name }}
+    {{- end }}
+            }
+            panic("unknown column " + column + " of {{ schema .Schema .Table.TableName }}")
+        },
+    }
+}
+{{- end -}}
+
+{{- define "memorymethods" -}}
+    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "ctx" "xoLog" "s" "t" "i" "rows" "row" "order") -}}
+    {{- $t := . -}}
+    {{- $table := (schema .Schema .Table.TableName) -}}
+    {{- $deleted := (deletedcolumn .) -}}
+    {{- $created := (createdcolumn .) -}}
+    {{- $changed := (changedcolumn .) -}}
+    {{- if .PrimaryKey }}
+// Insert{{ .Name }} inserts the {{ .Name }} to the memory table
+{{- if not .Table.ManualPk }}, setting its
+// primary key from the sequence of the table{{ end }}.
+func (s *MemoryStorage) Insert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    // if already exist, bail
+    if {{ $short }}._exists {
+        return errors.New("insert failed: already exists")
+    }
+
+    // run the BeforeInsert hook
+    if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+        return err
+    }
+
+    s.mu.Lock()
+    err := s.insert(s.tables[`{{ $table }}`], {{ $short }})
+    s.mu.Unlock()
+    if err != nil {
+        return err
+    }
+
+    // set existence
+    {{ $short }}._exists = true
+
+    return xoAfterInsert({{ dbarg }}, {{ $short }})
+}
+
+// Insert{{ .Name }}ByFields inserts the {{ .Name }} to the memory table.
+func (s *MemoryStorage) Insert{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    // run the BeforeInsert hook
+    if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+        return err
+    }
+
+    s.mu.Lock()
+    err := s.insert(s.tables[`{{ $table }}`], {{ $short }})
+    s.mu.Unlock()
+    if err != nil {
+        return err
+    }
+
+    // set existence
+    {{ $short }}._exists = true
+
+    return xoAfterInsert({{ dbarg }}, {{ $short }})
+}
+
+// Insert{{ .Name }}s inserts the {{ .Name }}s to the memory table, none of them
+// when one fails.
+func (s *MemoryStorage) Insert{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error {
+    // run the BeforeInsert hooks
+    for _, {{ $short }} := range {{ $short }}s {
+        if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+            return err
+        }
+    }
+
+    if err := s.insert{{ .Name }}s({{ $short }}s); err != nil {
+        return err
+    }
+
+    // set existence
+    for _, {{ $short }} := range {{ $short }}s {
+        {{ $short }}._exists = true
+    }
+
+    // run the AfterInsert hooks
+    for _, {{ $short }} := range {{ $short }}s {
+        if err := xoAfterInsert({{ dbarg }}, {{ $short }}); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// insert{{ .Name }}s inserts the {{ .Name }}s to the memory table, none of them
+// when one fails.
+func (s *MemoryStorage) insert{{ .Name }}s({{ $short }}s []*{{ .Name }}) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    t := s.tables[`{{ $table }}`]
+    rows, last := t.rows, t.last
+    for _, {{ $short }} := range {{ $short }}s {
+        if err := s.insert(t, {{ $short }}); err != nil {
+            t.rows, t.last = rows, last
+            return err
+        }
+    }
+    return nil
+}
+
+// Delete{{ .Name }} deletes the {{ .Name }} from the memory table.
+{{- if (softdelete .) }}
+// The rows are soft deleted, setting their {{ $deleted }} column.
+{{- end }}
+func (s *MemoryStorage) Delete{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    // if doesn't exist, bail
+    if !{{ $short }}._exists {
+        return nil
+    }
+
+    // if deleted, bail
+    if {{ $short }}._deleted {
+        return nil
+    }
+
+    // run the BeforeDelete hook
+    if err := xoBeforeDelete({{ dbarg }}, {{ $short }}); err != nil {
+        return err
+    }
+
+    s.mu.Lock()
+    err := s.delete(s.tables[`{{ $table }}`], {{ $short }})
+    s.mu.Unlock()
+    if err != nil {
+        return err
+    }
+
+    // set deleted
+    {{ $short }}._deleted = true
+
+    return xoAfterDelete({{ dbarg }}, {{ $short }})
+}
+
+// Delete{{ .Name }}s deletes the {{ .Name }}s from the memory table, none of
+// them when one fails.
+{{- if (softdelete .) }}
+// The rows are soft deleted, setting their {{ $deleted }} column.
+{{- end }}
+func (s *MemoryStorage) Delete{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error {
+    if len({{ $short }}s) == 0 {
+        return nil
+    }
+
+    // run the BeforeDelete hooks
+    for _, {{ $short }} := range {{ $short }}s {
+        if err := xoBeforeDelete({{ dbarg }}, {{ $short }}); err != nil {
+            return err
+        }
+    }
+
+    rows := make([]interface{}, len({{ $short }}s))
+    for i, {{ $short }} := range {{ $short }}s {
+        rows[i] = {{ $short }}
+    }
+    s.mu.Lock()
+    err := s.delete(s.tables[`{{ $table }}`], rows...)
+    s.mu.Unlock()
+    if err != nil {
+        return err
+    }
+
+    // set deleted
+    for _, {{ $short }} := range {{ $short }}s {
+        {{ $short }}._deleted = true
+    }
+
+    // run the AfterDelete hooks
+    for _, {{ $short }} := range {{ $short }}s {
+        if err := xoAfterDelete({{ dbarg }}, {{ $short }}); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+    {{- if ne (fieldnamesmulti .Fields $short .PrimaryKeyFields) "" }}
+
+// Update{{ .Name }} updates the {{ .Name }} in the memory table.
+func (s *MemoryStorage) Update{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    // if doesn't exist, bail
+    if !{{ $short }}._exists {
+        return errors.New("update failed: does not exist")
+    }
+
+    // if deleted, bail
+    if {{ $short }}._deleted {
+        return errors.New("update failed: marked for deletion")
+    }
+
+    // run the BeforeUpdate hook
+    if err := xoBeforeUpdate({{ dbarg }}, {{ $short }}); err != nil {
+        return err
+    }
+
+    s.mu.Lock()
+    _, err := s.update(s.tables[`{{ $table }}`], {{ $short }})
+    s.mu.Unlock()
+    if err != nil {
+        return err
+    }
+
+    return xoAfterUpdate({{ dbarg }}, {{ $short }})
+}
+
+// Update{{ .Name }}ByFields sets the fields of the {{ .Name }} in the memory
+// table to params, and reads its retCols columns into retVars.
+func (s *MemoryStorage) Update{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}, fields, retCols []string, params, retVars []interface{}) error {
+    // run the BeforeUpdate hook, saving the columns it changes
+    fields, retCols, params, retVars, err := xoBeforeUpdateFields({{ dbarg }}, {{ $short }}, fields, retCols, params, retVars)
+    if err != nil {
+        return err
+    }
+
+    s.mu.Lock()
+    err = s.updateFields(s.tables[`{{ $table }}`], {{ $short }}, fields, retCols, params, retVars)
+    s.mu.Unlock()
+    if err != nil {
+        return err
+    }
+
+    return xoAfterUpdate({{ dbarg }}, {{ $short }})
+}
+
+// Save{{ .Name }} saves the {{ .Name }} to the memory table.
+func (s *MemoryStorage) Save{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    if {{ $short }}.Exists() {
+        return s.Update{{ .Name }}({{ dbarg }}, {{ $short }})
+    }
+
+    return s.Insert{{ .Name }}({{ dbarg }}, {{ $short }})
+}
+
+// Upsert{{ .Name }} performs an upsert for {{ .Name }}: it inserts the {{ .Name }},
+// or overwrites the row with the same primary key.
+func (s *MemoryStorage) Upsert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    // run the BeforeInsert hook, an upsert writes the row as it is inserted
+    if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+        return err
+    }
+
+    s.mu.Lock()
+    t := s.tables[`{{ $table }}`]
+    ok, err := s.update(t, {{ $short }})
+    if err == nil && !ok {
+        err = s.insertKey(t, {{ $short }})
+    }
+    s.mu.Unlock()
+    if err != nil {
+        return err
+    }
+
+    // set existence
+    {{ $short }}._exists = true
+
+    return xoAfterInsert({{ dbarg }}, {{ $short }})
+}
+    {{- end }}
+    {{- range $ix := .Indexes }}
+        {{- if and $ix.Index.IsUnique (not $ix.Index.IsPrimary) }}
+
+// Upsert{{ $ix.FuncName }} performs an upsert for {{ $.Name }} on the unique
+// index '{{ $ix.Index.IndexName }}': it inserts the {{ $.Name }}, or when a row
+// with the same {{ colnames $ix.Fields }} exists, overwrites its columns chosen by opts.
+func (s *MemoryStorage) Upsert{{ $ix.FuncName }}({{ dbparam }}, {{ $short }} *{{ $.Name }}, opts *UpsertOptions) error {
+    err := opts.check({{ range $i, $f := $.Fields }}{{ if $i }}, {{ end }}"{{ $f.Col.ColumnName }}"{{ end }})
+    if err != nil {
+        return err
+    }
+
+    // run the BeforeInsert hook, an upsert writes the row as it is inserted
+    if err := xoBeforeInsert({{ dbarg }}, {{ $short }}); err != nil {
+        return err
+    }
+
+    s.mu.Lock()
+    err = s.upsert(s.tables[`{{ $table }}`], {{ $short }}, []string{ {{- range $i, $f := $ix.Fields }}{{ if $i }}, {{ end }}"{{ $f.Col.ColumnName }}"{{ end -}} }, opts)
+    s.mu.Unlock()
+    if err != nil {
+        return err
+    }
+
+    // set existence
+    {{ $short }}._exists = true
+
+    return xoAfterInsert({{ dbarg }}, {{ $short }})
+}
+        {{- end }}
+    {{- end }}
+    {{- end }}
+    {{- if $created }}
+
+// GetMostRecent{{ .Name }} returns n most recent rows from '{{ .Table.TableName }}',
+// ordered by "{{ $created }}" in descending order.
+func (s *MemoryStorage) GetMostRecent{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    t := s.tables[`{{ $table }}`]
+    rows, err := s.recent(t, "{{ $created }}", n)
+    if err != nil {
+        return nil, err
+    }
+
+    res := make([]*{{ .Name }}, len(rows))
+    for i, row := range rows {
+        res[i] = row.(*{{ .Name }})
+    }
+    return res, nil
+}
+    {{- end }}
+    {{- if $changed }}
+
+// GetMostRecentChanged{{ .Name }} returns n most recent rows from '{{ .Table.TableName }}',
+// ordered by "{{ $changed }}" in descending order.
+func (s *MemoryStorage) GetMostRecentChanged{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    t := s.tables[`{{ $table }}`]
+    rows, err := s.recent(t, "{{ $changed }}", n)
+    if err != nil {
+        return nil, err
+    }
+
+    res := make([]*{{ .Name }}, len(rows))
+    for i, row := range rows {
+        res[i] = row.(*{{ .Name }})
+    }
+    return res, nil
+}
+    {{- end }}
+
+// GetAll{{ .Name }} returns all rows from '{{ .Table.TableName }}', based on the {{ .Name }}QueryArguments.
+// If the {{ .Name }}QueryArguments is nil, it will use the default {{ .Name }}QueryArguments instead.
+func (s *MemoryStorage) GetAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) ([]*{{ .Name }}, error) {
+    queryArgs = Apply{{ .Name }}QueryArgsDefaults(queryArgs)
+    var filterArgs *filterArguments
+{{- if (existsqlfilter .) }}
+    if queryArgs.filterArgs == nil {
+        filterArgs, err := get{{ .Name }}Filter(queryArgs.Where)
+        if err != nil {
+            return nil, errors.Wrap(err, "unable to get {{ .Name }} filter")
+        }
+        queryArgs.filterArgs = filterArgs
+    }
+    filterArgs = queryArgs.filterArgs
+{{- end }}
+
+    order, err := get{{ .Name }}Order(queryArgs)
+    if err != nil {
+        return nil, err
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    t := s.tables[`{{ $table }}`]
+    rows, err := s.selectRows(t, filterArgs, *queryArgs.Dead, order, nil)
+    if err != nil {
+        return nil, err
+    }
+    rows, err = s.page(t, "{{ .Name }}", rows, order, &queryArgs.Cursor)
+    if err != nil {
+        return nil, err
+    }
+
+    res := make([]*{{ .Name }}, len(rows))
+    for i, row := range rows {
+        res[i] = row.(*{{ .Name }})
+    }
+    return res, nil
+}
+
+// CountAll{{ .Name }} returns a count of all rows from '{{ .Table.TableName }}'
+func (s *MemoryStorage) CountAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) (int, error) {
+    queryArgs = Apply{{ .Name }}QueryArgsDefaults(queryArgs)
+    var filterArgs *filterArguments
+{{- if (existsqlfilter .) }}
+    if queryArgs.filterArgs == nil {
+        filterArgs, err := get{{ .Name }}Filter(queryArgs.Where)
+        if err != nil {
+            return 0, errors.Wrap(err, "unable to get {{ .Name }} filter")
+        }
+        queryArgs.filterArgs = filterArgs
+    }
+    filterArgs = queryArgs.filterArgs
+{{- end }}
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    rows, err := s.selectRows(s.tables[`{{ $table }}`], filterArgs, *queryArgs.Dead, nil, nil)
+    if err != nil {
+        return -1, err
+    }
+    return len(rows), nil
+}
+
+// Aggregate{{ .Name }} returns the aggregates of the rows from '{{ .Table.TableName }}' grouped by the groupBy columns,
+// based on the {{ .Name }}QueryArguments.
+func (s *MemoryStorage) Aggregate{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments, groupBy []{{ .Name }}OrderField) ([]*{{ .Name }}Aggregate, error) {
+    queryArgs = Apply{{ .Name }}QueryArgsDefaults(queryArgs)
+    var filterArgs *filterArguments
+{{- if (existsqlfilter .) }}
+    if queryArgs.filterArgs == nil {
+        filterArgs, err := get{{ .Name }}Filter(queryArgs.Where)
+        if err != nil {
+            return nil, errors.Wrap(err, "unable to get {{ .Name }} filter")
+        }
+        queryArgs.filterArgs = filterArgs
+    }
+    filterArgs = queryArgs.filterArgs
+{{- end }}
+    columns, dests, err := get{{ .Name }}GroupBy(groupBy)
+    if err != nil {
+        return nil, err
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    t := s.tables[`{{ $table }}`]
+    rows, err := s.selectRows(t, filterArgs, *queryArgs.Dead, nil, nil)
+    if err != nil {
+        return nil, err
+    }
+
+    var res []*{{ .Name }}Aggregate
+    for _, group := range t.groups(rows, columns) {
+        a := &{{ .Name }}Aggregate{Group: &{{ .Name }}{}, Count: len(group)}
+        if len(group) > 0 {
+            for i, dest := range dests(a.Group) {
+                if err := memoryAssign(dest, t.value(group[0], columns[i])); err != nil {
+                    return nil, err
+                }
+            }
+        }
+        err := memoryAssignAll(
+        {{- range (aggregatefields . "Number") }}
+            &a.Sum.{{ .Name }}, t.sum(group, "{{ .Col.ColumnName }}"),
+            &a.Avg.{{ .Name }}, t.avg(group, "{{ .Col.ColumnName }}"),
+        {{- end }}
+        {{- range (aggregatefields . "Number" "Time") }}
+            &a.Min.{{ .Name }}, t.extreme(group, "{{ .Col.ColumnName }}", -1),
+            &a.Max.{{ .Name }}, t.extreme(group, "{{ .Col.ColumnName }}", 1),
+        {{- end }}
+        )
+        if err != nil {
+            return nil, err
+        }
+        res = append(res, a)
+    }
+
+    return res, nil
+}
+    {{- range .ForeignKeys }}
+        {{- $fnname := (print (plural $t.Name) "By" .Field.Name "FK") -}}
+        {{- $param := (togqlname .Field.Name) -}}
+        {{- if not (isdup $fnname "memory") }}
+
+// {{ $fnname }} retrieves rows from {{ $table }} by foreign key {{ .Field.Name }}.
+// Generated from foreign key {{ .Name }}.
+func (s *MemoryStorage) {{ $fnname }}({{ dbparam }}, {{ $param }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) ([]*{{ $t.Name }}, error) {
+    queryArgs = Apply{{ $t.Name }}QueryArgsDefaults(queryArgs)
+    order, err := get{{ $t.Name }}Order(queryArgs)
+    if err != nil {
+        return nil, err
+    }
+
+    var filterArgs *filterArguments
+{{- if (existsqlfilter .Type) }}
+    if queryArgs.filterArgs != nil {
+        if queryArgs.filterArgs.hasField("{{ .Field.Col.ColumnName }}") {
+            return nil, fmt.Errorf("already have condition on field:{{ .Field.Name }}, because of foregin key {{ .Name }}")
+        }
+        filterArgs = queryArgs.filterArgs
+    }
+{{- end }}
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    t := s.tables[`{{ $table }}`]
+    rows, err := s.selectRows(t, filterArgs, *queryArgs.Dead, order, t.equal("{{ .Field.Col.ColumnName }}", {{ $param }}))
+    if err != nil {
+        return nil, err
+    }
+    rows, err = s.page(t, "{{ $t.Name }}", rows, order, &queryArgs.Cursor)
+    if err != nil {
+        return nil, err
+    }
+
+    res := make([]*{{ $t.Name }}, len(rows))
+    for i, row := range rows {
+        res[i] = row.(*{{ $t.Name }})
+    }
+    return res, nil
+}
+
+// Count{{ $fnname }} count rows from {{ $table }} by foreign key {{ .Field.Name }}.
+// Generated from foreign key {{ .Name }}.
+func (s *MemoryStorage) Count{{ $fnname }}({{ dbparam }}, {{ $param }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) (int, error) {
+    queryArgs = Apply{{ $t.Name }}QueryArgsDefaults(queryArgs)
+
+    var filterArgs *filterArguments
+{{- if (existsqlfilter .Type) }}
+    if queryArgs.filterArgs != nil {
+        if queryArgs.filterArgs.hasField("{{ .Field.Col.ColumnName }}") {
+            return -1, fmt.Errorf("already have condition on field:{{ .Field.Name }}, because of foregin key {{ .Name }}")
+        }
+        filterArgs = queryArgs.filterArgs
+    }
+{{- end }}
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    t := s.tables[`{{ $table }}`]
+    rows, err := s.selectRows(t, filterArgs, *queryArgs.Dead, nil, t.equal("{{ .Field.Col.ColumnName }}", {{ $param }}))
+    if err != nil {
+        return -1, err
+    }
+    return len(rows), nil
+}
+        {{- end }}
+    {{- end }}
+{{- end -}}
+
+// MemoryStorage is a Storage keeping the rows of the tables in memory, for
+// running the resolvers without a database. It's the memory driver of New.
+//
+// It enforces the primary keys, the unique indexes and the foreign keys of
+// the tables, referenced rows cannot be deleted, and it soft deletes the rows
+// like the database drivers. The columns are not given their database
+// defaults. The db handles passed to its methods are not used, and WithTx
+// restores the rows when fn fails, whatever the other goroutines wrote.
+type MemoryStorage struct {
+    mu     sync.Mutex
+    tables map[string]*memoryTable
+}
+
+// NewMemoryStorage returns a MemoryStorage with empty tables.
+func NewMemoryStorage() *MemoryStorage {
+    return &MemoryStorage{
+        tables: map[string]*memoryTable{
+{{- range .Tables }}
+            `{{ schema .Schema .Table.TableName }}`: newMemory{{ .Name }}Table(),
+{{- end }}
+{{- range .Views }}
+            `{{ schema .Schema .Table.TableName }}`: newMemory{{ .Name }}Table(),
+{{- end }}
+        },
+    }
+}
+
+// WithTx runs fn on db, the rows are restored when fn fails.
+func (s *MemoryStorage) WithTx(ctx context.Context, db {{ dbtype }}, fn func(tx {{ dbtype }}) error) error {
+    s.mu.Lock()
+    saved := make(map[string]memoryTable, len(s.tables))
+    for name, t := range s.tables {
+        c := *t
+        c.rows = append([]interface{}(nil), t.rows...)
+        saved[name] = c
+    }
+    s.mu.Unlock()
+
+    if err := fn(db); err != nil {
+        s.mu.Lock()
+        for name, t := range saved {
+            *s.tables[name] = t
+        }
+        s.mu.Unlock()
+        return err
+    }
+    return nil
+}
+
+// memoryUnique is a unique index of a memory table.
+type memoryUnique struct {
+    name    string
+    columns []string
+}
+
+// memoryForeignKey is a foreign key of a memory table.
+type memoryForeignKey struct {
+    name      string
+    column    string
+    refTable  string
+    refColumn string
+}
+
+// memoryTable holds the rows of a table, in insertion order. The rows are
+// never modified once stored but replaced, so that copying rows is enough
+// to save them.
+type memoryTable struct {
+    // name is the name of the table in the storage, table the one of the
+    // errors.
+    name        string
+    table       string
+    columns     []string
+    pk          []string
+    seq         string
+    deleted     string
+    softDelete  bool
+    uniques     []memoryUnique
+    foreignKeys []memoryForeignKey
+
+    // new returns an empty row, clone returns a copy of row marked as
+    // existing, and field returns the pointer to the field of the column of
+    // row.
+    new   func() interface{}
+    clone func(row interface{}) interface{}
+    field func(row interface{}, column string) interface{}
+
+    last int64
+    rows []interface{}
+}
+
+// value returns the value of the column of row.
+func (t *memoryTable) value(row interface{}, column string) interface{} {
+    return memoryValue(t.field(row, column))
+}
+
+// find returns the index of the row with the primary key of row, -1 if
+// there is none.
+func (t *memoryTable) find(row interface{}) int {
+    if len(t.pk) == 0 {
+        return -1
+    }
+
+    for i, r := range t.rows {
+        found := true
+        for _, column := range t.pk {
+            if !memoryEqual(t.value(r, column), t.value(row, column)) {
+                found = false
+                break
+            }
+        }
+        if found {
+            return i
+        }
+    }
+    return -1
+}
+
+// lookup returns copies of the rows whose columns equal values.
+func (t *memoryTable) lookup(columns []string, values ...interface{}) []interface{} {
+    var res []interface{}
+    for _, row := range t.rows {
+        found := true
+        for i, column := range columns {
+            if !memoryEqual(t.value(row, column), memoryValue(values[i])) {
+                found = false
+                break
+            }
+        }
+        if found {
+            res = append(res, t.clone(row))
+        }
+    }
+    return res
+}
+
+// equal returns the predicate of the rows whose column equals v.
+func (t *memoryTable) equal(column string, v interface{}) func(row interface{}) bool {
+    v = memoryValue(v)
+    return func(row interface{}) bool {
+        return memoryEqual(t.value(row, column), v)
+    }
+}
+
+// conflict returns the name of the unique index, empty for the primary key,
+// on which row conflicts with a row of t but the one at skip, and its columns.
+func (t *memoryTable) conflict(row interface{}, skip int) (string, []string) {
+    keys := append([]memoryUnique{ {columns: t.pk} }, t.uniques...)
+    for _, key := range keys {
+        if len(key.columns) == 0 {
+            continue
+        }
+        for i, r := range t.rows {
+            if i == skip {
+                continue
+            }
+            found := true
+            for _, column := range key.columns {
+                // nulls are distinct
+                if !memoryEqual(t.value(r, column), t.value(row, column)) {
+                    found = false
+                    break
+                }
+            }
+            if found {
+                return key.name, key.columns
+            }
+        }
+    }
+    return "", nil
+}
+
+// check returns an error when row, to be stored at skip or appended if -1,
+// violates a primary key, unique index or foreign key.
+func (s *MemoryStorage) check(t *memoryTable, row interface{}, skip int) error {
+    if name, columns := t.conflict(row, skip); columns != nil {
+        return xoUniqueViolation(t.table, name, columns, nil)
+    }
+
+    for _, fk := range t.foreignKeys {
+        v := t.value(row, fk.column)
+        ref, ok := s.tables[fk.refTable]
+        if v == nil || !ok {
+            continue
+        }
+        if len(ref.lookup([]string{fk.refColumn}, v)) == 0 {
+            return xoForeignKeyViolation(fk.name, nil)
+        }
+    }
+    return nil
+}
+
+// referenced returns an error when a row of the tables references row of t
+// through a foreign key.
+func (s *MemoryStorage) referenced(t *memoryTable, row interface{}) error {
+    for _, ref := range s.tables {
+        for _, fk := range ref.foreignKeys {
+            if fk.refTable != t.name {
+                continue
+            }
+            v := t.value(row, fk.refColumn)
+            if v != nil && len(ref.lookup([]string{fk.column}, v)) != 0 {
+                return xoForeignKeyViolation(fk.name, nil)
+            }
+        }
+    }
+    return nil
+}
+
+// insert inserts a copy of row to t, after setting the sequence column of
+// row when t has one.
+func (s *MemoryStorage) insert(t *memoryTable, row interface{}) error {
+    if t.seq == "" {
+        return s.insertKey(t, row)
+    }
+
+    c := t.clone(row)
+    if err := memoryAssign(t.field(c, t.seq), t.last+1); err != nil {
+        return err
+    }
+    if err := s.check(t, c, -1); err != nil {
+        return err
+    }
+
+    t.last++
+    t.rows = append(t.rows, c)
+    return memoryAssign(t.field(row, t.seq), t.last)
+}
+
+// insertKey inserts a copy of row to t with its primary key.
+func (s *MemoryStorage) insertKey(t *memoryTable, row interface{}) error {
+    c := t.clone(row)
+    if err := s.check(t, c, -1); err != nil {
+        return err
+    }
+
+    // the sequence goes on after the primary keys inserted
+    if t.seq != "" {
+        if n, ok := t.value(c, t.seq).(int64); ok && n > t.last {
+            t.last = n
+        }
+    }
+    t.rows = append(t.rows, c)
+    return nil
+}
+
+// update replaces the row of t with the primary key of row by a copy of
+// row, it returns false when there is none.
+func (s *MemoryStorage) update(t *memoryTable, row interface{}) (bool, error) {
+    i := t.find(row)
+    if i < 0 {
+        return false, nil
+    }
+
+    c := t.clone(row)
+    if err := s.check(t, c, i); err != nil {
+        return false, err
+    }
+
+    t.rows[i] = c
+    return true, nil
+}
+
+// updateFields sets the fields of the row of t with the primary key of row to
+// params, and reads its retCols columns into retVars. It returns
+// ErrNotFound when there is no such row.
+func (s *MemoryStorage) updateFields(t *memoryTable, row interface{}, fields, retCols []string, params, retVars []interface{}) error {
+    i := t.find(row)
+    if i < 0 {
+        return ErrNotFound
+    }
+
+    c := t.clone(t.rows[i])
+    for j, field := range fields {
+        if err := memoryAssign(t.field(c, field), params[j]); err != nil {
+            return errors.Wrapf(err, "unable to set %s", field)
+        }
+    }
+    if err := s.check(t, c, i); err != nil {
+        return err
+    }
+    t.rows[i] = c
+
+    for j, column := range retCols {
+        if err := memoryAssign(retVars[j], t.value(c, column)); err != nil {
+            return errors.Wrapf(err, "unable to read %s", column)
+        }
+    }
+    return nil
+}
+
+// upsert inserts row to t, or when a row with the same columns exists,
+// overwrites its columns chosen by opts and reads its primary key into row.
+func (s *MemoryStorage) upsert(t *memoryTable, row interface{}, columns []string, opts *UpsertOptions) error {
+    values := make([]interface{}, len(columns))
+    for i, column := range columns {
+        values[i] = t.value(row, column)
+    }
+    existing := t.lookup(columns, values...)
+    for _, v := range values {
+        // nulls are distinct
+        if v == nil {
+            existing = nil
+        }
+    }
+    if len(existing) == 0 {
+        return s.insert(t, row)
+    }
+
+    c := existing[0]
+    keep := map[string]bool{}
+    for _, column := range append(append([]string{}, t.pk...), columns...) {
+        keep[column] = true
+    }
+    for _, column := range t.columns {
+        if keep[column] || !opts.overwrites(column) {
+            continue
+        }
+        if err := memoryAssign(t.field(c, column), t.value(row, column)); err != nil {
+            return err
+        }
+    }
+    if _, err := s.update(t, c); err != nil {
+        return err
+    }
+
+    if t.seq != "" {
+        return memoryAssign(t.field(row, t.seq), t.value(c, t.seq))
+    }
+    return nil
+}
+
+// delete deletes the rows of t with the primary keys of rows, setting their
+// deleted column when t soft deletes its rows.
+func (s *MemoryStorage) delete(t *memoryTable, rows ...interface{}) error {
+    remove := map[int]bool{}
+    for _, row := range rows {
+        i := t.find(row)
+        if i < 0 {
+            continue
+        }
+        if !t.softDelete {
+            if err := s.referenced(t, t.rows[i]); err != nil {
+                return err
+            }
+        }
+        remove[i] = true
+    }
+
+    res := t.rows[:0:0]
+    for i, row := range t.rows {
+        switch {
+        case !remove[i]:
+            res = append(res, row)
+        case t.softDelete:
+            c := t.clone(row)
+            if err := memoryAssign(t.field(c, t.deleted), time.Now()); err != nil {
+                return err
+            }
+            res = append(res, c)
+        }
+    }
+    t.rows = res
+    return nil
+}
+
+// recent returns copies of the n rows of t, dead or alive, with the greatest
+// column.
+func (s *MemoryStorage) recent(t *memoryTable, column string, n int) ([]interface{}, error) {
+    if n < 0 {
+        return nil, fmt.Errorf("invalid limit %d", n)
+    }
+
+    rows := make([]interface{}, len(t.rows))
+    for i, row := range t.rows {
+        rows[i] = t.clone(row)
+    }
+    t.sort(rows, []orderTerm{ {column: column, desc: true} })
+    if n < len(rows) {
+        rows = rows[:n]
+    }
+    return rows, nil
+}
+
+// selectRows returns copies of the rows of t matching filter and where, if
+// not nil, ordered by order. When t has a deleted column, the dead rows are
+// selected if dead is set, the live ones otherwise.
+func (s *MemoryStorage) selectRows(t *memoryTable, filter *filterArguments, dead bool, order []orderTerm, where func(row interface{}) bool) ([]interface{}, error) {
+    var res []interface{}
+    for _, row := range t.rows {
+        if t.deleted != "" && (t.value(row, t.deleted) != nil) != dead {
+            continue
+        }
+        if where != nil && !where(row) {
+            continue
+        }
+        if filter != nil {
+            ok, err := s.match(t, row, filter)
+            if err != nil {
+                return nil, err
+            }
+            if !ok {
+                continue
+            }
+        }
+        res = append(res, t.clone(row))
+    }
+    t.sort(res, order)
+    return res, nil
+}
+
+// sort sorts rows by order.
+func (t *memoryTable) sort(rows []interface{}, order []orderTerm) {
+    if len(order) == 0 {
+        return
+    }
+    sort.SliceStable(rows, func(i, j int) bool {
+        return t.compare(rows[i], rows[j], order) < 0
+    })
+}
+
+// compare compares the rows a and b by order.
+func (t *memoryTable) compare(a, b interface{}, order []orderTerm) int {
+    for _, term := range order {
+        va, vb := t.value(a, term.column), t.value(b, term.column)
+        switch {
+        case va == nil && vb == nil:
+            continue
+        case va == nil || vb == nil:
+            if (va == nil) == term.nullsFirst {
+                return -1
+            }
+            return 1
+        }
+        c, _ := memoryCompare(va, vb)
+        if term.desc {
+            c = -c
+        }
+        if c != 0 {
+            return c
+        }
+    }
+    return 0
+}
+
+// page returns the page of rows, ordered by order, requested by the offset
+// and limit or the Relay cursors of c, recording the page boundaries of the
+// latter in c.
+func (s *MemoryStorage) page(t *memoryTable, typeName string, rows []interface{}, order []orderTerm, c *Cursor) ([]interface{}, error) {
+    if !c.isKeyset() {
+        offset, limit := int(*c.Offset), int(*c.Limit)
+        if offset < 0 || limit < 0 {
+            return nil, fmt.Errorf("invalid offset %d or limit %d", offset, limit)
+        }
+        if offset > len(rows) {
+            offset = len(rows)
+        }
+        if limit > len(rows)-offset {
+            limit = len(rows) - offset
+        }
+        return rows[offset : offset+limit], nil
+    }
+
+    if c.First != nil && c.Last != nil {
+        return nil, errors.New("first and last cannot be used together")
+    }
+    for _, cursor := range []struct {
+        id     *graphql.ID
+        before bool
+    }{ {c.After, false}, {c.Before, true} } {
+        if cursor.id == nil {
+            continue
+        }
+        id, err := decodeCursor(typeName, *cursor.id)
+        if err != nil {
+            return nil, err
+        }
+        ref := t.cursorRow(id)
+        res := rows[:0:0]
+        for _, row := range rows {
+            if cmp := t.compare(row, ref, order); (cmp > 0 && !cursor.before) || (cmp < 0 && cursor.before) {
+                res = append(res, row)
+            }
+        }
+        rows = res
+    }
+
+    n := int(*c.Limit)
+    if c.First != nil {
+        n = int(*c.First)
+    } else if c.Last != nil {
+        n = int(*c.Last)
+    }
+    if n < 0 {
+        return nil, fmt.Errorf("invalid page size %d", n)
+    }
+
+    more, reverse := len(rows) > n, c.Last != nil
+    switch {
+    case !more:
+    case reverse:
+        rows = rows[len(rows)-n:]
+    default:
+        rows = rows[:n]
+    }
+    c.setPageInfo(more, reverse)
+    return rows, nil
+}
+
+// cursorRow returns the row of t with the primary key id, whether dead or
+// alive, or else a row with only the primary key set.
+func (t *memoryTable) cursorRow(id int) interface{} {
+    row := t.new()
+    if err := memoryAssign(t.field(row, t.pk[0]), id); err != nil {
+        return row
+    }
+    if i := t.find(row); i >= 0 {
+        return t.rows[i]
+    }
+    return row
+}
+
+// groups returns the groups of rows with the same columns, ordered by the
+// columns, or all the rows in one group when there are no columns.
+func (t *memoryTable) groups(rows []interface{}, columns []string) [][]interface{} {
+    if len(columns) == 0 {
+        return [][]interface{}{rows}
+    }
+
+    order := make([]orderTerm, len(columns))
+    for i, column := range columns {
+        order[i] = orderTerm{column: column}
+    }
+    t.sort(rows, order)
+
+    var res [][]interface{}
+    for i, row := range rows {
+        if i == 0 || t.compare(rows[i-1], row, order) != 0 {
+            res = append(res, nil)
+        }
+        res[len(res)-1] = append(res[len(res)-1], row)
+    }
+    return res
+}
+
+// sum returns the sum of the column of rows, nil if there are no values.
+func (t *memoryTable) sum(rows []interface{}, column string) interface{} {
+    var sum float64
+    var n int
+    for _, row := range rows {
+        if f, ok := memoryFloat(t.value(row, column)); ok {
+            sum += f
+            n++
+        }
+    }
+    if n == 0 {
+        return nil
+    }
+    return sum
+}
+
+// avg returns the average of the column of rows, nil if there are no values.
+func (t *memoryTable) avg(rows []interface{}, column string) interface{} {
+    var sum float64
+    var n int
+    for _, row := range rows {
+        if f, ok := memoryFloat(t.value(row, column)); ok {
+            sum += f
+            n++
+        }
+    }
+    if n == 0 {
+        return nil
+    }
+    return sum / float64(n)
+}
+
+// extreme returns the minimum of the column of rows if sign is -1, the
+// maximum if it's 1, nil if there are no values.
+func (t *memoryTable) extreme(rows []interface{}, column string, sign int) interface{} {
+    var res interface{}
+    for _, row := range rows {
+        v := t.value(row, column)
+        if v == nil {
+            continue
+        }
+        if c, ok := memoryCompare(v, res); res == nil || (ok && c == sign) {
+            res = v
+        }
+    }
+    return res
+}
+
+// match reports whether row of t matches the filter.
+func (s *MemoryStorage) match(t *memoryTable, row interface{}, f *filterArguments) (bool, error) {
+    conds := make([]bool, 0, len(f.filterPairs)+len(f.relations)+len(f.groups))
+    for _, pair := range f.filterPairs {
+        ok, err := memoryMatchPair(t.value(row, pair.fieldName), pair)
+        if err != nil {
+            return false, err
+        }
+        conds = append(conds, ok)
+    }
+    for _, relation := range f.relations {
+        rt, ok := s.tables[relation.table]
+        if !ok {
+            return false, errors.Errorf("unknown table %s", relation.table)
+        }
+        v := t.value(row, relation.ref)
+        found := false
+        for _, r := range rt.rows {
+            if !memoryEqual(rt.value(r, relation.column), v) {
+                continue
+            }
+            if relation.deleted != "" && rt.value(r, relation.deleted) != nil {
+                continue
+            }
+            ok, err := s.match(rt, r, relation.filter)
+            if err != nil {
+                return false, err
+            }
+            if ok {
+                found = true
+                break
+            }
+        }
+        conds = append(conds, found != relation.not)
+    }
+    for _, group := range f.groups {
+        ok, err := s.match(t, row, group)
+        if err != nil {
+            return false, err
+        }
+        conds = append(conds, ok)
+    }
+
+    res := f.conjunction != "OR"
+    for _, ok := range conds {
+        if f.conjunction == "OR" {
+            res = res || ok
+        } else {
+            res = res && ok
+        }
+    }
+    return res != f.not, nil
+}
+
+// memoryMatchPair reports whether the value v of a column matches the filter
+// pair, the comparisons with null never match.
+func memoryMatchPair(v interface{}, p *filterPair) (bool, error) {
+    switch p.option {
+    case "IS NULL":
+        return v == nil, nil
+    case "IS NOT NULL":
+        return v != nil, nil
+    case "IN", "NOT IN":
+        values := p.value.([]interface{})
+        if len(values) == 0 {
+            // nothing is in an empty list
+            return p.option == "NOT IN", nil
+        }
+        found := false
+        for _, value := range values {
+            if memoryEqual(v, memoryValue(value)) {
+                found = true
+                break
+            }
+        }
+        return v != nil && found == (p.option == "IN"), nil
+    case "BETWEEN":
+        values := p.value.([]interface{})
+        lo, ok1 := memoryCompare(v, memoryValue(values[0]))
+        hi, ok2 := memoryCompare(v, memoryValue(values[1]))
+        return ok1 && ok2 && lo >= 0 && hi <= 0, nil
+    case "LIKE", "ILIKE", "NOT LIKE", "NOT ILIKE":
+        str, ok := memoryString(v)
+        pattern, ok2 := memoryString(memoryValue(p.value))
+        if !ok || !ok2 {
+            return false, nil
+        }
+        re, err := memoryLike(pattern, strings.HasSuffix(p.option, "ILIKE"))
+        if err != nil {
+            return false, err
+        }
+        return re.MatchString(str) != strings.HasPrefix(p.option, "NOT"), nil
+    }
+
+    c, ok := memoryCompare(v, memoryValue(p.value))
+    if !ok {
+        return false, nil
+    }
+    switch p.option {
+    case "=":
+        return c == 0, nil
+    case "<>":
+        return c != 0, nil
+    case "<":
+        return c < 0, nil
+    case "<=":
+        return c <= 0, nil
+    case ">":
+        return c > 0, nil
+    case ">=":
+        return c >= 0, nil
+    }
+    return false, errors.Errorf("unsupported filter option %s", p.option)
+}
+
+// memoryLike returns the regexp of the LIKE pattern, % matching any string,
+// _ any character and \ escaping the next one.
+func memoryLike(pattern string, insensitive bool) (*regexp.Regexp, error) {
+    var buf strings.Builder
+    buf.WriteString("(?s)")
+    if insensitive {
+        buf.WriteString("(?i)")
+    }
+    buf.WriteString("^")
+    escaped := false
+    for _, r := range pattern {
+        switch {
+        case escaped:
+            buf.WriteString(regexp.QuoteMeta(string(r)))
+            escaped = false
+        case r == '\\':
+            escaped = true
+        case r == '%':
+            buf.WriteString(".*")
+        case r == '_':
+            buf.WriteString(".")
+        default:
+            buf.WriteString(regexp.QuoteMeta(string(r)))
+        }
+    }
+    buf.WriteString("$")
+    return regexp.Compile(buf.String())
+}
+
+// memoryValue returns the value of v as stored in a column: nil, int64,
+// float64, bool, []byte, string or time.Time, or v itself when it has no
+// such value.
+func memoryValue(v interface{}) interface{} {
+    switch x := v.(type) {
+    case graphql.Time:
+        v = x.Time
+    case *graphql.Time:
+        if x == nil {
+            return nil
+        }
+        v = x.Time
+    }
+
+    dv, err := driver.DefaultParameterConverter.ConvertValue(v)
+    if err != nil {
+        return v
+    }
+    return dv
+}
+
+// memoryString returns the string of the value v.
+func memoryString(v interface{}) (string, bool) {
+    switch x := v.(type) {
+    case string:
+        return x, true
+    case []byte:
+        return string(x), true
+    }
+    return "", false
+}
+
+// memoryFloat returns the number of the value v, parsing strings.
+func memoryFloat(v interface{}) (float64, bool) {
+    switch x := v.(type) {
+    case int64:
+        return float64(x), true
+    case float64:
+        return x, true
+    }
+    if str, ok := memoryString(v); ok {
+        f, err := strconv.ParseFloat(str, 64)
+        return f, err == nil
+    }
+    return 0, false
+}
+
+// memoryCompare compares the values a and b, it reports false when they are
+// null or cannot be compared.
+func memoryCompare(a, b interface{}) (int, bool) {
+    if a == nil || b == nil {
+        return 0, false
+    }
+
+    switch x := a.(type) {
+    case int64:
+        if y, ok := b.(int64); ok {
+            switch {
+            case x < y:
+                return -1, true
+            case x > y:
+                return 1, true
+            }
+            return 0, true
+        }
+    case bool:
+        y, ok := b.(bool)
+        switch {
+        case !ok:
+            return 0, false
+        case x == y:
+            return 0, true
+        case y:
+            return -1, true
+        }
+        return 1, true
+    case time.Time:
+        y, ok := b.(time.Time)
+        switch {
+        case !ok:
+            return 0, false
+        case x.Before(y):
+            return -1, true
+        case x.After(y):
+            return 1, true
+        }
+        return 0, true
+    }
+
+    xs, ok1 := memoryString(a)
+    ys, ok2 := memoryString(b)
+    if ok1 && ok2 {
+        return strings.Compare(xs, ys), true
+    }
+
+    xf, ok1 := memoryFloat(a)
+    yf, ok2 := memoryFloat(b)
+    if ok1 && ok2 {
+        switch {
+        case xf < yf:
+            return -1, true
+        case xf > yf:
+            return 1, true
+        }
+        return 0, true
+    }
+    return 0, false
+}
+
+// memoryEqual reports whether the values a and b are equal and not null.
+func memoryEqual(a, b interface{}) bool {
+    c, ok := memoryCompare(a, b)
+    return ok && c == 0
+}
+
+// memoryAssign sets the value pointed to by dest to v, like a column scanned
+// into dest.
+func memoryAssign(dest, v interface{}) error {
+    v = memoryValue(v)
+    if scanner, ok := dest.(sql.Scanner); ok {
+        return scanner.Scan(v)
+    }
+
+    dv := reflect.ValueOf(dest).Elem()
+    if v == nil {
+        dv.Set(reflect.Zero(dv.Type()))
+        return nil
+    }
+    if dv.Kind() == reflect.Ptr {
+        p := reflect.New(dv.Type().Elem())
+        if err := memoryAssign(p.Interface(), v); err != nil {
+            return err
+        }
+        dv.Set(p)
+        return nil
+    }
+
+    sv := reflect.ValueOf(v)
+    switch {
+    case sv.Type().AssignableTo(dv.Type()):
+        dv.Set(sv)
+        return nil
+    case sv.Type().ConvertibleTo(dv.Type()) && sv.Kind() == dv.Kind():
+        dv.Set(sv.Convert(dv.Type()))
+        return nil
+    }
+
+    switch dv.Kind() {
+    case reflect.String:
+        switch x := v.(type) {
+        case []byte:
+            dv.SetString(string(x))
+            return nil
+        case int64, float64, bool:
+            dv.SetString(fmt.Sprint(x))
+            return nil
+        }
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        if f, ok := memoryFloat(v); ok {
+            dv.SetInt(int64(f))
+            return nil
+        }
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        if f, ok := memoryFloat(v); ok {
+            dv.SetUint(uint64(f))
+            return nil
+        }
+    case reflect.Float32, reflect.Float64:
+        if f, ok := memoryFloat(v); ok {
+            dv.SetFloat(f)
+            return nil
+        }
+    case reflect.Bool:
+        switch x := v.(type) {
+        case int64:
+            dv.SetBool(x != 0)
+            return nil
+        case string:
+            b, err := strconv.ParseBool(x)
+            if err != nil {
+                return err
+            }
+            dv.SetBool(b)
+            return nil
+        }
+    }
+    return errors.Errorf("unable to assign %T to %s", v, dv.Type())
+}
+
+// memoryAssignAll assigns pairs of destinations and values.
+func memoryAssignAll(pairs ...interface{}) error {
+    for i := 0; i+1 < len(pairs); i += 2 {
+        if err := memoryAssign(pairs[i], pairs[i+1]); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+{{- range .Tables }}
+{{ template "memorytable" . }}
+{{- end }}
+{{- range .Views }}
+{{ template "memorytable" . }}
+{{- end }}
+{{- range .Tables }}
+
+{{ template "memorymethods" . }}
+{{- end }}
+{{- range .Views }}
+
+{{ template "memorymethods" . }}
+{{- end }}
+{{- range .Foreign }}
+    {{- $short := (shortname .Type.Name) }}
+
+// {{ .Name }}In{{ .Type.Name }} returns the {{ .RefType.Name }} associated with the {{ .Type.Name }}'s {{ .Field.Name }} ({{ .Field.Col.ColumnName }}).
+//
+// Generated from foreign key '{{ .ForeignKey.ForeignKeyName }}'.
+func (s *MemoryStorage) {{ .Name }}In{{ .Type.Name }}({{ dbparam }}, {{ $short }} *{{ .Type.Name }}) (*{{ .RefType.Name }}, error) {
+    return s.{{ .RefType.Name }}By{{ .RefField.Name }}({{ dbarg }}, {{ convext $short .Field .RefField }})
+}
+    {{- if (enabledataloader) }}
+        {{- $fnname := (print (plural .Type.Name) "By" .Field.Name "FK") -}}
+        {{- $batch := (print .RefType.Name "By" .RefField.Name "Batch") -}}
+        {{- $reftable := (schema .RefType.Schema .RefType.Table.TableName) -}}
+        {{- $table := (schema .Type.Schema .Type.Table.TableName) -}}
+        {{- if not (isdup $batch "memorybatch") }}
+
+// {{ $batch }} retrieves the rows from '{{ $reftable }}' matching any of keys.
+func (s *MemoryStorage) {{ $batch }}({{ dbparam }}, keys []{{ .RefField.Type }}) ([]*{{ .RefType.Name }}, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    t := s.tables[`{{ $reftable }}`]
+    var res []*{{ .RefType.Name }}
+    for _, key := range keys {
+        for _, row := range t.lookup([]string{"{{ .RefField.Col.ColumnName }}"}, key) {
+            res = append(res, row.(*{{ .RefType.Name }}))
+        }
+    }
+    return res, nil
+}
+        {{- end }}
+        {{- if not (isdup (print $fnname "Batch") "memorybatch") }}
+
+// {{ $fnname }}Batch retrieves the rows from {{ $table }} of several foreign keys {{ .Field.Name }} at once,
+// the offset and limit of queryArgs apply to the rows of each key.
+func (s *MemoryStorage) {{ $fnname }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}][]*{{ .Type.Name }}, error) {
+    queryArgs = Apply{{ .Type.Name }}QueryArgsDefaults(queryArgs)
+    order, err := get{{ .Type.Name }}Order(queryArgs)
+    if err != nil {
+        return nil, err
+    }
+
+    var filterArgs *filterArguments
+{{- if (existsqlfilter .Type) }}
+    if queryArgs.filterArgs != nil {
+        if queryArgs.filterArgs.hasField("{{ .Field.Col.ColumnName }}") {
+            return nil, fmt.Errorf("already have condition on field:{{ .Field.Name }}, because of foregin key {{ .ForeignKey.ForeignKeyName }}")
+        }
+        filterArgs = queryArgs.filterArgs
+    }
+{{- end }}
+    cursor := Cursor{Offset: queryArgs.Offset, Limit: queryArgs.Limit}
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    t := s.tables[`{{ $table }}`]
+    res := make(map[{{ .RefField.Type }}][]*{{ .Type.Name }}, len(keys))
+    for _, key := range keys {
+        if _, ok := res[key]; ok {
+            continue
+        }
+        rows, err := s.selectRows(t, filterArgs, *queryArgs.Dead, order, t.equal("{{ .Field.Col.ColumnName }}", key))
+        if err != nil {
+            return nil, err
+        }
+        rows, err = s.page(t, "{{ .Type.Name }}", rows, order, &cursor)
+        if err != nil {
+            return nil, err
+        }
+        for _, row := range rows {
+            res[key] = append(res[key], row.(*{{ .Type.Name }}))
+        }
+    }
+    return res, nil
+}
+
+// Count{{ $fnname }}Batch counts the rows from {{ $table }} of several foreign keys {{ .Field.Name }} at once.
+func (s *MemoryStorage) Count{{ $fnname }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}]int, error) {
+    queryArgs = Apply{{ .Type.Name }}QueryArgsDefaults(queryArgs)
+
+    var filterArgs *filterArguments
+{{- if (existsqlfilter .Type) }}
+    if queryArgs.filterArgs != nil {
+        if queryArgs.filterArgs.hasField("{{ .Field.Col.ColumnName }}") {
+            return nil, fmt.Errorf("already have condition on field:{{ .Field.Name }}, because of foregin key {{ .ForeignKey.ForeignKeyName }}")
+        }
+        filterArgs = queryArgs.filterArgs
+    }
+{{- end }}
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    t := s.tables[`{{ $table }}`]
+    res := make(map[{{ .RefField.Type }}]int, len(keys))
+    for _, key := range keys {
+        rows, err := s.selectRows(t, filterArgs, *queryArgs.Dead, nil, t.equal("{{ .Field.Col.ColumnName }}", key))
+        if err != nil {
+            return nil, err
+        }
+        if len(rows) > 0 {
+            res[key] = len(rows)
+        }
+    }
+    return res, nil
+}
+        {{- end }}
+    {{- end }}
+{{- end }}
+{{- range .Indexes }}
+    {{- $table := (schema .Type.Schema .Type.Table.TableName) }}
+
+// {{ .FuncName }} retrieves a row from '{{ $table }}' as a {{ .Type.Name }}.
+//
+// Generated from index '{{ .Index.IndexName }}'.
+func (s *MemoryStorage) {{ .FuncName }}({{ dbparam }}{{ goparamlist .Fields true true }}) ({{ if not .Index.IsUnique }}[]{{ end }}*{{ .Type.Name }}, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    rows := s.tables[`{{ $table }}`].lookup([]string{ {{- range $i, $f := .Fields }}{{ if $i }}, {{ end }}"{{ $f.Col.ColumnName }}"{{ end -}} }{{ goparamlist .Fields true false }})
+{{- if .Index.IsUnique }}
+    if len(rows) == 0 {
+        return nil, ErrNotFound
+    }
+    return rows[0].(*{{ .Type.Name }}), nil
+{{- else }}
+    res := []*{{ .Type.Name }}{}
+    for _, row := range rows {
+        res = append(res, row.(*{{ .Type.Name }}))
+    }
+    return res, nil
+{{- end }}
+}
+{{- end }}
+
+var _ Storage = (*MemoryStorage)(nil)
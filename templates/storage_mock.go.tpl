@@ -0,0 +1,368 @@
+{{- define "mockfields" -}}
+    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "ctx" "xoLog") -}}
+    {{- $t := . -}}
+    {{- if .PrimaryKey }}
+    Insert{{ .Name }}Func func({{ dbparam }}, {{ $short }} *{{ .Name }}) error
+    Insert{{ .Name }}ByFieldsFunc func({{ dbparam }}, {{ $short }} *{{ .Name }}) error
+    Insert{{ .Name }}sFunc func({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error
+    Delete{{ .Name }}Func func({{ dbparam }}, {{ $short }} *{{ .Name }}) error
+    Delete{{ .Name }}sFunc func({{ dbparam }}, {{ $short }} []*{{ .Name }}) error
+    {{- if ne (fieldnamesmulti .Fields $short .PrimaryKeyFields) "" }}
+    Update{{ .Name }}Func func({{ dbparam }}, {{ $short }} *{{ .Name }}) error
+    Update{{ .Name }}ByFieldsFunc func({{ dbparam }}, {{ $short }} *{{ .Name }}, fields, retCols []string, params, retVars []interface{}) error
+    Save{{ .Name }}Func func({{ dbparam }}, {{ $short }} *{{ .Name }}) error
+    Upsert{{ .Name }}Func func({{ dbparam }}, {{ $short }} *{{ .Name }}) error
+    {{- end }}
+    {{- end }}
+    {{- if (createdcolumn .) }}
+    GetMostRecent{{ .Name }}Func func({{ dbparam }}, n int) ([]*{{ .Name }}, error)
+    {{- end }}
+    {{- if (changedcolumn .) }}
+    GetMostRecentChanged{{ .Name }}Func func({{ dbparam }}, n int) ([]*{{ .Name }}, error)
+    {{- end }}
+    GetAll{{ .Name }}Func func({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) ([]*{{ .Name }}, error)
+    CountAll{{ .Name }}Func func({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) (int, error)
+    Aggregate{{ .Name }}Func func({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments, groupBy []{{ .Name }}OrderField) ([]*{{ .Name }}Aggregate, error)
+    {{- range .ForeignKeys }}
+        {{- $fnname := (print (plural $t.Name) "By" .Field.Name "FK") -}}
+        {{- if not (isdup $fnname "mockfields") }}
+    {{ $fnname }}Func func({{ dbparam }}, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) ([]*{{ $t.Name }}, error)
+    Count{{ $fnname }}Func func({{ dbparam }}, {{ togqlname .Field.Name }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) (int, error)
+        {{- end }}
+    {{- end }}
+{{- end -}}
+
+{{- define "mockmethods" -}}
+    {{- $short := (shortname .Name "err" "res" "sqlstr" "db" "ctx" "xoLog") -}}
+    {{- $t := . -}}
+    {{- $table := (schema .Table.TableName) -}}
+    {{- if .PrimaryKey }}
+// Insert{{ .Name }} records the call and calls Insert{{ .Name }}Func.
+func (mock *MockStorage) Insert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    mock.record("Insert{{ .Name }}", {{ $short }})
+    if mock.Insert{{ .Name }}Func == nil {
+        return mock.unset("Insert{{ .Name }}")
+    }
+    return mock.Insert{{ .Name }}Func({{ dbarg }}, {{ $short }})
+}
+
+// Insert{{ .Name }}ByFields records the call and calls Insert{{ .Name }}ByFieldsFunc.
+func (mock *MockStorage) Insert{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    mock.record("Insert{{ .Name }}ByFields", {{ $short }})
+    if mock.Insert{{ .Name }}ByFieldsFunc == nil {
+        return mock.unset("Insert{{ .Name }}ByFields")
+    }
+    return mock.Insert{{ .Name }}ByFieldsFunc({{ dbarg }}, {{ $short }})
+}
+
+// Insert{{ .Name }}s records the call and calls Insert{{ .Name }}sFunc.
+func (mock *MockStorage) Insert{{ .Name }}s({{ dbparam }}, {{ $short }}s []*{{ .Name }}) error {
+    mock.record("Insert{{ .Name }}s", {{ $short }}s)
+    if mock.Insert{{ .Name }}sFunc == nil {
+        return mock.unset("Insert{{ .Name }}s")
+    }
+    return mock.Insert{{ .Name }}sFunc({{ dbarg }}, {{ $short }}s)
+}
+
+// Delete{{ .Name }} records the call and calls Delete{{ .Name }}Func.
+func (mock *MockStorage) Delete{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    mock.record("Delete{{ .Name }}", {{ $short }})
+    if mock.Delete{{ .Name }}Func == nil {
+        return mock.unset("Delete{{ .Name }}")
+    }
+    return mock.Delete{{ .Name }}Func({{ dbarg }}, {{ $short }})
+}
+
+// Delete{{ .Name }}s records the call and calls Delete{{ .Name }}sFunc.
+func (mock *MockStorage) Delete{{ .Name }}s({{ dbparam }}, {{ $short }} []*{{ .Name }}) error {
+    mock.record("Delete{{ .Name }}s", {{ $short }})
+    if mock.Delete{{ .Name }}sFunc == nil {
+        return mock.unset("Delete{{ .Name }}s")
+    }
+    return mock.Delete{{ .Name }}sFunc({{ dbarg }}, {{ $short }})
+}
+    {{- if ne (fieldnamesmulti .Fields $short .PrimaryKeyFields) "" }}
+
+// Update{{ .Name }} records the call and calls Update{{ .Name }}Func.
+func (mock *MockStorage) Update{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    mock.record("Update{{ .Name }}", {{ $short }})
+    if mock.Update{{ .Name }}Func == nil {
+        return mock.unset("Update{{ .Name }}")
+    }
+    return mock.Update{{ .Name }}Func({{ dbarg }}, {{ $short }})
+}
+
+// Update{{ .Name }}ByFields records the call and calls Update{{ .Name }}ByFieldsFunc.
+func (mock *MockStorage) Update{{ .Name }}ByFields({{ dbparam }}, {{ $short }} *{{ .Name }}, fields, retCols []string, params, retVars []interface{}) error {
+    mock.record("Update{{ .Name }}ByFields", {{ $short }}, fields, retCols, params, retVars)
+    if mock.Update{{ .Name }}ByFieldsFunc == nil {
+        return mock.unset("Update{{ .Name }}ByFields")
+    }
+    return mock.Update{{ .Name }}ByFieldsFunc({{ dbarg }}, {{ $short }}, fields, retCols, params, retVars)
+}
+
+// Save{{ .Name }} records the call and calls Save{{ .Name }}Func.
+func (mock *MockStorage) Save{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    mock.record("Save{{ .Name }}", {{ $short }})
+    if mock.Save{{ .Name }}Func == nil {
+        return mock.unset("Save{{ .Name }}")
+    }
+    return mock.Save{{ .Name }}Func({{ dbarg }}, {{ $short }})
+}
+
+// Upsert{{ .Name }} records the call and calls Upsert{{ .Name }}Func.
+func (mock *MockStorage) Upsert{{ .Name }}({{ dbparam }}, {{ $short }} *{{ .Name }}) error {
+    mock.record("Upsert{{ .Name }}", {{ $short }})
+    if mock.Upsert{{ .Name }}Func == nil {
+        return mock.unset("Upsert{{ .Name }}")
+    }
+    return mock.Upsert{{ .Name }}Func({{ dbarg }}, {{ $short }})
+}
+    {{- end }}
+    {{- end }}
+    {{- if (createdcolumn .) }}
+
+// GetMostRecent{{ .Name }} records the call and calls GetMostRecent{{ .Name }}Func.
+func (mock *MockStorage) GetMostRecent{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error) {
+    mock.record("GetMostRecent{{ .Name }}", n)
+    if mock.GetMostRecent{{ .Name }}Func == nil {
+        return nil, mock.unset("GetMostRecent{{ .Name }}")
+    }
+    return mock.GetMostRecent{{ .Name }}Func({{ dbarg }}, n)
+}
+    {{- end }}
+    {{- if (changedcolumn .) }}
+
+// GetMostRecentChanged{{ .Name }} records the call and calls GetMostRecentChanged{{ .Name }}Func.
+func (mock *MockStorage) GetMostRecentChanged{{ .Name }}({{ dbparam }}, n int) ([]*{{ .Name }}, error) {
+    mock.record("GetMostRecentChanged{{ .Name }}", n)
+    if mock.GetMostRecentChanged{{ .Name }}Func == nil {
+        return nil, mock.unset("GetMostRecentChanged{{ .Name }}")
+    }
+    return mock.GetMostRecentChanged{{ .Name }}Func({{ dbarg }}, n)
+}
+    {{- end }}
+
+// GetAll{{ .Name }} records the call and calls GetAll{{ .Name }}Func.
+func (mock *MockStorage) GetAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) ([]*{{ .Name }}, error) {
+    mock.record("GetAll{{ .Name }}", queryArgs)
+    if mock.GetAll{{ .Name }}Func == nil {
+        return nil, mock.unset("GetAll{{ .Name }}")
+    }
+    return mock.GetAll{{ .Name }}Func({{ dbarg }}, queryArgs)
+}
+
+// CountAll{{ .Name }} records the call and calls CountAll{{ .Name }}Func.
+func (mock *MockStorage) CountAll{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments) (int, error) {
+    mock.record("CountAll{{ .Name }}", queryArgs)
+    if mock.CountAll{{ .Name }}Func == nil {
+        return 0, mock.unset("CountAll{{ .Name }}")
+    }
+    return mock.CountAll{{ .Name }}Func({{ dbarg }}, queryArgs)
+}
+
+// Aggregate{{ .Name }} records the call and calls Aggregate{{ .Name }}Func.
+func (mock *MockStorage) Aggregate{{ .Name }}({{ dbparam }}, queryArgs *{{ .Name }}QueryArguments, groupBy []{{ .Name }}OrderField) ([]*{{ .Name }}Aggregate, error) {
+    mock.record("Aggregate{{ .Name }}", queryArgs, groupBy)
+    if mock.Aggregate{{ .Name }}Func == nil {
+        return nil, mock.unset("Aggregate{{ .Name }}")
+    }
+    return mock.Aggregate{{ .Name }}Func({{ dbarg }}, queryArgs, groupBy)
+}
+    {{- range .ForeignKeys }}
+        {{- $fnname := (print (plural $t.Name) "By" .Field.Name "FK") -}}
+        {{- $param := (togqlname .Field.Name) -}}
+        {{- if not (isdup $fnname "mockmethods") }}
+
+// {{ $fnname }} records the call and calls {{ $fnname }}Func.
+func (mock *MockStorage) {{ $fnname }}({{ dbparam }}, {{ $param }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) ([]*{{ $t.Name }}, error) {
+    mock.record("{{ $fnname }}", {{ $param }}, queryArgs)
+    if mock.{{ $fnname }}Func == nil {
+        return nil, mock.unset("{{ $fnname }}")
+    }
+    return mock.{{ $fnname }}Func({{ dbarg }}, {{ $param }}, queryArgs)
+}
+
+// Count{{ $fnname }} records the call and calls Count{{ $fnname }}Func.
+func (mock *MockStorage) Count{{ $fnname }}({{ dbparam }}, {{ $param }} {{ .RefField.Type }}, queryArgs *{{ $t.Name }}QueryArguments) (int, error) {
+    mock.record("Count{{ $fnname }}", {{ $param }}, queryArgs)
+    if mock.Count{{ $fnname }}Func == nil {
+        return 0, mock.unset("Count{{ $fnname }}")
+    }
+    return mock.Count{{ $fnname }}Func({{ dbarg }}, {{ $param }}, queryArgs)
+}
+        {{- end }}
+    {{- end }}
+{{- end -}}
+
+// MockCall is a call of a MockStorage method, with its arguments but the
+// database handle.
+type MockCall struct {
+    Method string
+    Args   []interface{}
+}
+
+// MockStorage is a Storage whose methods record their calls and call the
+// func field of the same name. The methods of the nil func fields return an
+// error, but WithTx which runs fn on db.
+type MockStorage struct {
+    mu    sync.Mutex
+    calls []MockCall
+
+    WithTxFunc func(ctx context.Context, db {{ dbtype }}, fn func(tx {{ dbtype }}) error) error
+{{- range .Tables }}
+    {{ template "mockfields" . }}
+{{- end }}
+{{- range .Views }}
+    {{ template "mockfields" . }}
+{{- end }}
+{{- range .Foreign }}
+    {{ .Name }}In{{ .Type.Name }}Func func({{ dbparam }}, {{ shortname .Type.Name }} *{{ .Type.Name }}) (*{{ .RefType.Name }}, error)
+    {{- if (enabledataloader) }}
+        {{- $fnname := (print (plural .Type.Name) "By" .Field.Name "FK") -}}
+        {{- if not (isdup (print .RefType.Name "By" .RefField.Name "Batch") "mockfields") }}
+    {{ .RefType.Name }}By{{ .RefField.Name }}BatchFunc func({{ dbparam }}, keys []{{ .RefField.Type }}) ([]*{{ .RefType.Name }}, error)
+        {{- end }}
+        {{- if not (isdup (print $fnname "Batch") "mockfields") }}
+    {{ $fnname }}BatchFunc func({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}][]*{{ .Type.Name }}, error)
+    Count{{ $fnname }}BatchFunc func({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}]int, error)
+        {{- end }}
+    {{- end }}
+{{- end }}
+{{- range .Indexes }}
+    {{ .FuncName }}Func func({{ dbparam }}{{ goparamlist .Fields true true }}) ({{ if not .Index.IsUnique }}[]{{ end }}*{{ .Type.Name }}, error)
+    {{- if and .Index.IsUnique (not .Index.IsPrimary) .Type.PrimaryKey }}
+    Upsert{{ .FuncName }}Func func({{ dbparam }}, {{ shortname .Type.Name "err" "res" "sqlstr" "db" "ctx" "xoLog" }} *{{ .Type.Name }}, opts *UpsertOptions) error
+    {{- end }}
+{{- end }}
+}
+
+// record records the call of method with args.
+func (mock *MockStorage) record(method string, args ...interface{}) {
+    mock.mu.Lock()
+    defer mock.mu.Unlock()
+    mock.calls = append(mock.calls, MockCall{Method: method, Args: args})
+}
+
+// unset returns the error of a call of method without its func field.
+func (mock *MockStorage) unset(method string) error {
+    return errors.Errorf("MockStorage: %sFunc is not set", method)
+}
+
+// Calls returns the recorded calls, in order. When methods are given, only
+// the calls of those methods are returned.
+func (mock *MockStorage) Calls(methods ...string) []MockCall {
+    mock.mu.Lock()
+    defer mock.mu.Unlock()
+
+    calls := make([]MockCall, 0, len(mock.calls))
+    for _, c := range mock.calls {
+        if len(methods) == 0 {
+            calls = append(calls, c)
+            continue
+        }
+        for _, m := range methods {
+            if c.Method == m {
+                calls = append(calls, c)
+                break
+            }
+        }
+    }
+    return calls
+}
+
+// Reset forgets the recorded calls.
+func (mock *MockStorage) Reset() {
+    mock.mu.Lock()
+    defer mock.mu.Unlock()
+    mock.calls = nil
+}
+
+// WithTx records the call and calls WithTxFunc, or else runs fn on db.
+func (mock *MockStorage) WithTx(ctx context.Context, db {{ dbtype }}, fn func(tx {{ dbtype }}) error) error {
+    mock.record("WithTx")
+    if mock.WithTxFunc == nil {
+        return fn(db)
+    }
+    return mock.WithTxFunc(ctx, db, fn)
+}
+{{- range .Tables }}
+
+{{ template "mockmethods" . }}
+{{- end }}
+{{- range .Views }}
+
+{{ template "mockmethods" . }}
+{{- end }}
+{{- range .Foreign }}
+    {{- $short := (shortname .Type.Name) }}
+
+// {{ .Name }}In{{ .Type.Name }} records the call and calls {{ .Name }}In{{ .Type.Name }}Func.
+func (mock *MockStorage) {{ .Name }}In{{ .Type.Name }}({{ dbparam }}, {{ $short }} *{{ .Type.Name }}) (*{{ .RefType.Name }}, error) {
+    mock.record("{{ .Name }}In{{ .Type.Name }}", {{ $short }})
+    if mock.{{ .Name }}In{{ .Type.Name }}Func == nil {
+        return nil, mock.unset("{{ .Name }}In{{ .Type.Name }}")
+    }
+    return mock.{{ .Name }}In{{ .Type.Name }}Func({{ dbarg }}, {{ $short }})
+}
+    {{- if (enabledataloader) }}
+        {{- $fnname := (print (plural .Type.Name) "By" .Field.Name "FK") -}}
+        {{- $batch := (print .RefType.Name "By" .RefField.Name "Batch") -}}
+        {{- if not (isdup $batch "mockmethods") }}
+
+// {{ $batch }} records the call and calls {{ $batch }}Func.
+func (mock *MockStorage) {{ $batch }}({{ dbparam }}, keys []{{ .RefField.Type }}) ([]*{{ .RefType.Name }}, error) {
+    mock.record("{{ $batch }}", keys)
+    if mock.{{ $batch }}Func == nil {
+        return nil, mock.unset("{{ $batch }}")
+    }
+    return mock.{{ $batch }}Func({{ dbarg }}, keys)
+}
+        {{- end }}
+        {{- if not (isdup (print $fnname "Batch") "mockmethods") }}
+
+// {{ $fnname }}Batch records the call and calls {{ $fnname }}BatchFunc.
+func (mock *MockStorage) {{ $fnname }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}][]*{{ .Type.Name }}, error) {
+    mock.record("{{ $fnname }}Batch", keys, queryArgs)
+    if mock.{{ $fnname }}BatchFunc == nil {
+        return nil, mock.unset("{{ $fnname }}Batch")
+    }
+    return mock.{{ $fnname }}BatchFunc({{ dbarg }}, keys, queryArgs)
+}
+
+// Count{{ $fnname }}Batch records the call and calls Count{{ $fnname }}BatchFunc.
+func (mock *MockStorage) Count{{ $fnname }}Batch({{ dbparam }}, keys []{{ .RefField.Type }}, queryArgs *{{ .Type.Name }}QueryArguments) (map[{{ .RefField.Type }}]int, error) {
+    mock.record("Count{{ $fnname }}Batch", keys, queryArgs)
+    if mock.Count{{ $fnname }}BatchFunc == nil {
+        return nil, mock.unset("Count{{ $fnname }}Batch")
+    }
+    return mock.Count{{ $fnname }}BatchFunc({{ dbarg }}, keys, queryArgs)
+}
+        {{- end }}
+    {{- end }}
+{{- end }}
+{{- range .Indexes }}
+
+// {{ .FuncName }} records the call and calls {{ .FuncName }}Func.
+func (mock *MockStorage) {{ .FuncName }}({{ dbparam }}{{ goparamlist .Fields true true }}) ({{ if not .Index.IsUnique }}[]{{ end }}*{{ .Type.Name }}, error) {
+    mock.record("{{ .FuncName }}"{{ goparamlist .Fields true false }})
+    if mock.{{ .FuncName }}Func == nil {
+        return nil, mock.unset("{{ .FuncName }}")
+    }
+    return mock.{{ .FuncName }}Func({{ dbarg }}{{ goparamlist .Fields true false }})
+}
+    {{- if and .Index.IsUnique (not .Index.IsPrimary) .Type.PrimaryKey }}
+    {{- $short := (shortname .Type.Name "err" "res" "sqlstr" "db" "ctx" "xoLog") }}
+
+// Upsert{{ .FuncName }} records the call and calls Upsert{{ .FuncName }}Func.
+func (mock *MockStorage) Upsert{{ .FuncName }}({{ dbparam }}, {{ $short }} *{{ .Type.Name }}, opts *UpsertOptions) error {
+    mock.record("Upsert{{ .FuncName }}", {{ $short }}, opts)
+    if mock.Upsert{{ .FuncName }}Func == nil {
+        return mock.unset("Upsert{{ .FuncName }}")
+    }
+    return mock.Upsert{{ .FuncName }}Func({{ dbarg }}, {{ $short }}, opts)
+}
+    {{- end }}
+{{- end }}
+
+var _ Storage = (*MockStorage)(nil)
@@ -1,6 +1,10 @@
 // Config is storage configuration
 type Config struct {
 	Logger XOLogger
+	// PostgresCopy makes the postgres InsertXs use COPY FROM STDIN, which
+	// requires the lib/pq driver and leaves the primary keys of the inserted
+	// rows unset.
+	PostgresCopy bool
 }
 
 // XODB is the common interface for database operations that can be used with
@@ -12,6 +16,19 @@ type XODB interface {
 	Query(string, ...interface{}) (*sql.Rows, error)
 	QueryRow(string, ...interface{}) *sql.Row
 }
+{{- if (enablecontext) }}
+
+// XODBContext is the context-aware variant of XODB, used by the generated
+// Storage methods so that queries can be canceled, carry deadlines and
+// tracing information.
+//
+// This should work with database/sql.DB, database/sql.Tx and database/sql.Conn.
+type XODBContext interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+{{- end }}
 
 // XOLogger provides the log interface used by generated queries.
 type XOLogger interface {
@@ -32,6 +49,146 @@ func xoLogf(logger XOLogger, level logrus.Level, format string, args ...interfac
 	}
 }
 
+// xoBeginner is implemented by the databases transactions are begun on, such
+// as database/sql.DB and database/sql.Conn.
+type xoBeginner interface {
+	BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error)
+}
+
+// xoTxDialect is the savepoint syntax of a database, the savepoints are not
+// released when release is empty.
+type xoTxDialect struct {
+	savepoint  string
+	rollbackTo string
+	release    string
+	// retry is whether the transactions failing to serialize are run again.
+	retry bool
+}
+
+// xoTxMaxAttempts is how many times a transaction failing to serialize is run.
+const xoTxMaxAttempts = 5
+
+// xoSavepointSeq numbers the savepoints, nested savepoints need distinct names.
+var xoSavepointSeq uint64
+
+// xoWithTx runs fn in a transaction begun on db, committing it when fn
+// succeeds and rolling it back otherwise. When db is not a database
+// transactions are begun on, it's a transaction, and fn runs in a savepoint.
+func xoWithTx(ctx context.Context, logger XOLogger, dialect xoTxDialect, db {{ dbtype }}, fn func(tx {{ dbtype }}) error) error {
+	beginner, ok := db.(xoBeginner)
+	if !ok {
+		return xoWithSavepoint(ctx, logger, dialect, db, fn)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := xoRunTx(ctx, logger, beginner, fn)
+		if err == nil || !dialect.retry || attempt == xoTxMaxAttempts || !xoIsSerializationFailure(err) || ctx.Err() != nil {
+			return err
+		}
+		xoLogf(logger, logrus.InfoLevel, "retrying transaction failing to serialize, attempt %d: %v", attempt, err)
+	}
+}
+
+// xoRunTx runs fn in a transaction begun on beginner.
+func xoRunTx(ctx context.Context, logger XOLogger, beginner xoBeginner, fn func(tx {{ dbtype }}) error) error {
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			xoLogf(logger, logrus.WarnLevel, "unable to roll back transaction: %v", rerr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// xoWithSavepoint runs fn in a savepoint of the transaction db, rolling back
+// to the savepoint when fn fails.
+func xoWithSavepoint(ctx context.Context, logger XOLogger, dialect xoTxDialect, db {{ dbtype }}, fn func(tx {{ dbtype }}) error) error {
+	name := fmt.Sprintf("xo_savepoint_%d", atomic.AddUint64(&xoSavepointSeq, 1))
+
+	sqlstr := fmt.Sprintf(dialect.savepoint, name)
+	xoLog(logger, logrus.InfoLevel, sqlstr)
+	if _, err := {{ dbcall "Exec" }}sqlstr); err != nil {
+		return err
+	}
+
+	if err := fn(db); err != nil {
+		sqlstr = fmt.Sprintf(dialect.rollbackTo, name)
+		xoLog(logger, logrus.InfoLevel, sqlstr)
+		if _, rerr := {{ dbcall "Exec" }}sqlstr); rerr != nil {
+			xoLogf(logger, logrus.WarnLevel, "unable to roll back to savepoint: %v", rerr)
+		}
+		return err
+	}
+
+	if dialect.release == "" {
+		return nil
+	}
+	sqlstr = fmt.Sprintf(dialect.release, name)
+	xoLog(logger, logrus.InfoLevel, sqlstr)
+	_, err := {{ dbcall "Exec" }}sqlstr)
+	return err
+}
+
+// xoIsSerializationFailure returns whether err is a serialization failure
+// (SQLSTATE 40001), after which the transaction can be run again.
+func xoIsSerializationFailure(err error) bool {
+	if e, ok := errors.Cause(err).(interface{ SQLState() string }); ok {
+		return e.SQLState() == "40001"
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "could not serialize access") || strings.Contains(msg, "restart transaction")
+}
+
+// xoValues returns the placeholders of a multi-row VALUES clause of rows
+// rows of n values (ie, "($1, $2), ($3, $4)"), numbered when mask is.
+func xoValues(mask string, rows, n int) string {
+	numbered := strings.Contains(mask, "%d")
+
+	var buf strings.Builder
+	for i := 0; i < rows; i++ {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString("(")
+		for j := 0; j < n; j++ {
+			if j != 0 {
+				buf.WriteString(", ")
+			}
+			if numbered {
+				fmt.Fprintf(&buf, mask, i*n+j+1)
+			} else {
+				buf.WriteString(mask)
+			}
+		}
+		buf.WriteString(")")
+	}
+	return buf.String()
+}
+
+// xoIsNull reports whether one of the values is NULL in the database.
+func xoIsNull(values ...interface{}) bool {
+	for _, v := range values {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return true
+		}
+		if valuer, ok := v.(driver.Valuer); ok {
+			var err error
+			if v, err = valuer.Value(); err != nil {
+				continue
+			}
+		}
+		if v == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // ScannerValuer is the common interface for types that implement both the
 // database/sql.Scanner and sql/driver.Valuer interfaces.
 type ScannerValuer interface {
@@ -113,6 +270,7 @@ func (nt NullTime) Value() (driver.Value, error) {
 }
 
 // Cursor specifies an index to sort by, the direction of the sort, an offset, and a limit.
+// An empty OrderBy sorts by the primary key.
 type Cursor struct {
 	Offset *int32
 	Limit  *int32
@@ -123,12 +281,15 @@ type Cursor struct {
 	First  *int32
 	Before *graphql.ID
 	Last   *int32
+
+	// page boundaries found by a keyset query
+	hasNextPage, hasPreviousPage bool
 }
 
 var (
 	defaultOffset 	int32  = 0
 	defaultLimit  	int32  = 50
-	defaultOrderBy  string = "id"
+	defaultOrderBy  string = ""
 	defaultDesc   	bool   = false
 	defaultDead   	bool   = false
 )
@@ -142,6 +303,252 @@ var DefaultCursor = Cursor{
 	Dead:   &defaultDead,
 }
 
+// OrderDirection is the direction of an order term, related to graphql enum: OrderDirection
+type OrderDirection string
+
+// OrderDirection values.
+const (
+	OrderAsc  OrderDirection = "ASC"
+	OrderDesc OrderDirection = "DESC"
+)
+
+// OrderNulls is the position of the nulls of an order term, related to graphql enum: OrderNulls
+type OrderNulls string
+
+// OrderNulls values.
+const (
+	NullsFirst OrderNulls = "FIRST"
+	NullsLast  OrderNulls = "LAST"
+)
+
+// orderTerm is a column of an ORDER BY clause, the nulls of a nullable column
+// are ordered explicitly, so that every driver orders them alike.
+type orderTerm struct {
+	column     string
+	desc       bool
+	nullable   bool
+	nullsFirst bool
+}
+
+// newOrderTerm returns the term ordering by column in direction, ascending if
+// nil, with the nulls at position nulls, greatest if nil.
+func newOrderTerm(column string, nullable bool, direction *OrderDirection, nulls *OrderNulls) (orderTerm, error) {
+	t := orderTerm{column: column, nullable: nullable}
+	if direction != nil {
+		switch *direction {
+		case OrderAsc:
+		case OrderDesc:
+			t.desc = true
+		default:
+			return t, fmt.Errorf("unsupported order direction:%v", *direction)
+		}
+	}
+	t.nullsFirst = t.desc
+	if nulls != nil {
+		switch *nulls {
+		case NullsFirst:
+			t.nullsFirst = true
+		case NullsLast:
+			t.nullsFirst = false
+		default:
+			return t, fmt.Errorf("unsupported order nulls:%v", *nulls)
+		}
+	}
+	return t, nil
+}
+
+// sql returns the term of the ORDER BY clause, reversed for the rows of a last
+// page queried in reverse order.
+func (t orderTerm) sql(reverse bool) string {
+	dir := "ASC"
+	if t.desc != reverse {
+		dir = "DESC"
+	}
+	if !t.nullable {
+		return fmt.Sprintf("%s %s", t.column, dir)
+	}
+	nulls := "1 ELSE 0"
+	if t.nullsFirst != reverse {
+		nulls = "0 ELSE 1"
+	}
+	return fmt.Sprintf("CASE WHEN %s IS NULL THEN %s END, %s %s", t.column, nulls, t.column, dir)
+}
+
+// equal returns the predicate of the rows equal to the cursor row on the term,
+// ref returns the value of a column of the cursor row.
+func (t orderTerm) equal(ref func(string) string) string {
+	if !t.nullable {
+		return fmt.Sprintf("%s = %s", t.column, ref(t.column))
+	}
+	return fmt.Sprintf("(%s = %s OR (%s IS NULL AND %s IS NULL))", t.column, ref(t.column), t.column, ref(t.column))
+}
+
+// after returns the predicate of the rows coming after the cursor row on the
+// term, or before it when before is set, ref returns the value of a column of
+// the cursor row.
+func (t orderTerm) after(ref func(string) string, before bool) string {
+	op := ">"
+	if t.desc != before {
+		op = "<"
+	}
+	if !t.nullable {
+		return fmt.Sprintf("%s %s %s", t.column, op, ref(t.column))
+	}
+	// the nulls come before the values if they are first when paging forward
+	var nulls string
+	if t.nullsFirst != before {
+		nulls = fmt.Sprintf("%s IS NULL AND %s IS NOT NULL", ref(t.column), t.column)
+	} else {
+		nulls = fmt.Sprintf("%s IS NULL AND %s IS NOT NULL", t.column, ref(t.column))
+	}
+	return fmt.Sprintf("((%s) OR %s %s %s)", nulls, t.column, op, ref(t.column))
+}
+
+// orderByKey returns the order ended by the primary key pk, which breaks the
+// ties of the other terms, in the direction of the last term or desc.
+func orderByKey(order []orderTerm, pk string, desc bool) []orderTerm {
+	for _, t := range order {
+		if t.column == pk {
+			return order
+		}
+	}
+	if len(order) > 0 {
+		desc = order[len(order)-1].desc
+	}
+	return append(order, orderTerm{column: pk, desc: desc})
+}
+
+// orderSQL returns the ORDER BY clause of order, reversed for the rows of a
+// last page queried in reverse order.
+func orderSQL(order []orderTerm, reverse bool) string {
+	terms := make([]string, len(order))
+	for i, t := range order {
+		terms[i] = t.sql(reverse)
+	}
+	return strings.Join(terms, ", ")
+}
+
+// encodeCursor returns the opaque Relay cursor of the typeName row with the primary key id.
+func encodeCursor(typeName string, id int) graphql.ID {
+	return graphql.ID(base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d", typeName, id))))
+}
+
+// decodeCursor returns the primary key of the typeName row encoded in cursor.
+func decodeCursor(typeName string, cursor graphql.ID) (int, error) {
+	buf, err := base64.StdEncoding.DecodeString(string(cursor))
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %s", cursor)
+	}
+
+	i := strings.LastIndex(string(buf), ":")
+	if i < 0 || string(buf[:i]) != typeName {
+		return 0, fmt.Errorf("invalid cursor %s, not a %s cursor", cursor, typeName)
+	}
+
+	id, err := strconv.Atoi(string(buf[i+1:]))
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %s", cursor)
+	}
+	return id, nil
+}
+
+// isKeyset reports whether the Cursor asks for Relay cursor pagination
+// (first, after, last, before) instead of offset pagination.
+func (c *Cursor) isKeyset() bool {
+	return c.After != nil || c.First != nil || c.Before != nil || c.Last != nil
+}
+
+// keyset builds the Relay cursor pagination of the typeName rows from table,
+// ordered by order, which ends with the primary key pk. param adds a query
+// parameter and returns its placeholder.
+//
+// It returns the predicates to add to the WHERE clause, the number of rows to
+// query, one more than requested to find out if there is a further page, and
+// whether the rows are to be queried in reverse order, as is the case for last.
+func (c *Cursor) keyset(typeName, table string, order []orderTerm, pk string, param func(interface{}) string) (string, int32, bool, error) {
+	if c.First != nil && c.Last != nil {
+		return "", 0, false, errors.New("first and last cannot be used together")
+	}
+
+	var pls []string
+	if c.After != nil {
+		id, err := decodeCursor(typeName, *c.After)
+		if err != nil {
+			return "", 0, false, err
+		}
+		pls = append(pls, keysetPredicate(table, order, pk, id, false, param))
+	}
+	if c.Before != nil {
+		id, err := decodeCursor(typeName, *c.Before)
+		if err != nil {
+			return "", 0, false, err
+		}
+		pls = append(pls, keysetPredicate(table, order, pk, id, true, param))
+	}
+
+	n := *c.Limit
+	if c.First != nil {
+		n = *c.First
+	} else if c.Last != nil {
+		n = *c.Last
+	}
+	if n < 0 {
+		return "", 0, false, fmt.Errorf("invalid page size %d", n)
+	}
+
+	where := ""
+	if len(pls) > 0 {
+		where = strings.Join(pls, " AND ") + " AND"
+	}
+	return where, n + 1, c.Last != nil, nil
+}
+
+// keysetPredicate returns the predicate selecting the rows from table that
+// come after the row with the primary key id, when ordered by order, or the
+// rows that come before it when before is set.
+func keysetPredicate(table string, order []orderTerm, pk string, id int, before bool, param func(interface{}) string) string {
+	ref := func(column string) string {
+		if column == pk {
+			return param(id)
+		}
+		return fmt.Sprintf("(SELECT %s FROM %s WHERE %s = %s)", column, table, pk, param(id))
+	}
+
+	// a row comes after on a term, and is equal on the previous terms
+	ors := make([]string, len(order))
+	for i, t := range order {
+		ands := make([]string, 0, i+1)
+		for _, prev := range order[:i] {
+			ands = append(ands, prev.equal(ref))
+		}
+		ands = append(ands, t.after(ref, before))
+		ors[i] = strings.Join(ands, " AND ")
+	}
+	if len(ors) == 1 {
+		return ors[0]
+	}
+	return fmt.Sprintf("((%s))", strings.Join(ors, ") OR ("))
+}
+
+// setPageInfo records the page boundaries of a keyset query, more reports
+// whether rows were found beyond the requested ones.
+func (c *Cursor) setPageInfo(more, reverse bool) {
+	if reverse {
+		c.hasNextPage, c.hasPreviousPage = c.Before != nil, more
+		return
+	}
+	c.hasNextPage, c.hasPreviousPage = more, c.After != nil
+}
+
+// pageInfo reports whether pages exist after and before a page of n rows out
+// of count rows.
+func (c *Cursor) pageInfo(n, count int) (bool, bool) {
+	if c.isKeyset() {
+		return c.hasNextPage, c.hasPreviousPage
+	}
+	return int(*c.Offset)+n < count, *c.Offset > 0
+}
+
 // sqlConjunctionMap supported conjunction, related to graphql enum: FilterConjunction
 var sqlConjunctionMap = map[string]struct{}{
 	"AND":{},
@@ -155,13 +562,119 @@ type filterPair struct{
 	value interface{}
 }
 
-// filterArguments filter arguments
+// sql returns the condition of the filter pair, param returns the placeholder
+// of a value and option converts the option to the driver dialect.
+func (p *filterPair) sql(param func(interface{}) string, option func(string) string) string {
+	switch p.option {
+	case "IS NULL", "IS NOT NULL":
+		return fmt.Sprintf("%s %s", p.fieldName, p.option)
+	case "IN", "NOT IN":
+		values := p.value.([]interface{})
+		if len(values) == 0 {
+			// nothing is in an empty list
+			if p.option == "IN" {
+				return "1 = 0"
+			}
+			return "1 = 1"
+		}
+		pls := make([]string, len(values))
+		for i, v := range values {
+			pls[i] = param(v)
+		}
+		return fmt.Sprintf("%s %s (%s)", p.fieldName, p.option, strings.Join(pls, ", "))
+	case "BETWEEN":
+		values := p.value.([]interface{})
+		return fmt.Sprintf("%s BETWEEN %s AND %s", p.fieldName, param(values[0]), param(values[1]))
+	}
+	return fmt.Sprintf("%s %s %s", p.fieldName, option(p.option), param(p.value))
+}
+
+// filterRelation filter on the rows of a related table, it matches if one of
+// the rows whose column equals the ref column matches the filter, or if none
+// does when not is set
+type filterRelation struct{
+	table string
+	column string
+	ref string
+	deleted string
+	filter *filterArguments
+	not bool
+}
+
+// sql returns the EXISTS subquery of the relation, scope is the table or the
+// alias the ref column belongs to and depth the nesting of the subquery.
+func (r *filterRelation) sql(scope string, depth int, param func(interface{}) string, option func(string) string) string {
+	alias := fmt.Sprintf("xo_r%d", depth+1)
+	where := fmt.Sprintf("%s.%s = %s.%s", alias, r.column, scope, r.ref)
+	if r.deleted != "" {
+		where += fmt.Sprintf(" AND %s.%s IS NULL", alias, r.deleted)
+	}
+	sql := fmt.Sprintf("EXISTS (SELECT 1 FROM %s %s WHERE %s AND %s)", r.table, alias, where, r.filter.scopeSQL(alias, depth+1, param, option))
+	if r.not {
+		sql = "NOT " + sql
+	}
+	return sql
+}
+
+// filterArguments filter arguments of the table, the filter pairs, the
+// relations and the nested groups are connected by the conjunction, AND if
+// empty, and negated by not
 type filterArguments struct{
+	table string
 	filterPairs []*filterPair
+	relations []*filterRelation
+	groups []*filterArguments
 	conjunction string
+	not bool
 	conjCnt int
 }
 
+// hasField reports whether the filter or one of its groups has a condition
+// on the field.
+func (f *filterArguments) hasField(fieldName string) bool {
+	for _, pair := range f.filterPairs {
+		if pair.fieldName == fieldName {
+			return true
+		}
+	}
+	for _, group := range f.groups {
+		if group.hasField(fieldName) {
+			return true
+		}
+	}
+	return false
+}
+
+// sql returns the parenthesized condition of the filter, param returns the
+// placeholder of a value and option converts the option to the driver dialect.
+func (f *filterArguments) sql(param func(interface{}) string, option func(string) string) string {
+	return f.scopeSQL(f.table, 0, param, option)
+}
+
+// scopeSQL returns the condition of the filter on the rows of scope, the
+// table or the alias of a relation subquery.
+func (f *filterArguments) scopeSQL(scope string, depth int, param func(interface{}) string, option func(string) string) string {
+	pls := make([]string, 0, len(f.filterPairs)+len(f.relations)+len(f.groups))
+	for _, pair := range f.filterPairs {
+		pls = append(pls, pair.sql(param, option))
+	}
+	for _, relation := range f.relations {
+		pls = append(pls, relation.sql(scope, depth, param, option))
+	}
+	for _, group := range f.groups {
+		pls = append(pls, group.scopeSQL(scope, depth, param, option))
+	}
+	conjunction := f.conjunction
+	if conjunction == "" {
+		conjunction = "AND"
+	}
+	sql := fmt.Sprintf("(%s)", strings.Join(pls, " "+conjunction+" "))
+	if f.not {
+		sql = "NOT " + sql
+	}
+	return sql
+}
+
 // updateArguments additional parameters when updating method
 type updateArguments struct {
 	Deletions *[]string
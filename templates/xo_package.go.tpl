@@ -18,5 +18,8 @@ import (
 	"github.com/graph-gophers/graphql-go"
 	"github.com/shopspring/decimal"
 	"github.com/mattn/go-oci8"
+{{- range .TypeOverrideImports }}
+	"{{ . }}"
+{{- end }}
 )
 